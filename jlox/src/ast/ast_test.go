@@ -0,0 +1,133 @@
+package ast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/parser"
+)
+
+const PROGRAM = `import "lib.lox" as lib;
+export var answer: number = 42;
+var empty;
+func add(a: number, b): number { return a + b; }
+func noop() {}
+class A { x: number; init() { this.x = 1; } }
+class B < A { get() { return super.get; } }
+var b = B();
+b.x = -(1 + 2) * 3;
+print add(1, 2) and nil or "text\n" != true;
+if (!false) { print b.x; } else print lib.answer;
+while (false) print 1;
+for (var i = 0; i < 3; i = i + 1) print i;
+for (;;) {}
+empty = add;
+`
+
+func parse(t *testing.T, source string) []grammar.Statement {
+	t.Helper()
+	lexer := &lexer.Lexer{Source: []rune(source)}
+	tokens, errs := lexer.Tokenize()
+	if len(errs) > 0 {
+		t.Fatalf("lexer errors %v", errs)
+	}
+	parser := parser.Parser{Tokens: tokens}
+	stmts, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("parser error %v", err)
+	}
+	return stmts
+}
+
+func TestRoundTrip(t *testing.T) {
+	stmts := parse(t, PROGRAM)
+
+	data, err := JSON(stmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ParseJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromJSON, stmts) {
+		t.Errorf("JSON round trip changed the tree:\n%s", data)
+	}
+
+	text, err := SExpr(stmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromSExpr, err := ParseSExpr(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromSExpr, stmts) {
+		t.Errorf("S-expression round trip changed the tree:\n%s", text)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	stmts := parse(t, "print a.b;")
+
+	text, err := SExpr(stmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `(PrintStatement
+  :keyword (token PRINT "print" nil 1 1)
+  :value (PropertyAccessExpression
+    :object (VariableDeclaration :name (token IDENTIFIER "a" nil 1 7))
+    :name (token IDENTIFIER "b" nil 1 9)))
+`
+	if text != expect {
+		t.Errorf("got\n%s\nwant\n%s", text, expect)
+	}
+
+	data, err := JSON(parse(t, "1;"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = `[
+  {
+    "kind": "ExpressionStatement",
+    "expression": {
+      "kind": "LiteralExpression",
+      "literal": 1
+    }
+  }
+]`
+	if string(data) != expect {
+		t.Errorf("got\n%s\nwant\n%s", data, expect)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		parse  func() error
+		expect string
+	}{
+		{"json not an array", func() error { _, err := ParseJSON([]byte(`{}`)); return err }, "expected an array"},
+		{"json unknown kind", func() error { _, err := ParseJSON([]byte(`[{"kind": "Loop"}]`)); return err }, `unknown node kind "Loop"`},
+		{"json unknown token", func() error {
+			_, err := ParseJSON([]byte(`[{"kind": "PrintStatement", "keyword": {"type": "SHOUT"}}]`))
+			return err
+		}, `unknown token type "SHOUT"`},
+		{"sexpr unbalanced", func() error { _, err := ParseSExpr("(PrintStatement :value"); return err }, "unexpected end of input"},
+		{"sexpr unknown symbol", func() error { _, err := ParseSExpr("(ExpressionStatement\n :expression maybe)"); return err }, `line 2: unknown symbol "maybe"`},
+		{"sexpr wrong field", func() error { _, err := ParseSExpr("(ExpressionStatement :expression [1])"); return err }, "expected a node, got []interface {}"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.parse()
+			if err == nil || !strings.Contains(err.Error(), tc.expect) {
+				t.Errorf("got error %v, want %q", err, tc.expect)
+			}
+		})
+	}
+}
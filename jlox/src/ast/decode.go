@@ -0,0 +1,264 @@
+package ast
+
+import (
+	"fmt"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+type decoder struct {
+	err error
+}
+
+// Decode converts the generic form back into statements.
+func Decode(nodes []any) ([]grammar.Statement, error) {
+	decoder := &decoder{}
+	stmts := make([]grammar.Statement, 0, len(nodes))
+	for _, node := range nodes {
+		stmts = append(stmts, decoder.statement(node))
+	}
+	if decoder.err != nil {
+		return nil, decoder.err
+	}
+	return stmts, nil
+}
+
+func (decoder *decoder) fail(format string, args ...any) {
+	if decoder.err == nil {
+		decoder.err = fmt.Errorf("ast: "+format, args...)
+	}
+}
+
+func (decoder *decoder) decode(value any) any {
+	if value == nil {
+		return nil
+	}
+	node, ok := value.(*Node)
+	if !ok {
+		decoder.fail("expected a node, got %T", value)
+		return nil
+	}
+
+	switch node.Kind {
+	case "ExpressionStatement":
+		return grammar.ExpressionStatement{Expression: decoder.expr(node, "expression")}
+	case "PrintStatement":
+		return grammar.PrintStatement{Keyword: decoder.token(node, "keyword"), Value: decoder.expr(node, "value")}
+	case "VariableDeclarationStatement":
+		return decoder.variable(node)
+	case "FunctionDeclarationStatement":
+		return decoder.function(node)
+	case "ClassDeclarationStatement":
+		class := grammar.ClassDeclarationStatement{Name: decoder.token(node, "name"), Super: decoder.expr(node, "super")}
+		if fields, ok := decoder.list(node, "fields"); ok {
+			class.Fields = make([]grammar.VariableDeclarationStatement, 0, len(fields))
+			for _, field := range fields {
+				if field, ok := decoder.kind(field, "VariableDeclarationStatement"); ok {
+					class.Fields = append(class.Fields, decoder.variable(field))
+				}
+			}
+		}
+		if methods, ok := decoder.list(node, "methods"); ok {
+			class.Methods = make([]grammar.FunctionDeclarationStatement, 0, len(methods))
+			for _, method := range methods {
+				if method, ok := decoder.kind(method, "FunctionDeclarationStatement"); ok {
+					class.Methods = append(class.Methods, decoder.function(method))
+				}
+			}
+		}
+		return class
+	case "ReturnStatement":
+		return grammar.ReturnStatement{Keyword: decoder.token(node, "keyword"), Expression: decoder.expr(node, "expression")}
+	case "BlockScopeStatement":
+		return decoder.block(node)
+	case "ConditionalStatement":
+		return grammar.ConditionalStatement{
+			Keyword:    decoder.token(node, "keyword"),
+			Condition:  decoder.expr(node, "condition"),
+			ThenBranch: decoder.stmt(node, "thenBranch"),
+			Else:       decoder.token(node, "else"),
+			ElseBranch: decoder.stmt(node, "elseBranch"),
+		}
+	case "WhileLoopStatement":
+		return grammar.WhileLoopStatement{Keyword: decoder.token(node, "keyword"), Condition: decoder.expr(node, "condition"), Body: decoder.stmt(node, "body")}
+	case "ImportStatement":
+		return grammar.ImportStatement{Keyword: decoder.token(node, "keyword"), Path: decoder.token(node, "path"), Alias: decoder.token(node, "alias")}
+	case "ExportStatement":
+		return grammar.ExportStatement{Keyword: decoder.token(node, "keyword"), Declaration: decoder.stmt(node, "declaration")}
+	case "BinaryExpression":
+		return grammar.BinaryExpression{Left: decoder.expr(node, "left"), Operator: decoder.token(node, "operator"), Right: decoder.expr(node, "right")}
+	case "UnaryExpression":
+		return grammar.UnaryExpression{Operator: decoder.token(node, "operator"), Right: decoder.expr(node, "right")}
+	case "LiteralExpression":
+		return grammar.LiteralExpression{Literal: decoder.literal(node, "literal")}
+	case "GroupingExpression":
+		return grammar.GroupingExpression{Expression: decoder.expr(node, "expression")}
+	case "VariableDeclaration":
+		return grammar.VariableDeclaration{Name: decoder.token(node, "name")}
+	case "AssignmentExpression":
+		return grammar.AssignmentExpression{Name: decoder.token(node, "name"), Value: decoder.expr(node, "value")}
+	case "LogicExpression":
+		return grammar.LogicExpression{Left: decoder.expr(node, "left"), Operator: decoder.token(node, "operator"), Right: decoder.expr(node, "right")}
+	case "CallExpression":
+		call := grammar.CallExpression{Callee: decoder.expr(node, "callee"), Paren: decoder.token(node, "paren")}
+		if arguments, ok := decoder.list(node, "arguments"); ok {
+			call.Arguments = make([]grammar.Expression, 0, len(arguments))
+			for _, argument := range arguments {
+				call.Arguments = append(call.Arguments, decoder.expression(argument))
+			}
+		}
+		return call
+	case "PropertyAccessExpression":
+		return grammar.PropertyAccessExpression{Object: decoder.expr(node, "object"), Name: decoder.token(node, "name")}
+	case "PropertyAssignmentExpression":
+		return grammar.PropertyAssignmentExpression{Object: decoder.expr(node, "object"), Name: decoder.token(node, "name"), Value: decoder.expr(node, "value")}
+	case "SelfReferenceExpression":
+		return grammar.SelfReferenceExpression{Keyword: decoder.token(node, "keyword")}
+	case "BaseClassCallExpression":
+		return grammar.BaseClassCallExpression{Keyword: decoder.token(node, "keyword"), Method: decoder.token(node, "method")}
+	}
+	decoder.fail("unknown node kind %q", node.Kind)
+	return nil
+}
+
+func (decoder *decoder) variable(node *Node) grammar.VariableDeclarationStatement {
+	return grammar.VariableDeclarationStatement{Name: decoder.token(node, "name"), Type: decoder.annotation(node, "type"), Initializer: decoder.expr(node, "initializer")}
+}
+
+func (decoder *decoder) function(node *Node) grammar.FunctionDeclarationStatement {
+	function := grammar.FunctionDeclarationStatement{Name: decoder.token(node, "name"), ReturnType: decoder.annotation(node, "returnType")}
+	if params, ok := decoder.list(node, "params"); ok {
+		function.Params = make([]grammar.Token, 0, len(params))
+		for _, param := range params {
+			token, ok := param.(grammar.Token)
+			if !ok {
+				decoder.fail("params of %s: expected a token, got %T", node.Kind, param)
+			}
+			function.Params = append(function.Params, token)
+		}
+	}
+	if annotations, ok := decoder.list(node, "paramTypes"); ok {
+		function.ParamTypes = make([]*grammar.TypeAnnotation, 0, len(annotations))
+		for _, annotation := range annotations {
+			function.ParamTypes = append(function.ParamTypes, decoder.typeAnnotation(annotation))
+		}
+	}
+	if body, ok := node.Get("body"); ok {
+		if body, ok := decoder.kind(body, "BlockScopeStatement"); ok {
+			function.Body = decoder.block(body)
+		}
+	}
+	return function
+}
+
+func (decoder *decoder) block(node *Node) grammar.BlockScopeStatement {
+	block := grammar.BlockScopeStatement{Brace: decoder.token(node, "brace")}
+	if stmts, ok := decoder.list(node, "statements"); ok {
+		block.Statements = make([]grammar.Statement, 0, len(stmts))
+		for _, stmt := range stmts {
+			block.Statements = append(block.Statements, decoder.statement(stmt))
+		}
+	}
+	return block
+}
+
+// kind checks that value is a node of the given kind.
+func (decoder *decoder) kind(value any, kind string) (*Node, bool) {
+	node, ok := value.(*Node)
+	if !ok || node.Kind != kind {
+		decoder.fail("expected a %s, got %v", kind, describe(value))
+		return nil, false
+	}
+	return node, true
+}
+
+func (decoder *decoder) expr(node *Node, name string) grammar.Expression {
+	value, _ := node.Get(name)
+	return decoder.expression(value)
+}
+
+func (decoder *decoder) stmt(node *Node, name string) grammar.Statement {
+	value, _ := node.Get(name)
+	return decoder.statement(value)
+}
+
+func (decoder *decoder) expression(value any) grammar.Expression {
+	decoded := decoder.decode(value)
+	if decoded == nil {
+		return nil
+	}
+	expr, ok := decoded.(grammar.Expression)
+	if !ok {
+		decoder.fail("expected an expression, got %v", describe(value))
+	}
+	return expr
+}
+
+func (decoder *decoder) statement(value any) grammar.Statement {
+	decoded := decoder.decode(value)
+	if decoded == nil {
+		return nil
+	}
+	stmt, ok := decoded.(grammar.Statement)
+	if !ok {
+		decoder.fail("expected a statement, got %v", describe(value))
+	}
+	return stmt
+}
+
+func (decoder *decoder) token(node *Node, name string) grammar.Token {
+	value, _ := node.Get(name)
+	switch value := value.(type) {
+	case nil:
+		return grammar.Token{}
+	case grammar.Token:
+		return value
+	}
+	decoder.fail("%s of %s: expected a token, got %v", name, node.Kind, describe(value))
+	return grammar.Token{}
+}
+
+func (decoder *decoder) list(node *Node, name string) ([]any, bool) {
+	value, _ := node.Get(name)
+	switch value := value.(type) {
+	case nil:
+		return nil, false
+	case []any:
+		return value, true
+	}
+	decoder.fail("%s of %s: expected a list, got %v", name, node.Kind, describe(value))
+	return nil, false
+}
+
+func (decoder *decoder) literal(node *Node, name string) any {
+	value, _ := node.Get(name)
+	switch value.(type) {
+	case nil, bool, float64, string:
+		return value
+	}
+	decoder.fail("%s of %s: expected a literal, got %v", name, node.Kind, describe(value))
+	return nil
+}
+
+func (decoder *decoder) annotation(node *Node, name string) *grammar.TypeAnnotation {
+	value, _ := node.Get(name)
+	return decoder.typeAnnotation(value)
+}
+
+func (decoder *decoder) typeAnnotation(value any) *grammar.TypeAnnotation {
+	if value == nil {
+		return nil
+	}
+	node, ok := decoder.kind(value, "TypeAnnotation")
+	if !ok {
+		return nil
+	}
+	return &grammar.TypeAnnotation{Name: decoder.token(node, "name")}
+}
+
+func describe(value any) string {
+	if node, ok := value.(*Node); ok {
+		return node.Kind
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,160 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+// object is a JSON object that keeps its keys in order, so nodes read
+// kind, position and then fields in declaration order.
+type object []member
+
+type member struct {
+	key   string
+	value any
+}
+
+func (object object) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for i, member := range object {
+		if i > 0 {
+			buffer.WriteByte(',')
+		}
+		key, _ := json.Marshal(member.key)
+		value, err := json.Marshal(member.value)
+		if err != nil {
+			return nil, err
+		}
+		buffer.Write(key)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+// JSON writes a program as an indented array of nodes. A node is an object
+// with its "kind", its "line" and "column" when it has a first token, and
+// one key per field. Tokens are objects with "type", "lexeme", "literal",
+// "line" and "column".
+func JSON(stmts []grammar.Statement) ([]byte, error) {
+	nodes, err := Encode(stmts)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(toJSON(nodes), "", "  ")
+}
+
+func toJSON(value any) any {
+	switch value := value.(type) {
+	case *Node:
+		fields := object{{"kind", value.Kind}}
+		if value.Line > 0 {
+			fields = append(fields, member{"line", value.Line}, member{"column", value.Column})
+		}
+		for _, field := range value.Fields {
+			fields = append(fields, member{field.Name, toJSON(field.Value)})
+		}
+		return fields
+	case grammar.Token:
+		return object{
+			{"type", grammar.TOKEN_NAMES[value.TokenType]},
+			{"lexeme", value.Lexeme},
+			{"literal", value.Literal},
+			{"line", value.Line},
+			{"column", value.Column},
+		}
+	case []any:
+		list := make([]any, 0, len(value))
+		for _, item := range value {
+			list = append(list, toJSON(item))
+		}
+		return list
+	}
+	return value
+}
+
+// ParseJSON reads a program written by JSON.
+func ParseJSON(data []byte) ([]grammar.Statement, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	list, ok := value.([]any)
+	if !ok {
+		return nil, fmt.Errorf("ast: expected an array of statements, got %T", value)
+	}
+	nodes, err := fromJSON(list)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(nodes.([]any))
+}
+
+func fromJSON(value any) (any, error) {
+	switch value := value.(type) {
+	case map[string]any:
+		if kind, ok := value["kind"].(string); ok {
+			return nodeFromJSON(kind, value)
+		}
+		return tokenFromJSON(value)
+	case []any:
+		list := make([]any, 0, len(value))
+		for _, item := range value {
+			converted, err := fromJSON(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, converted)
+		}
+		return list, nil
+	}
+	return value, nil
+}
+
+func nodeFromJSON(kind string, value map[string]any) (*Node, error) {
+	node := &Node{Kind: kind}
+	line, _ := value["line"].(float64)
+	column, _ := value["column"].(float64)
+	node.Line, node.Column = int(line), int(column)
+
+	names := make([]string, 0, len(value))
+	for name := range value {
+		if name != "kind" && name != "line" && name != "column" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field, err := fromJSON(value[name])
+		if err != nil {
+			return nil, err
+		}
+		node.Fields = append(node.Fields, Field{name, field})
+	}
+	return node, nil
+}
+
+func tokenFromJSON(value map[string]any) (grammar.Token, error) {
+	name, _ := value["type"].(string)
+	tokenType, ok := tokenTypes[name]
+	if !ok {
+		return grammar.Token{}, fmt.Errorf("ast: unknown token type %q", name)
+	}
+	line, _ := value["line"].(float64)
+	column, _ := value["column"].(float64)
+	return grammar.Token{TokenType: tokenType, Lexeme: value["lexeme"], Literal: value["literal"], Line: int(line), Column: int(column)}, nil
+}
+
+var tokenTypes = func() map[string]int {
+	types := make(map[string]int, len(grammar.TOKEN_NAMES))
+	for tokenType, name := range grammar.TOKEN_NAMES {
+		types[name] = tokenType
+	}
+	return types
+}()
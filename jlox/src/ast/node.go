@@ -0,0 +1,227 @@
+// Package ast serializes Lox syntax trees to JSON and to S-expressions and
+// reads them back into grammar types. Both forms are written from Node, a
+// generic tree that keeps every field of every grammar node, so decoding
+// gives back exactly the tree the parser produced.
+package ast
+
+import (
+	"fmt"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+// Node is a grammar node by kind, the Go type name such as
+// "BinaryExpression". Line and Column locate its first token and are zero
+// for nodes without one, like literals.
+type Node struct {
+	Kind   string
+	Line   int
+	Column int
+	Fields []Field
+}
+
+// Field values are *Node, grammar.Token, []any for slices, or the literals
+// nil, bool, float64 and string. A nil slice stays nil, so it can be told
+// apart from an empty one.
+type Field struct {
+	Name  string
+	Value any
+}
+
+func (node *Node) Get(name string) (any, bool) {
+	for _, field := range node.Fields {
+		if field.Name == name {
+			return field.Value, true
+		}
+	}
+	return nil, false
+}
+
+// newNode writes absent tokens, such as the alias of an import without one,
+// as nil rather than as a zero token.
+func newNode(kind string, source any, fields ...Field) *Node {
+	for i, field := range fields {
+		if token, ok := field.Value.(grammar.Token); ok && token == (grammar.Token{}) {
+			fields[i].Value = nil
+		}
+	}
+	node := &Node{Kind: kind, Fields: fields}
+	if token, ok := grammar.FirstToken(source); ok {
+		node.Line, node.Column = token.Line, token.Column
+	}
+	return node
+}
+
+type encoder struct {
+	err error
+}
+
+// Encode converts statements into their generic form.
+func Encode(stmts []grammar.Statement) ([]any, error) {
+	encoder := &encoder{}
+	nodes, _ := encoder.statements(stmts).([]any)
+	if encoder.err != nil {
+		return nil, encoder.err
+	}
+	return nodes, nil
+}
+
+func (encoder *encoder) encode(value any) any {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case grammar.Token:
+		return value
+	case *grammar.TypeAnnotation:
+		if value == nil {
+			return nil
+		}
+		return newNode("TypeAnnotation", value.Name, Field{"name", value.Name})
+	case grammar.ExpressionStatement:
+		return newNode("ExpressionStatement", value, Field{"expression", encoder.encode(value.Expression)})
+	case grammar.PrintStatement:
+		return newNode("PrintStatement", value, Field{"keyword", value.Keyword}, Field{"value", encoder.encode(value.Value)})
+	case grammar.VariableDeclarationStatement:
+		return newNode("VariableDeclarationStatement", value,
+			Field{"name", value.Name},
+			Field{"type", encoder.encode(value.Type)},
+			Field{"initializer", encoder.encode(value.Initializer)})
+	case grammar.FunctionDeclarationStatement:
+		annotations := any(nil)
+		if value.ParamTypes != nil {
+			list := make([]any, 0, len(value.ParamTypes))
+			for _, annotation := range value.ParamTypes {
+				list = append(list, encoder.encode(annotation))
+			}
+			annotations = list
+		}
+		return newNode("FunctionDeclarationStatement", value,
+			Field{"name", value.Name},
+			Field{"params", tokens(value.Params)},
+			Field{"paramTypes", annotations},
+			Field{"returnType", encoder.encode(value.ReturnType)},
+			Field{"body", encoder.encode(value.Body)})
+	case grammar.ClassDeclarationStatement:
+		fields, methods := any(nil), any(nil)
+		if value.Fields != nil {
+			list := make([]any, 0, len(value.Fields))
+			for _, field := range value.Fields {
+				list = append(list, encoder.encode(field))
+			}
+			fields = list
+		}
+		if value.Methods != nil {
+			list := make([]any, 0, len(value.Methods))
+			for _, method := range value.Methods {
+				list = append(list, encoder.encode(method))
+			}
+			methods = list
+		}
+		return newNode("ClassDeclarationStatement", value,
+			Field{"name", value.Name},
+			Field{"super", encoder.encode(value.Super)},
+			Field{"fields", fields},
+			Field{"methods", methods})
+	case grammar.ReturnStatement:
+		return newNode("ReturnStatement", value, Field{"keyword", value.Keyword}, Field{"expression", encoder.encode(value.Expression)})
+	case grammar.BlockScopeStatement:
+		return newNode("BlockScopeStatement", value, Field{"brace", value.Brace}, Field{"statements", encoder.statements(value.Statements)})
+	case grammar.ConditionalStatement:
+		return newNode("ConditionalStatement", value,
+			Field{"keyword", value.Keyword},
+			Field{"condition", encoder.encode(value.Condition)},
+			Field{"thenBranch", encoder.encode(value.ThenBranch)},
+			Field{"else", value.Else},
+			Field{"elseBranch", encoder.encode(value.ElseBranch)})
+	case grammar.WhileLoopStatement:
+		return newNode("WhileLoopStatement", value,
+			Field{"keyword", value.Keyword},
+			Field{"condition", encoder.encode(value.Condition)},
+			Field{"body", encoder.encode(value.Body)})
+	case grammar.ImportStatement:
+		return newNode("ImportStatement", value, Field{"keyword", value.Keyword}, Field{"path", value.Path}, Field{"alias", value.Alias})
+	case grammar.ExportStatement:
+		return newNode("ExportStatement", value, Field{"keyword", value.Keyword}, Field{"declaration", encoder.encode(value.Declaration)})
+	case grammar.BinaryExpression:
+		return newNode("BinaryExpression", value,
+			Field{"left", encoder.encode(value.Left)},
+			Field{"operator", value.Operator},
+			Field{"right", encoder.encode(value.Right)})
+	case grammar.UnaryExpression:
+		return newNode("UnaryExpression", value, Field{"operator", value.Operator}, Field{"right", encoder.encode(value.Right)})
+	case grammar.LiteralExpression:
+		return newNode("LiteralExpression", value, Field{"literal", encoder.literal(value.Literal)})
+	case grammar.GroupingExpression:
+		return newNode("GroupingExpression", value, Field{"expression", encoder.encode(value.Expression)})
+	case grammar.VariableDeclaration:
+		return newNode("VariableDeclaration", value, Field{"name", value.Name})
+	case grammar.AssignmentExpression:
+		return newNode("AssignmentExpression", value, Field{"name", value.Name}, Field{"value", encoder.encode(value.Value)})
+	case grammar.LogicExpression:
+		return newNode("LogicExpression", value,
+			Field{"left", encoder.encode(value.Left)},
+			Field{"operator", value.Operator},
+			Field{"right", encoder.encode(value.Right)})
+	case grammar.CallExpression:
+		arguments := any(nil)
+		if value.Arguments != nil {
+			list := make([]any, 0, len(value.Arguments))
+			for _, argument := range value.Arguments {
+				list = append(list, encoder.encode(argument))
+			}
+			arguments = list
+		}
+		return newNode("CallExpression", value,
+			Field{"callee", encoder.encode(value.Callee)},
+			Field{"paren", value.Paren},
+			Field{"arguments", arguments})
+	case grammar.PropertyAccessExpression:
+		return newNode("PropertyAccessExpression", value, Field{"object", encoder.encode(value.Object)}, Field{"name", value.Name})
+	case grammar.PropertyAssignmentExpression:
+		return newNode("PropertyAssignmentExpression", value,
+			Field{"object", encoder.encode(value.Object)},
+			Field{"name", value.Name},
+			Field{"value", encoder.encode(value.Value)})
+	case grammar.SelfReferenceExpression:
+		return newNode("SelfReferenceExpression", value, Field{"keyword", value.Keyword})
+	case grammar.BaseClassCallExpression:
+		return newNode("BaseClassCallExpression", value, Field{"keyword", value.Keyword}, Field{"method", value.Method})
+	}
+	if encoder.err == nil {
+		encoder.err = fmt.Errorf("ast: cannot encode %T", value)
+	}
+	return nil
+}
+
+func (encoder *encoder) statements(stmts []grammar.Statement) any {
+	if stmts == nil {
+		return nil
+	}
+	list := make([]any, 0, len(stmts))
+	for _, stmt := range stmts {
+		list = append(list, encoder.encode(stmt))
+	}
+	return list
+}
+
+func (encoder *encoder) literal(value any) any {
+	switch value.(type) {
+	case nil, bool, float64, string:
+		return value
+	}
+	if encoder.err == nil {
+		encoder.err = fmt.Errorf("ast: cannot encode literal %T", value)
+	}
+	return nil
+}
+
+func tokens(list []grammar.Token) any {
+	if list == nil {
+		return nil
+	}
+	values := make([]any, 0, len(list))
+	for _, token := range list {
+		values = append(values, token)
+	}
+	return values
+}
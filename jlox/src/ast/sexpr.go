@@ -0,0 +1,285 @@
+package ast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+const LINE_WIDTH = 80
+
+// SExpr writes a program as one S-expression per statement:
+//
+//	(ExpressionStatement
+//	  :expression (BinaryExpression
+//	    :left (LiteralExpression :literal 1)
+//	    :operator (token PLUS "+" nil 1 3)
+//	    :right (LiteralExpression :literal 2)))
+//
+// Nodes are lists headed by their kind with a keyword per field, tokens are
+// (token TYPE lexeme literal line column), slices are [vectors] and nil
+// marks absent values. Nodes that fit on a line are kept on one.
+func SExpr(stmts []grammar.Statement) (string, error) {
+	nodes, err := Encode(stmts)
+	if err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	for _, node := range nodes {
+		builder.WriteString(pretty(node, 0, 0))
+		builder.WriteByte('\n')
+	}
+	return builder.String(), nil
+}
+
+func inline(value any) string {
+	switch value := value.(type) {
+	case *Node:
+		parts := []string{value.Kind}
+		for _, field := range value.Fields {
+			parts = append(parts, ":"+field.Name, inline(field.Value))
+		}
+		return "(" + strings.Join(parts, " ") + ")"
+	case grammar.Token:
+		return fmt.Sprintf("(token %s %s %s %d %d)", grammar.TOKEN_NAMES[value.TokenType], atom(value.Lexeme), atom(value.Literal), value.Line, value.Column)
+	case []any:
+		parts := make([]string, 0, len(value))
+		for _, item := range value {
+			parts = append(parts, inline(item))
+		}
+		return "[" + strings.Join(parts, " ") + "]"
+	}
+	return atom(value)
+}
+
+// pretty breaks a node that does not fit into one field per line and a
+// vector that does not fit into one item per line. column is where the
+// value starts on its line.
+func pretty(value any, depth int, column int) string {
+	text := inline(value)
+	if column+len(text) <= LINE_WIDTH {
+		return text
+	}
+	padding := strings.Repeat("  ", depth+1)
+	switch value := value.(type) {
+	case *Node:
+		var builder strings.Builder
+		builder.WriteString("(" + value.Kind)
+		for _, field := range value.Fields {
+			prefix := padding + ":" + field.Name + " "
+			builder.WriteString("\n" + prefix + pretty(field.Value, depth+1, len(prefix)))
+		}
+		builder.WriteString(")")
+		return builder.String()
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			items = append(items, pretty(item, depth+1, len(padding)))
+		}
+		return "[\n" + padding + strings.Join(items, "\n"+padding) + "]"
+	}
+	return text
+}
+
+func atom(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case string:
+		return strconv.Quote(value)
+	}
+	return fmt.Sprintf("%q", fmt.Sprint(value))
+}
+
+// ParseSExpr reads a program written by SExpr. Text after ';' up to the end
+// of the line is a comment.
+func ParseSExpr(text string) ([]grammar.Statement, error) {
+	reader := &sexprReader{text: []rune(text), line: 1}
+	nodes := make([]any, 0)
+	for reader.skip(); reader.position < len(reader.text); reader.skip() {
+		value, err := reader.value()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, value)
+	}
+	return Decode(nodes)
+}
+
+type sexprReader struct {
+	text     []rune
+	position int
+	line     int
+}
+
+func (reader *sexprReader) errorf(format string, args ...any) error {
+	return fmt.Errorf("ast: line %d: %s", reader.line, fmt.Sprintf(format, args...))
+}
+
+func (reader *sexprReader) skip() {
+	for reader.position < len(reader.text) {
+		switch char := reader.text[reader.position]; {
+		case char == '\n':
+			reader.line++
+		case char == ';':
+			for reader.position < len(reader.text) && reader.text[reader.position] != '\n' {
+				reader.position++
+			}
+			continue
+		case !unicode.IsSpace(char):
+			return
+		}
+		reader.position++
+	}
+}
+
+func (reader *sexprReader) peek() rune {
+	reader.skip()
+	if reader.position >= len(reader.text) {
+		return 0
+	}
+	return reader.text[reader.position]
+}
+
+func (reader *sexprReader) value() (any, error) {
+	switch reader.peek() {
+	case 0:
+		return nil, reader.errorf("unexpected end of input")
+	case '(':
+		reader.position++
+		return reader.list()
+	case '[':
+		reader.position++
+		items := make([]any, 0)
+		for reader.peek() != ']' {
+			item, err := reader.value()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		reader.position++
+		return items, nil
+	case ')', ']':
+		return nil, reader.errorf("unexpected '%c'", reader.text[reader.position])
+	case '"':
+		return reader.string()
+	}
+	return reader.literal(reader.symbol())
+}
+
+// list reads a token or a node after its opening parenthesis.
+func (reader *sexprReader) list() (any, error) {
+	head := reader.symbol()
+	if head == "token" {
+		name := reader.symbol()
+		tokenType, ok := tokenTypes[name]
+		if !ok {
+			return nil, reader.errorf("unknown token type %q", name)
+		}
+		values := make([]any, 0, 4)
+		for i := 0; i < 4; i++ {
+			value, err := reader.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		line, lineOk := values[2].(float64)
+		column, columnOk := values[3].(float64)
+		if !lineOk || !columnOk {
+			return nil, reader.errorf("token position must be two numbers")
+		}
+		if err := reader.close(')'); err != nil {
+			return nil, err
+		}
+		return grammar.Token{TokenType: tokenType, Lexeme: values[0], Literal: values[1], Line: int(line), Column: int(column)}, nil
+	}
+
+	if head == "" {
+		return nil, reader.errorf("expected a node kind")
+	}
+	node := &Node{Kind: head}
+	for reader.peek() != ')' {
+		if reader.peek() != ':' {
+			return nil, reader.errorf("expected a field of %s", head)
+		}
+		reader.position++
+		name := reader.symbol()
+		value, err := reader.value()
+		if err != nil {
+			return nil, err
+		}
+		node.Fields = append(node.Fields, Field{name, value})
+	}
+	reader.position++
+	return node, nil
+}
+
+func (reader *sexprReader) close(char rune) error {
+	if reader.peek() != char {
+		return reader.errorf("expected '%c'", char)
+	}
+	reader.position++
+	return nil
+}
+
+func (reader *sexprReader) symbol() string {
+	reader.skip()
+	start := reader.position
+	for reader.position < len(reader.text) {
+		char := reader.text[reader.position]
+		if unicode.IsSpace(char) || strings.ContainsRune("()[];\"", char) {
+			break
+		}
+		reader.position++
+	}
+	return string(reader.text[start:reader.position])
+}
+
+func (reader *sexprReader) string() (string, error) {
+	start := reader.position
+	reader.position++
+	for reader.position < len(reader.text) && reader.text[reader.position] != '"' {
+		if reader.text[reader.position] == '\\' {
+			reader.position++
+		}
+		reader.position++
+	}
+	reader.position++
+	if reader.position > len(reader.text) {
+		return "", reader.errorf("unterminated string")
+	}
+	quoted := string(reader.text[start:reader.position])
+	reader.line += strings.Count(quoted, "\n")
+	value, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", reader.errorf("invalid string %s", quoted)
+	}
+	return value, nil
+}
+
+func (reader *sexprReader) literal(symbol string) (any, error) {
+	switch symbol {
+	case "nil":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "":
+		return nil, reader.errorf("unexpected '%c'", reader.text[reader.position])
+	}
+	number, err := strconv.ParseFloat(symbol, 64)
+	if err != nil {
+		return nil, reader.errorf("unknown symbol %q", symbol)
+	}
+	return number, nil
+}
@@ -0,0 +1,394 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+type TypeChecker struct {
+	Scopes          utils.Stack[map[string]Type]
+	Error           []grammar.LoxError
+	classes         map[string]*ClassType
+	currentFunction *FunctionType
+	currentClass    *ClassType
+}
+
+func (checker *TypeChecker) beginScope() {
+	checker.Scopes.Push(make(map[string]Type, 0))
+}
+
+func (checker *TypeChecker) endScope() {
+	checker.Scopes.Pop()
+}
+
+func (checker *TypeChecker) report(token grammar.Token, message string) {
+	checker.Error = append(checker.Error, CheckerError{Token: token, Message: message})
+}
+
+func (checker *TypeChecker) define(name grammar.Token, valueType Type) {
+	scope, err := checker.Scopes.Peek()
+	if err != nil {
+		return
+	}
+	scope[fmt.Sprintf("%s", name.Lexeme)] = valueType
+}
+
+func (checker *TypeChecker) lookup(name grammar.Token) Type {
+	lookup := fmt.Sprintf("%s", name.Lexeme)
+	for i := checker.Scopes.Len() - 1; i >= 0; i-- {
+		scope, err := checker.Scopes.Get(i)
+		if err != nil {
+			break
+		}
+		if valueType, ok := scope[lookup]; ok {
+			return valueType
+		}
+	}
+	return Type{Kind: ANY}
+}
+
+func (checker *TypeChecker) Check(statements []grammar.Statement) []grammar.LoxError {
+	checker.classes = make(map[string]*ClassType)
+	for _, stmt := range statements {
+		if export, ok := stmt.(grammar.ExportStatement); ok {
+			stmt = export.Declaration
+		}
+		if class, ok := stmt.(grammar.ClassDeclarationStatement); ok {
+			name := fmt.Sprintf("%s", class.Name.Lexeme)
+			checker.classes[name] = &ClassType{Name: name, Fields: make(map[string]Type), Methods: make(map[string]Type)}
+		}
+	}
+
+	checker.beginScope()
+	checker.checkStmts(statements)
+	checker.endScope()
+	return checker.Error
+}
+
+func (checker *TypeChecker) resolveAnnotation(annotation *grammar.TypeAnnotation) Type {
+	if annotation == nil {
+		return Type{Kind: ANY}
+	}
+	name := fmt.Sprintf("%s", annotation.Name.Lexeme)
+	if kind, ok := BUILTIN_TYPES[name]; ok {
+		return Type{Kind: kind}
+	}
+	if class, ok := checker.classes[name]; ok {
+		return Type{Kind: INSTANCE, Class: class}
+	}
+	checker.report(annotation.Name, fmt.Sprintf("Unknown type '%s'.", name))
+	return Type{Kind: ANY}
+}
+
+func (checker *TypeChecker) functionType(function grammar.FunctionDeclarationStatement) Type {
+	params := make([]Type, 0)
+	for i := range function.Params {
+		var annotation *grammar.TypeAnnotation
+		if i < len(function.ParamTypes) {
+			annotation = function.ParamTypes[i]
+		}
+		params = append(params, checker.resolveAnnotation(annotation))
+	}
+	return Type{Kind: FUNCTION, Function: &FunctionType{Params: params, Return: checker.resolveAnnotation(function.ReturnType)}}
+}
+
+func (checker *TypeChecker) checkStmts(statements []grammar.Statement) {
+	for _, stmt := range statements {
+		checker.checkStmt(stmt)
+	}
+}
+
+func (checker *TypeChecker) checkStmt(stmt grammar.Statement) {
+	switch stmtType := stmt.(type) {
+	case grammar.BlockScopeStatement:
+		checker.beginScope()
+		checker.checkStmts(stmtType.Statements)
+		checker.endScope()
+	case grammar.VariableDeclarationStatement:
+		checker.varStmt(stmtType)
+	case grammar.FunctionDeclarationStatement:
+		functionType := checker.functionType(stmtType)
+		checker.define(stmtType.Name, functionType)
+		checker.checkFunction(stmtType, functionType.Function)
+	case grammar.ClassDeclarationStatement:
+		checker.classStmt(stmtType)
+	case grammar.ExpressionStatement:
+		checker.typeOf(stmtType.Expression)
+	case grammar.PrintStatement:
+		checker.typeOf(stmtType.Value)
+	case grammar.ReturnStatement:
+		checker.returnStmt(stmtType)
+	case grammar.ConditionalStatement:
+		checker.typeOf(stmtType.Condition)
+		checker.checkStmt(stmtType.ThenBranch)
+		if stmtType.ElseBranch != nil {
+			checker.checkStmt(stmtType.ElseBranch)
+		}
+	case grammar.WhileLoopStatement:
+		checker.typeOf(stmtType.Condition)
+		checker.checkStmt(stmtType.Body)
+	case grammar.ImportStatement:
+		checker.define(stmtType.Alias, Type{Kind: ANY})
+	case grammar.ExportStatement:
+		checker.checkStmt(stmtType.Declaration)
+	}
+}
+
+func (checker *TypeChecker) varStmt(stmt grammar.VariableDeclarationStatement) {
+	declared := checker.resolveAnnotation(stmt.Type)
+	if stmt.Initializer != nil {
+		value := checker.typeOf(stmt.Initializer)
+		if !isAssignable(declared, value) {
+			checker.report(stmt.Name, fmt.Sprintf("Cannot assign value of type %v to variable '%v' of type %v.", value, stmt.Name.Lexeme, declared))
+		}
+	}
+	checker.define(stmt.Name, declared)
+}
+
+func (checker *TypeChecker) checkFunction(function grammar.FunctionDeclarationStatement, functionType *FunctionType) {
+	enclosingFunction := checker.currentFunction
+	checker.currentFunction = functionType
+	checker.beginScope()
+	for i, param := range function.Params {
+		checker.define(param, functionType.Params[i])
+	}
+	checker.checkStmts(function.Body.Statements)
+	checker.endScope()
+	checker.currentFunction = enclosingFunction
+}
+
+func (checker *TypeChecker) classStmt(stmt grammar.ClassDeclarationStatement) {
+	name := fmt.Sprintf("%s", stmt.Name.Lexeme)
+	class, ok := checker.classes[name]
+	if !ok {
+		class = &ClassType{Name: name, Fields: make(map[string]Type), Methods: make(map[string]Type)}
+		checker.classes[name] = class
+	}
+
+	if super, ok := stmt.Super.(grammar.VariableDeclaration); ok {
+		superType := checker.lookup(super.Name)
+		switch superType.Kind {
+		case CLASS:
+			class.Super = superType.Class
+		case ANY:
+		default:
+			checker.report(super.Name, "Superclass must be a class.")
+		}
+	}
+
+	for _, field := range stmt.Fields {
+		class.Fields[fmt.Sprintf("%s", field.Name.Lexeme)] = checker.resolveAnnotation(field.Type)
+	}
+	for _, method := range stmt.Methods {
+		class.Methods[fmt.Sprintf("%s", method.Name.Lexeme)] = checker.functionType(method)
+	}
+	checker.define(stmt.Name, Type{Kind: CLASS, Class: class})
+
+	enclosingClass := checker.currentClass
+	checker.currentClass = class
+	for _, method := range stmt.Methods {
+		methodType := class.Methods[fmt.Sprintf("%s", method.Name.Lexeme)]
+		checker.checkFunction(method, methodType.Function)
+	}
+	checker.currentClass = enclosingClass
+}
+
+func (checker *TypeChecker) returnStmt(stmt grammar.ReturnStatement) {
+	value := Type{Kind: NULL}
+	if stmt.Expression != nil {
+		value = checker.typeOf(stmt.Expression)
+	}
+	if checker.currentFunction == nil {
+		return
+	}
+	if !isAssignable(checker.currentFunction.Return, value) {
+		checker.report(stmt.Keyword, fmt.Sprintf("Cannot return value of type %v from function returning %v.", value, checker.currentFunction.Return))
+	}
+}
+
+func (checker *TypeChecker) typeOf(expr grammar.Expression) Type {
+	switch exprType := expr.(type) {
+	case grammar.LiteralExpression:
+		return literalType(exprType.Literal)
+	case grammar.GroupingExpression:
+		return checker.typeOf(exprType.Expression)
+	case grammar.UnaryExpression:
+		return checker.unaryExpr(exprType)
+	case grammar.BinaryExpression:
+		return checker.binaryExpr(exprType)
+	case grammar.LogicExpression:
+		left := checker.typeOf(exprType.Left)
+		right := checker.typeOf(exprType.Right)
+		if left.Kind == right.Kind && left.Kind != INSTANCE && left.Kind != CLASS {
+			return left
+		}
+		return Type{Kind: ANY}
+	case grammar.VariableDeclaration:
+		return checker.lookup(exprType.Name)
+	case grammar.AssignmentExpression:
+		value := checker.typeOf(exprType.Value)
+		declared := checker.lookup(exprType.Name)
+		if !isAssignable(declared, value) {
+			checker.report(exprType.Name, fmt.Sprintf("Cannot assign value of type %v to variable '%v' of type %v.", value, exprType.Name.Lexeme, declared))
+		}
+		return value
+	case grammar.CallExpression:
+		return checker.callExpr(exprType)
+	case grammar.PropertyAccessExpression:
+		return checker.propAccessExpr(exprType)
+	case grammar.PropertyAssignmentExpression:
+		return checker.propAssignmentExpr(exprType)
+	case grammar.SelfReferenceExpression:
+		if checker.currentClass != nil {
+			return Type{Kind: INSTANCE, Class: checker.currentClass}
+		}
+		return Type{Kind: ANY}
+	case grammar.BaseClassCallExpression:
+		if checker.currentClass != nil && checker.currentClass.Super != nil {
+			if method, ok := checker.currentClass.Super.findMethod(fmt.Sprintf("%s", exprType.Method.Lexeme)); ok {
+				return method
+			}
+		}
+		return Type{Kind: ANY}
+	default:
+		return Type{Kind: ANY}
+	}
+}
+
+func literalType(literal any) Type {
+	switch literal.(type) {
+	case float64:
+		return Type{Kind: NUMBER}
+	case string:
+		return Type{Kind: STRING}
+	case bool:
+		return Type{Kind: BOOL}
+	case nil:
+		return Type{Kind: NULL}
+	default:
+		return Type{Kind: ANY}
+	}
+}
+
+func (checker *TypeChecker) unaryExpr(expr grammar.UnaryExpression) Type {
+	right := checker.typeOf(expr.Right)
+	switch expr.Operator.TokenType {
+	case grammar.MINUS:
+		if right.Kind != ANY && right.Kind != NUMBER {
+			checker.report(expr.Operator, "Operand must be a number.")
+		}
+		return Type{Kind: NUMBER}
+	case grammar.BANG:
+		return Type{Kind: BOOL}
+	}
+	return Type{Kind: ANY}
+}
+
+func (checker *TypeChecker) binaryExpr(expr grammar.BinaryExpression) Type {
+	left := checker.typeOf(expr.Left)
+	right := checker.typeOf(expr.Right)
+	isNumeric := func(t Type) bool { return t.Kind == ANY || t.Kind == NUMBER }
+
+	switch expr.Operator.TokenType {
+	case grammar.MINUS, grammar.SLASH, grammar.STAR:
+		if !isNumeric(left) || !isNumeric(right) {
+			checker.report(expr.Operator, "Operands must be numbers.")
+		}
+		return Type{Kind: NUMBER}
+	case grammar.GREATER, grammar.GREATER_EQUAL, grammar.LESS, grammar.LESS_EQUAL:
+		if !isNumeric(left) || !isNumeric(right) {
+			checker.report(expr.Operator, "Operands must be numbers.")
+		}
+		return Type{Kind: BOOL}
+	case grammar.PLUS:
+		isAddable := func(t Type) bool { return t.Kind == ANY || t.Kind == NUMBER || t.Kind == STRING }
+		if !isAddable(left) || !isAddable(right) || (left.Kind != ANY && right.Kind != ANY && left.Kind != right.Kind) {
+			checker.report(expr.Operator, "Operands must be two numbers or two strings.")
+			return Type{Kind: ANY}
+		}
+		if left.Kind != ANY {
+			return left
+		}
+		return right
+	case grammar.BANG_EQUAL, grammar.EQUAL_EQUAL:
+		return Type{Kind: BOOL}
+	}
+	return Type{Kind: ANY}
+}
+
+func (checker *TypeChecker) callExpr(expr grammar.CallExpression) Type {
+	callee := checker.typeOf(expr.Callee)
+	arguments := make([]Type, 0)
+	for _, argument := range expr.Arguments {
+		arguments = append(arguments, checker.typeOf(argument))
+	}
+
+	switch callee.Kind {
+	case FUNCTION:
+		if callee.Function == nil {
+			return Type{Kind: ANY}
+		}
+		checker.checkArguments(expr.Paren, callee.Function, arguments)
+		return callee.Function.Return
+	case CLASS:
+		if constructor, ok := callee.Class.findMethod(runtime.CONSTRUCTOR); ok {
+			checker.checkArguments(expr.Paren, constructor.Function, arguments)
+		} else if len(arguments) != 0 {
+			checker.report(expr.Paren, fmt.Sprintf("Expect 0 arguments but got %v.", len(arguments)))
+		}
+		return Type{Kind: INSTANCE, Class: callee.Class}
+	case ANY:
+		return Type{Kind: ANY}
+	default:
+		checker.report(expr.Paren, fmt.Sprintf("Can't call value of type %v.", callee))
+		return Type{Kind: ANY}
+	}
+}
+
+func (checker *TypeChecker) checkArguments(paren grammar.Token, function *FunctionType, arguments []Type) {
+	if len(arguments) != len(function.Params) {
+		checker.report(paren, fmt.Sprintf("Expect %v arguments but got %v.", len(function.Params), len(arguments)))
+		return
+	}
+	for i, argument := range arguments {
+		if !isAssignable(function.Params[i], argument) {
+			checker.report(paren, fmt.Sprintf("Argument %v has type %v but parameter expects %v.", i+1, argument, function.Params[i]))
+		}
+	}
+}
+
+func (checker *TypeChecker) propAccessExpr(expr grammar.PropertyAccessExpression) Type {
+	object := checker.typeOf(expr.Object)
+	name := fmt.Sprintf("%s", expr.Name.Lexeme)
+	switch object.Kind {
+	case INSTANCE:
+		if field, ok := object.Class.findField(name); ok {
+			return field
+		}
+		if method, ok := object.Class.findMethod(name); ok {
+			return method
+		}
+	case ANY:
+	default:
+		checker.report(expr.Name, "Only instances have properties.")
+	}
+	return Type{Kind: ANY}
+}
+
+func (checker *TypeChecker) propAssignmentExpr(expr grammar.PropertyAssignmentExpression) Type {
+	object := checker.typeOf(expr.Object)
+	value := checker.typeOf(expr.Value)
+	switch object.Kind {
+	case INSTANCE:
+		if field, ok := object.Class.findField(fmt.Sprintf("%s", expr.Name.Lexeme)); ok && !isAssignable(field, value) {
+			checker.report(expr.Name, fmt.Sprintf("Cannot assign value of type %v to field '%v' of type %v.", value, expr.Name.Lexeme, field))
+		}
+	case ANY:
+	default:
+		checker.report(expr.Name, "Only instances have fields.")
+	}
+	return value
+}
@@ -0,0 +1,49 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/parser"
+)
+
+func TestCheck(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+		expect []string
+	}{
+		{"untyped code is accepted", "var a = 1; a = \"b\"; print a;", nil},
+		{"annotated variable", "var a: number = \"b\";", []string{"Cannot assign value of type string to variable 'a' of type number."}},
+		{"numeric operands", "print \"a\" - 1;", []string{"Operands must be numbers."}},
+		{"mixed addition", "print \"a\" + 1;", []string{"Operands must be two numbers or two strings."}},
+		{"argument types", "func f(a: string) {} f(1);", []string{"Argument 1 has type number but parameter expects string."}},
+		{"return type", "func f(): bool { return 1; }", []string{"Cannot return value of type number from function returning bool."}},
+		{"field types", "class A { x: number; set() { this.x = true; } }", []string{"Cannot assign value of type bool to field 'x' of type number."}},
+		{"subclass instance", "class A {} class B < A {} var a: A = B();", nil},
+		{"unknown type", "var a: Foo;", []string{"Unknown type 'Foo'."}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens, lexErrs := lexer.Lexer{Source: []rune(tc.source)}.Tokenize()
+			if len(lexErrs) > 0 {
+				t.Fatalf("lexer errors: %v", lexErrs)
+			}
+			stmts, err := parser.Parser{Tokens: tokens}.Parse()
+			if err != nil {
+				t.Fatalf("parser error: %v", err)
+			}
+			checker := TypeChecker{}
+			errs := checker.Check(stmts)
+			if len(errs) != len(tc.expect) {
+				t.Fatalf("got %v, want %v", errs, tc.expect)
+			}
+			for i, err := range errs {
+				if err.(CheckerError).Message != tc.expect[i] {
+					t.Errorf("got %v, want %v", err.(CheckerError).Message, tc.expect[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+type CheckerError struct {
+	Token   grammar.Token
+	Message string
+}
+
+func (e CheckerError) Print() {
+	fmt.Printf("[%d]: Type error at '%v': %s\n", e.Token.Line, e.Token.Lexeme, e.Message)
+}
+
+func (e CheckerError) Error() string {
+	return fmt.Sprintf("[%d]: Type error at '%v': %s", e.Token.Line, e.Token.Lexeme, e.Message)
+}
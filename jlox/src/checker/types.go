@@ -0,0 +1,105 @@
+package checker
+
+import "fmt"
+
+const (
+	ANY = iota
+	NUMBER
+	STRING
+	BOOL
+	NULL
+	FUNCTION
+	CLASS
+	INSTANCE
+)
+
+var BUILTIN_TYPES = map[string]int{
+	"any":    ANY,
+	"number": NUMBER,
+	"string": STRING,
+	"bool":   BOOL,
+	"null":   NULL,
+	"func":   FUNCTION,
+}
+
+type Type struct {
+	Kind     int
+	Function *FunctionType
+	Class    *ClassType
+}
+
+type FunctionType struct {
+	Params []Type
+	Return Type
+}
+
+type ClassType struct {
+	Name    string
+	Super   *ClassType
+	Fields  map[string]Type
+	Methods map[string]Type
+}
+
+func (class *ClassType) findField(name string) (Type, bool) {
+	for current := class; current != nil; current = current.Super {
+		if field, ok := current.Fields[name]; ok {
+			return field, true
+		}
+	}
+	return Type{}, false
+}
+
+func (class *ClassType) findMethod(name string) (Type, bool) {
+	for current := class; current != nil; current = current.Super {
+		if method, ok := current.Methods[name]; ok {
+			return method, true
+		}
+	}
+	return Type{}, false
+}
+
+func (class *ClassType) inherits(other *ClassType) bool {
+	for current := class; current != nil; current = current.Super {
+		if current == other {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Type) String() string {
+	switch t.Kind {
+	case NUMBER:
+		return "number"
+	case STRING:
+		return "string"
+	case BOOL:
+		return "bool"
+	case NULL:
+		return "null"
+	case FUNCTION:
+		return "func"
+	case CLASS:
+		return fmt.Sprintf("class %s", t.Class.Name)
+	case INSTANCE:
+		return t.Class.Name
+	default:
+		return "any"
+	}
+}
+
+func isAssignable(target Type, value Type) bool {
+	if target.Kind == ANY || value.Kind == ANY {
+		return true
+	}
+	if target.Kind == INSTANCE && value.Kind == NULL {
+		return true
+	}
+	if target.Kind != value.Kind {
+		return false
+	}
+	if target.Kind == INSTANCE || target.Kind == CLASS {
+		return value.Class.inherits(target.Class)
+	}
+	return true
+}
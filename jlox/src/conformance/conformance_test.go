@@ -0,0 +1,72 @@
+package conformance
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConformance(t *testing.T) {
+	err := filepath.WalkDir("testdata", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".lox" {
+			return err
+		}
+
+		name, _ := filepath.Rel("testdata", path)
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			source, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := Expected(string(source))
+			mismatches := Diff(expected, Run(path))
+			if expected.KnownFailure != "" {
+				if len(mismatches) == 0 {
+					t.Fatal("passes, remove its known failure comment")
+				}
+				t.Skipf("known failure: %s", expected.KnownFailure)
+			}
+			for _, mismatch := range mismatches {
+				t.Error(mismatch)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExpected(t *testing.T) {
+	source := strings.Join([]string{
+		`print 1; // expect: 1`,
+		`print "";  // expect: `,
+		`a = ; // expect error: Expect expression.`,
+		`// [line 7] expect error: Expect ';' after value.`,
+		`print -"a"; // expect runtime error: Operand must be a number.`,
+		`// known failure: negation is broken.`,
+	}, "\n")
+
+	expected := Expected(source)
+	if expected.KnownFailure != "negation is broken." {
+		t.Errorf("got known failure %q", expected.KnownFailure)
+	}
+	if len(expected.Output) != 2 || expected.Output[0] != "1" || expected.Output[1] != "" {
+		t.Errorf("got output %q", expected.Output)
+	}
+	want := []Diagnostic{
+		{Kind: COMPILE_ERROR, Line: 3, Message: "Expect expression."},
+		{Kind: COMPILE_ERROR, Line: 7, Message: "Expect ';' after value."},
+		{Kind: RUNTIME_ERROR, Line: 5, Message: "Operand must be a number."},
+	}
+	if len(expected.Errors) != len(want) {
+		t.Fatalf("got errors %v, want %v", expected.Errors, want)
+	}
+	for i := range want {
+		if expected.Errors[i] != want[i] {
+			t.Errorf("got %v, want %v", expected.Errors[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Package conformance runs .lox scripts annotated with expectation comments
+// in the style of the Crafting Interpreters test suite:
+//
+//	print 1 + 2; // expect: 3
+//	print -"a";  // expect runtime error: Operand must be a number.
+//	var a = ;    // expect error: Expect expression.
+//
+// A script the interpreter does not pass yet says why in a known failure
+// comment, which turns its mismatches into a skip:
+//
+//	// known failure: a runtime error does not stop the statements after it.
+package conformance
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DrEmbryo/jlox/src/lox"
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+const (
+	COMPILE_ERROR = "compile"
+	RUNTIME_ERROR = "runtime"
+)
+
+var DEFAULT_LIMITS = runtime.Limits{MaxSteps: 1_000_000, Timeout: 5 * time.Second}
+
+var (
+	expectOutput       = regexp.MustCompile(`// expect: ?(.*)$`)
+	expectRuntimeError = regexp.MustCompile(`// expect runtime error: (.+)$`)
+	expectError        = regexp.MustCompile(`// (?:\[line (\d+)\] )?expect error: (.+)$`)
+	knownFailure       = regexp.MustCompile(`// known failure: (.+)$`)
+)
+
+type Diagnostic struct {
+	Kind    string
+	Line    int
+	Message string
+}
+
+func (diagnostic Diagnostic) String() string {
+	return fmt.Sprintf("[line %d] %s error: %s", diagnostic.Line, diagnostic.Kind, diagnostic.Message)
+}
+
+type Outcome struct {
+	Output       []string
+	Errors       []Diagnostic
+	KnownFailure string
+}
+
+func Expected(source string) Outcome {
+	expected := Outcome{Output: make([]string, 0), Errors: make([]Diagnostic, 0)}
+	scanner := bufio.NewScanner(strings.NewReader(source))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if match := expectOutput.FindStringSubmatch(text); match != nil {
+			expected.Output = append(expected.Output, match[1])
+		}
+		if match := expectRuntimeError.FindStringSubmatch(text); match != nil {
+			expected.Errors = append(expected.Errors, Diagnostic{Kind: RUNTIME_ERROR, Line: line, Message: match[1]})
+		}
+		if match := expectError.FindStringSubmatch(text); match != nil {
+			diagnostic := Diagnostic{Kind: COMPILE_ERROR, Line: line, Message: match[2]}
+			if match[1] != "" {
+				fmt.Sscan(match[1], &diagnostic.Line)
+			}
+			expected.Errors = append(expected.Errors, diagnostic)
+		}
+		if match := knownFailure.FindStringSubmatch(text); match != nil {
+			expected.KnownFailure = match[1]
+		}
+	}
+	return expected
+}
+
+func Run(path string) Outcome {
+	var stdout strings.Builder
+	interpreter := lox.New(lox.Options{Stdout: &stdout, Limits: DEFAULT_LIMITS})
+	_, err := interpreter.RunFile(path)
+
+	actual := Outcome{Output: Lines(stdout.String()), Errors: make([]Diagnostic, 0)}
+	var loxErr lox.Error
+	if errors.As(err, &loxErr) {
+		kind := RUNTIME_ERROR
+		if loxErr.IsCompileError() {
+			kind = COMPILE_ERROR
+		}
+		for _, e := range loxErr.Errors {
+			actual.Errors = append(actual.Errors, Diagnostic{Kind: kind, Line: lox.Line(e), Message: strings.TrimSpace(lox.Message(e))})
+		}
+	} else if err != nil {
+		actual.Errors = append(actual.Errors, Diagnostic{Kind: COMPILE_ERROR, Message: err.Error()})
+	}
+	return actual
+}
+
+func Check(path string) ([]string, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Diff(Expected(string(source)), Run(path)), nil
+}
+
+func Diff(expected Outcome, actual Outcome) []string {
+	mismatches := make([]string, 0)
+	for i := 0; i < max(len(expected.Output), len(actual.Output)); i++ {
+		switch {
+		case i >= len(actual.Output):
+			mismatches = append(mismatches, fmt.Sprintf("missing output %q", expected.Output[i]))
+		case i >= len(expected.Output):
+			mismatches = append(mismatches, fmt.Sprintf("unexpected output %q", actual.Output[i]))
+		case expected.Output[i] != actual.Output[i]:
+			mismatches = append(mismatches, fmt.Sprintf("expected output %q but got %q", expected.Output[i], actual.Output[i]))
+		}
+	}
+	for i := 0; i < max(len(expected.Errors), len(actual.Errors)); i++ {
+		switch {
+		case i >= len(actual.Errors):
+			mismatches = append(mismatches, fmt.Sprintf("missing %v", expected.Errors[i]))
+		case i >= len(expected.Errors):
+			mismatches = append(mismatches, fmt.Sprintf("unexpected %v", actual.Errors[i]))
+		case expected.Errors[i] != actual.Errors[i]:
+			mismatches = append(mismatches, fmt.Sprintf("expected %v but got %v", expected.Errors[i], actual.Errors[i]))
+		}
+	}
+	return mismatches
+}
+
+func Lines(output string) []string {
+	if output == "" {
+		return make([]string, 0)
+	}
+	return strings.Split(strings.TrimSuffix(output, "\n"), "\n")
+}
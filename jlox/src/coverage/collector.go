@@ -0,0 +1,152 @@
+package coverage
+
+import (
+	"fmt"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+// Collector counts statement and branch executions for one script.
+type Collector struct {
+	Path string
+	file *File
+}
+
+func (collector *Collector) init() {
+	if collector.file == nil {
+		collector.file = &File{Lines: make(map[int]int)}
+	}
+}
+
+// Instrument registers every statement line and branch point of the program
+// with a zero count, so code that never runs shows up as uncovered.
+func (collector *Collector) Instrument(stmts []grammar.Statement) {
+	collector.init()
+	for _, stmt := range stmts {
+		collector.statement(stmt)
+	}
+}
+
+func (collector *Collector) Profile() *Profile {
+	collector.init()
+	return &Profile{Files: map[string]*File{collector.Path: collector.file}}
+}
+
+func (collector *Collector) Statement(interpreter *runtime.Interpreter, stmt grammar.Statement) grammar.LoxError {
+	if line, ok := statementLine(stmt); ok {
+		collector.init()
+		collector.file.Lines[line]++
+	}
+	return nil
+}
+
+func (collector *Collector) EnterFunction(interpreter *runtime.Interpreter, function runtime.LoxCallable) {
+}
+
+func (collector *Collector) ExitFunction(interpreter *runtime.Interpreter, function runtime.LoxCallable) {
+}
+
+func (collector *Collector) Branch(interpreter *runtime.Interpreter, node any, taken bool) {
+	token, ok := branchToken(node)
+	if !ok {
+		return
+	}
+	collector.init()
+	branch := collector.file.branch(token.Line, token.Column, fmt.Sprintf("%v", token.Lexeme))
+	if taken {
+		branch.Taken++
+	} else {
+		branch.NotTaken++
+	}
+}
+
+func statementLine(stmt grammar.Statement) (int, bool) {
+	switch stmt.(type) {
+	case nil, grammar.BlockScopeStatement, grammar.ExportStatement:
+		return 0, false
+	}
+	token, ok := grammar.FirstToken(stmt)
+	return token.Line, ok && token.Line > 0
+}
+
+func branchToken(node any) (grammar.Token, bool) {
+	switch node := node.(type) {
+	case grammar.ConditionalStatement:
+		return node.Keyword, true
+	case grammar.WhileLoopStatement:
+		return node.Keyword, true
+	case grammar.LogicExpression:
+		return node.Operator, true
+	}
+	return grammar.Token{}, false
+}
+
+func (collector *Collector) register(node any) {
+	if token, ok := branchToken(node); ok {
+		collector.file.branch(token.Line, token.Column, fmt.Sprintf("%v", token.Lexeme))
+	}
+}
+
+func (collector *Collector) statement(stmt grammar.Statement) {
+	if line, ok := statementLine(stmt); ok {
+		collector.file.Lines[line] += 0
+	}
+	switch stmt := stmt.(type) {
+	case grammar.ExpressionStatement:
+		collector.expression(stmt.Expression)
+	case grammar.PrintStatement:
+		collector.expression(stmt.Value)
+	case grammar.VariableDeclarationStatement:
+		collector.expression(stmt.Initializer)
+	case grammar.ReturnStatement:
+		collector.expression(stmt.Expression)
+	case grammar.BlockScopeStatement:
+		collector.Instrument(stmt.Statements)
+	case grammar.FunctionDeclarationStatement:
+		collector.Instrument(stmt.Body.Statements)
+	case grammar.ClassDeclarationStatement:
+		for _, method := range stmt.Methods {
+			collector.Instrument(method.Body.Statements)
+		}
+	case grammar.ConditionalStatement:
+		collector.register(stmt)
+		collector.expression(stmt.Condition)
+		collector.statement(stmt.ThenBranch)
+		collector.statement(stmt.ElseBranch)
+	case grammar.WhileLoopStatement:
+		collector.register(stmt)
+		collector.expression(stmt.Condition)
+		collector.statement(stmt.Body)
+	case grammar.ExportStatement:
+		collector.statement(stmt.Declaration)
+	}
+}
+
+func (collector *Collector) expression(expr grammar.Expression) {
+	switch expr := expr.(type) {
+	case grammar.LogicExpression:
+		collector.register(expr)
+		collector.expression(expr.Left)
+		collector.expression(expr.Right)
+	case grammar.BinaryExpression:
+		collector.expression(expr.Left)
+		collector.expression(expr.Right)
+	case grammar.UnaryExpression:
+		collector.expression(expr.Right)
+	case grammar.GroupingExpression:
+		collector.expression(expr.Expression)
+	case grammar.AssignmentExpression:
+		collector.expression(expr.Value)
+	case grammar.CallExpression:
+		collector.expression(expr.Callee)
+		for _, argument := range expr.Arguments {
+			collector.expression(argument)
+		}
+	case grammar.PropertyAccessExpression:
+		collector.expression(expr.Object)
+	case grammar.PropertyAssignmentExpression:
+		collector.expression(expr.Object)
+		collector.expression(expr.Value)
+	}
+}
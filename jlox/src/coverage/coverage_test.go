@@ -0,0 +1,116 @@
+package coverage
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/lox"
+	"github.com/DrEmbryo/jlox/src/parser"
+)
+
+const PROGRAM = `func sign(n) {
+  if (n < 0) {
+    return -1;
+  }
+  return 1;
+}
+var small = sign(2);
+var both = true and small > 0;
+print both;
+`
+
+func collect(t *testing.T, source string) *Profile {
+	tokens, lexErrs := lexer.Lexer{Source: []rune(source)}.Tokenize()
+	if len(lexErrs) > 0 {
+		t.Fatal(lexErrs)
+	}
+	stmts, err := parser.Parser{Tokens: tokens}.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	collector := &Collector{Path: "test.lox"}
+	collector.Instrument(stmts)
+	if _, err := lox.New(lox.Options{Hook: collector, Stdout: &bytes.Buffer{}}).Eval(source); err != nil {
+		t.Fatal(err)
+	}
+	return collector.Profile()
+}
+
+func TestCollector(t *testing.T) {
+	file := collect(t, PROGRAM).Files["test.lox"]
+
+	lines := map[int]int{1: 1, 2: 1, 3: 0, 5: 1, 7: 1, 8: 1, 9: 1}
+	for line, hits := range lines {
+		if file.Lines[line] != hits {
+			t.Errorf("line %d: expected %d hits, got %d", line, hits, file.Lines[line])
+		}
+	}
+	if len(file.Lines) != len(lines) {
+		t.Errorf("expected lines %v, got %v", lines, file.Lines)
+	}
+
+	branches := []Branch{
+		{Line: 2, Column: 3, Kind: "if", Taken: 0, NotTaken: 1},
+		{Line: 8, Column: 17, Kind: "and", Taken: 1, NotTaken: 0},
+	}
+	if len(file.Branches) != len(branches) {
+		t.Fatalf("expected %d branches, got %d", len(branches), len(file.Branches))
+	}
+	for i, branch := range branches {
+		if *file.Branches[i] != branch {
+			t.Errorf("expected %+v, got %+v", branch, *file.Branches[i])
+		}
+	}
+}
+
+func TestLoopBranches(t *testing.T) {
+	var tests = []struct {
+		name       string
+		iterations int
+	}{
+		{"never", 0},
+		{"once", 1},
+		{"several times", 5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := collect(t, fmt.Sprintf("var i = 0;\nwhile (i < %d)\n  i = i + 1;\n", tc.iterations)).Files["test.lox"]
+
+			expect := Branch{Line: 2, Column: 1, Kind: "while", Taken: tc.iterations, NotTaken: 1}
+			if len(file.Branches) != 1 || *file.Branches[0] != expect {
+				t.Fatalf("expected %+v, got %v", expect, file.Branches)
+			}
+			if file.Lines[3] != tc.iterations {
+				t.Errorf("expected the body to run %d times, got %d", tc.iterations, file.Lines[3])
+			}
+		})
+	}
+}
+
+func TestMergeAndReports(t *testing.T) {
+	merged := &Profile{}
+	merged.Merge(collect(t, PROGRAM))
+	merged.Merge(collect(t, strings.Replace(PROGRAM, "sign(2)", "sign(-2)", 1)))
+
+	var text bytes.Buffer
+	merged.WriteText(&text)
+	expected := `test.lox: lines 7/7 (100.0%), branches 3/4 (75.0%)
+  and at 8:17: always taken
+total: lines 7/7 (100.0%), branches 3/4 (75.0%)
+`
+	if text.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, text.String())
+	}
+
+	var lcov bytes.Buffer
+	merged.WriteLcov(&lcov)
+	for _, record := range []string{"SF:test.lox\n", "DA:3,1\n", "DA:7,2\n", "LF:7\nLH:7\n", "BRDA:2,0,0,1\nBRDA:2,0,1,1\n", "BRDA:8,1,1,0\n", "BRF:4\nBRH:3\nend_of_record\n"} {
+		if !strings.Contains(lcov.String(), record) {
+			t.Errorf("expected lcov to contain %q, got:\n%s", record, lcov.String())
+		}
+	}
+}
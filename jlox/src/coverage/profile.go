@@ -0,0 +1,142 @@
+// Package coverage records which statements and branches of a Lox script
+// ran. A Collector is installed as the interpreter's runtime.Hook; the
+// Profile it fills can be saved, merged with the profiles of other runs and
+// reported as text, HTML or lcov.
+package coverage
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"sort"
+)
+
+type Branch struct {
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Kind     string `json:"kind"`
+	Taken    int    `json:"taken"`
+	NotTaken int    `json:"notTaken"`
+}
+
+type File struct {
+	Lines    map[int]int `json:"lines"`
+	Branches []*Branch   `json:"branches"`
+}
+
+type Profile struct {
+	Files map[string]*File `json:"files"`
+}
+
+func (profile *Profile) File(path string) *File {
+	if profile.Files == nil {
+		profile.Files = make(map[string]*File)
+	}
+	file, ok := profile.Files[path]
+	if !ok {
+		file = &File{Lines: make(map[int]int)}
+		profile.Files[path] = file
+	}
+	return file
+}
+
+func (profile *Profile) Paths() []string {
+	paths := make([]string, 0, len(profile.Files))
+	for path := range profile.Files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Merge adds the counts of another profile to this one.
+func (profile *Profile) Merge(other *Profile) {
+	for path, otherFile := range other.Files {
+		file := profile.File(path)
+		for line, hits := range otherFile.Lines {
+			file.Lines[line] += hits
+		}
+		for _, otherBranch := range otherFile.Branches {
+			branch := file.branch(otherBranch.Line, otherBranch.Column, otherBranch.Kind)
+			branch.Taken += otherBranch.Taken
+			branch.NotTaken += otherBranch.NotTaken
+		}
+	}
+}
+
+func (file *File) branch(line int, column int, kind string) *Branch {
+	for _, branch := range file.Branches {
+		if branch.Line == line && branch.Column == column {
+			return branch
+		}
+	}
+	branch := &Branch{Line: line, Column: column, Kind: kind}
+	file.Branches = append(file.Branches, branch)
+	sort.Slice(file.Branches, func(i, j int) bool {
+		if file.Branches[i].Line != file.Branches[j].Line {
+			return file.Branches[i].Line < file.Branches[j].Line
+		}
+		return file.Branches[i].Column < file.Branches[j].Column
+	})
+	return branch
+}
+
+// Summary counts covered and coverable lines and branch outcomes; every
+// branch point has two outcomes.
+func (file *File) Summary() (coveredLines int, lines int, coveredBranches int, branches int) {
+	for _, hits := range file.Lines {
+		lines++
+		if hits > 0 {
+			coveredLines++
+		}
+	}
+	for _, branch := range file.Branches {
+		branches += 2
+		if branch.Taken > 0 {
+			coveredBranches++
+		}
+		if branch.NotTaken > 0 {
+			coveredBranches++
+		}
+	}
+	return coveredLines, lines, coveredBranches, branches
+}
+
+func (file *File) SortedLines() []int {
+	lines := make([]int, 0, len(file.Lines))
+	for line := range file.Lines {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+func Load(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	profile := &Profile{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// LoadOrEmpty is Load for a profile that may not have been written yet.
+func LoadOrEmpty(path string) (*Profile, error) {
+	profile, err := Load(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Profile{}, nil
+	}
+	return profile, err
+}
+
+func (profile *Profile) Save(path string) error {
+	data, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,197 @@
+package coverage
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"strings"
+)
+
+func percent(covered int, total int) string {
+	if total == 0 {
+		return "100.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(covered)*100/float64(total))
+}
+
+// WriteText prints a summary per file with the uncovered lines and the
+// branch outcomes that never happened.
+func (profile *Profile) WriteText(writer io.Writer) {
+	totalCoveredLines, totalLines, totalCoveredBranches, totalBranches := 0, 0, 0, 0
+	for _, path := range profile.Paths() {
+		file := profile.Files[path]
+		coveredLines, lines, coveredBranches, branches := file.Summary()
+		totalCoveredLines += coveredLines
+		totalLines += lines
+		totalCoveredBranches += coveredBranches
+		totalBranches += branches
+
+		fmt.Fprintf(writer, "%s: lines %d/%d (%s), branches %d/%d (%s)\n", path, coveredLines, lines, percent(coveredLines, lines), coveredBranches, branches, percent(coveredBranches, branches))
+		if uncovered := file.uncoveredLines(); len(uncovered) > 0 {
+			fmt.Fprintf(writer, "  uncovered lines: %s\n", strings.Join(uncovered, ", "))
+		}
+		for _, branch := range file.Branches {
+			if branch.Taken == 0 || branch.NotTaken == 0 {
+				fmt.Fprintf(writer, "  %s at %d:%d: %s\n", branch.Kind, branch.Line, branch.Column, outcome(branch))
+			}
+		}
+	}
+	fmt.Fprintf(writer, "total: lines %d/%d (%s), branches %d/%d (%s)\n", totalCoveredLines, totalLines, percent(totalCoveredLines, totalLines), totalCoveredBranches, totalBranches, percent(totalCoveredBranches, totalBranches))
+}
+
+func outcome(branch *Branch) string {
+	switch {
+	case branch.Taken == 0 && branch.NotTaken == 0:
+		return "never evaluated"
+	case branch.Taken == 0:
+		return "never taken"
+	case branch.NotTaken == 0:
+		return "always taken"
+	}
+	return fmt.Sprintf("taken %d, not taken %d", branch.Taken, branch.NotTaken)
+}
+
+// uncoveredLines collapses consecutive uncovered lines into ranges.
+func (file *File) uncoveredLines() []string {
+	ranges := make([]string, 0)
+	start, end := 0, 0
+	flush := func() {
+		switch {
+		case start == 0:
+		case start == end:
+			ranges = append(ranges, fmt.Sprint(start))
+		default:
+			ranges = append(ranges, fmt.Sprintf("%d-%d", start, end))
+		}
+	}
+	for _, line := range file.SortedLines() {
+		if file.Lines[line] > 0 {
+			continue
+		}
+		if start != 0 && line == end+1 {
+			end = line
+			continue
+		}
+		flush()
+		start, end = line, line
+	}
+	flush()
+	return ranges
+}
+
+// WriteLcov writes the profile in the lcov tracefile format. Every branch
+// point is reported as block with branch 0 for the taken outcome and branch
+// 1 for the other.
+func (profile *Profile) WriteLcov(writer io.Writer) {
+	for _, path := range profile.Paths() {
+		file := profile.Files[path]
+		coveredLines, lines, coveredBranches, branches := file.Summary()
+		fmt.Fprintf(writer, "TN:\nSF:%s\n", path)
+		for _, line := range file.SortedLines() {
+			fmt.Fprintf(writer, "DA:%d,%d\n", line, file.Lines[line])
+		}
+		fmt.Fprintf(writer, "LF:%d\nLH:%d\n", lines, coveredLines)
+		for block, branch := range file.Branches {
+			for i, count := range []int{branch.Taken, branch.NotTaken} {
+				taken := fmt.Sprint(count)
+				if branch.Taken+branch.NotTaken == 0 {
+					taken = "-"
+				}
+				fmt.Fprintf(writer, "BRDA:%d,%d,%d,%s\n", branch.Line, block, i, taken)
+			}
+		}
+		fmt.Fprintf(writer, "BRF:%d\nBRH:%d\nend_of_record\n", branches, coveredBranches)
+	}
+}
+
+type htmlLine struct {
+	Number   int
+	Text     string
+	Class    string
+	Hits     string
+	Branches string
+}
+
+type htmlFile struct {
+	Path    string
+	Summary string
+	Lines   []htmlLine
+}
+
+var htmlTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Lox coverage</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; font-family: monospace; }
+td { padding: 0 8px; white-space: pre; }
+.covered { background: #dfd; }
+.uncovered { background: #fdd; }
+.partial { background: #ffd; }
+.number, .hits { color: #888; text-align: right; }
+</style>
+</head>
+<body>
+<h1>Lox coverage</h1>
+{{range .}}
+<h2>{{.Path}}</h2>
+<p>{{.Summary}}</p>
+<table>
+{{range .Lines}}<tr class="{{.Class}}"><td class="number">{{.Number}}</td><td class="hits">{{.Hits}}</td><td>{{.Text}}</td><td>{{.Branches}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// WriteHTML renders every file of the profile with its source, coloring
+// covered, uncovered and partially covered lines. Sources are read from the
+// paths recorded in the profile.
+func (profile *Profile) WriteHTML(writer io.Writer) error {
+	files := make([]htmlFile, 0)
+	for _, path := range profile.Paths() {
+		file := profile.Files[path]
+		coveredLines, lines, coveredBranches, branches := file.Summary()
+		summary := fmt.Sprintf("Lines %d/%d (%s), branches %d/%d (%s)", coveredLines, lines, percent(coveredLines, lines), coveredBranches, branches, percent(coveredBranches, branches))
+
+		source, err := os.ReadFile(path)
+		text := strings.Split(string(source), "\n")
+		if err != nil {
+			text = make([]string, 0)
+			for _, line := range file.SortedLines() {
+				for len(text) < line {
+					text = append(text, "")
+				}
+			}
+		}
+
+		rendered := make([]htmlLine, 0, len(text))
+		for i, content := range text {
+			line := htmlLine{Number: i + 1, Text: content}
+			if hits, ok := file.Lines[i+1]; ok {
+				line.Hits = fmt.Sprint(hits)
+				line.Class = "uncovered"
+				if hits > 0 {
+					line.Class = "covered"
+				}
+			}
+			outcomes := make([]string, 0)
+			for _, branch := range file.Branches {
+				if branch.Line != i+1 {
+					continue
+				}
+				outcomes = append(outcomes, fmt.Sprintf("%s: %s", branch.Kind, outcome(branch)))
+				if line.Class == "covered" && (branch.Taken == 0 || branch.NotTaken == 0) {
+					line.Class = "partial"
+				}
+			}
+			line.Branches = strings.Join(outcomes, "; ")
+			rendered = append(rendered, line)
+		}
+		files = append(files, htmlFile{Path: path, Summary: summary, Lines: rendered})
+	}
+	return htmlTemplate.Execute(writer, files)
+}
@@ -0,0 +1,403 @@
+// Package dap implements a Debug Adapter Protocol server for Lox over stdio.
+// The launched program runs on its own goroutine with a debug.Debugger
+// installed as the interpreter hook, while the server keeps answering
+// requests and reports stops, output and termination as events.
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/DrEmbryo/jlox/src/debug"
+	"github.com/DrEmbryo/jlox/src/lox"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+const (
+	EXIT_DATA_ERROR = 65
+	EXIT_SOFTWARE   = 70
+)
+
+type handler func(server *Server, arguments json.RawMessage) (any, error)
+
+var handlers = map[string]handler{
+	"initialize":        (*Server).initialize,
+	"launch":            (*Server).launch,
+	"setBreakpoints":    (*Server).setBreakpoints,
+	"configurationDone": (*Server).configurationDone,
+	"threads":           (*Server).threads,
+	"stackTrace":        (*Server).stackTrace,
+	"scopes":            (*Server).scopes,
+	"variables":         (*Server).variables,
+	"evaluate":          (*Server).evaluate,
+	"continue":          resume((*debug.Debugger).Continue),
+	"next":              resume((*debug.Debugger).StepOver),
+	"stepIn":            resume((*debug.Debugger).StepIn),
+	"stepOut":           resume((*debug.Debugger).StepOut),
+	"pause":             (*Server).pause,
+	"terminate":         (*Server).terminate,
+	"disconnect":        (*Server).terminate,
+}
+
+type Server struct {
+	In           *bufio.Reader
+	Out          io.Writer
+	Log          io.Writer
+	mutex        sync.Mutex
+	seq          int
+	debugger     *debug.Debugger
+	program      *LaunchArguments
+	lineOffset   int
+	columnOffset int
+	paused       bool
+	done         chan struct{}
+}
+
+// Serve handles requests until the client disconnects. The program, if one
+// was launched, is terminated before Serve returns.
+func (server *Server) Serve() error {
+	server.debugger = &debug.Debugger{Stopped: server.stopped}
+	for {
+		body, err := utils.ReadMessage(server.In)
+		if err != nil {
+			server.stop()
+			return err
+		}
+
+		var request request
+		if err := json.Unmarshal(body, &request); err != nil {
+			server.log("malformed request: %v", err)
+			continue
+		}
+
+		handle, ok := handlers[request.Command]
+		var result any
+		if !ok {
+			err = fmt.Errorf("Request '%s' is not supported.", request.Command)
+		} else {
+			result, err = handle(server, request.Arguments)
+		}
+		if err := server.respond(request, result, err); err != nil {
+			return err
+		}
+
+		switch request.Command {
+		case "initialize":
+			server.event("initialized", nil)
+		case "disconnect":
+			server.stop()
+			return nil
+		}
+	}
+}
+
+func (server *Server) log(format string, args ...any) {
+	if server.Log != nil {
+		fmt.Fprintf(server.Log, format+"\n", args...)
+	}
+}
+
+// write numbers and sends a message. Events come from the program goroutine
+// as well, so writes are serialised.
+func (server *Server) write(message func(seq int) any) error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.seq++
+	body, err := json.Marshal(message(server.seq))
+	if err != nil {
+		return err
+	}
+	return utils.WriteMessage(server.Out, body)
+}
+
+func (server *Server) respond(request request, body any, err error) error {
+	return server.write(func(seq int) any {
+		response := response{Seq: seq, Type: "response", RequestSeq: request.Seq, Success: err == nil, Command: request.Command, Body: body}
+		if err != nil {
+			response.Message = err.Error()
+		}
+		return response
+	})
+}
+
+func (server *Server) event(name string, body any) {
+	err := server.write(func(seq int) any {
+		return event{Seq: seq, Type: "event", Event: name, Body: body}
+	})
+	if err != nil {
+		server.log("%s: %v", name, err)
+	}
+}
+
+func decode[T any](arguments json.RawMessage) (T, error) {
+	var value T
+	if len(arguments) == 0 {
+		return value, nil
+	}
+	err := json.Unmarshal(arguments, &value)
+	return value, err
+}
+
+func (server *Server) initialize(arguments json.RawMessage) (any, error) {
+	initialize, err := decode[InitializeArguments](arguments)
+	if err != nil {
+		return nil, err
+	}
+	if initialize.LinesStartAt1 != nil && !*initialize.LinesStartAt1 {
+		server.lineOffset = 1
+	}
+	if initialize.ColumnsStartAt1 != nil && !*initialize.ColumnsStartAt1 {
+		server.columnOffset = 1
+	}
+	return Capabilities{
+		SupportsConfigurationDoneRequest: true,
+		SupportsConditionalBreakpoints:   true,
+		SupportsEvaluateForHovers:        true,
+		SupportsTerminateRequest:         true,
+	}, nil
+}
+
+// launch records the program; it starts running once the client has sent
+// its breakpoints and configurationDone.
+func (server *Server) launch(arguments json.RawMessage) (any, error) {
+	launch, err := decode[LaunchArguments](arguments)
+	if err != nil {
+		return nil, err
+	}
+	if launch.Program == "" {
+		return nil, fmt.Errorf("No program to launch.")
+	}
+	program, err := filepath.Abs(launch.Program)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(program); err != nil {
+		return nil, err
+	}
+	launch.Program = program
+	server.program = &launch
+	if launch.StopOnEntry && !launch.NoDebug {
+		server.debugger.StopOnEntry()
+	}
+	return nil, nil
+}
+
+func (server *Server) setBreakpoints(arguments json.RawMessage) (any, error) {
+	request, err := decode[SetBreakpointsArguments](arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	breakpoints := make([]Breakpoint, 0, len(request.Breakpoints))
+	if !server.isProgram(request.Source.Path) {
+		for _, breakpoint := range request.Breakpoints {
+			breakpoints = append(breakpoints, Breakpoint{Line: breakpoint.Line, Message: "Breakpoints are only supported in the launched program."})
+		}
+		return map[string]any{"breakpoints": breakpoints}, nil
+	}
+
+	requested := make([]debug.Breakpoint, 0, len(request.Breakpoints))
+	for _, breakpoint := range request.Breakpoints {
+		requested = append(requested, debug.Breakpoint{Line: breakpoint.Line + server.lineOffset, Condition: breakpoint.Condition})
+	}
+	for _, breakpoint := range server.debugger.SetBreakpoints(requested) {
+		breakpoints = append(breakpoints, Breakpoint{ID: breakpoint.ID, Verified: breakpoint.Verified, Line: breakpoint.Line - server.lineOffset, Message: breakpoint.Message})
+	}
+	return map[string]any{"breakpoints": breakpoints}, nil
+}
+
+func (server *Server) isProgram(path string) bool {
+	if server.program == nil || path == "" {
+		return false
+	}
+	path, err := filepath.Abs(path)
+	return err == nil && path == server.program.Program
+}
+
+func (server *Server) configurationDone(arguments json.RawMessage) (any, error) {
+	if server.program == nil {
+		return nil, fmt.Errorf("No program has been launched.")
+	}
+	if server.done != nil {
+		return nil, nil
+	}
+	server.done = make(chan struct{})
+	go server.run()
+	return nil, nil
+}
+
+func (server *Server) run() {
+	defer close(server.done)
+
+	options := lox.Options{
+		SearchPaths: []string{filepath.Dir(server.program.Program)},
+		Stdout:      output{server: server, category: "stdout"},
+	}
+	if !server.program.NoDebug {
+		options.Hook = server.debugger
+	}
+	exitCode := 0
+	if _, err := lox.New(options).RunFile(server.program.Program); err != nil {
+		exitCode = EXIT_SOFTWARE
+		if loxErr, ok := err.(lox.Error); ok {
+			if loxErr.IsCompileError() {
+				exitCode = EXIT_DATA_ERROR
+			}
+			for _, e := range loxErr.Errors {
+				server.event("output", OutputEvent{Category: "stderr", Output: fmt.Sprintf("[line %d] %s\n", lox.Line(e), lox.Message(e))})
+			}
+		} else {
+			server.event("output", OutputEvent{Category: "stderr", Output: err.Error() + "\n"})
+		}
+	}
+	server.event("exited", ExitedEvent{ExitCode: exitCode})
+	server.event("terminated", nil)
+}
+
+// stop terminates the program and waits for its goroutine to finish.
+func (server *Server) stop() {
+	if server.done == nil {
+		return
+	}
+	server.debugger.Terminate()
+	<-server.done
+}
+
+func (server *Server) stopped(reason string) {
+	server.mutex.Lock()
+	server.paused = true
+	server.mutex.Unlock()
+	server.event("stopped", StoppedEvent{Reason: reason, ThreadID: THREAD_ID, AllThreadsStopped: true})
+}
+
+func (server *Server) checkPaused() error {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if !server.paused {
+		return fmt.Errorf("The program is not paused.")
+	}
+	return nil
+}
+
+func resume(action func(debugger *debug.Debugger)) handler {
+	return func(server *Server, arguments json.RawMessage) (any, error) {
+		if err := server.checkPaused(); err != nil {
+			return nil, err
+		}
+		server.mutex.Lock()
+		server.paused = false
+		server.mutex.Unlock()
+		action(server.debugger)
+		return map[string]any{"allThreadsContinued": true}, nil
+	}
+}
+
+func (server *Server) pause(arguments json.RawMessage) (any, error) {
+	server.debugger.Pause()
+	return nil, nil
+}
+
+func (server *Server) terminate(arguments json.RawMessage) (any, error) {
+	server.mutex.Lock()
+	server.paused = false
+	server.mutex.Unlock()
+	if server.done != nil {
+		server.debugger.Terminate()
+	}
+	return nil, nil
+}
+
+func (server *Server) threads(arguments json.RawMessage) (any, error) {
+	return map[string]any{"threads": []Thread{{ID: THREAD_ID, Name: "main"}}}, nil
+}
+
+func (server *Server) stackTrace(arguments json.RawMessage) (any, error) {
+	if err := server.checkPaused(); err != nil {
+		return nil, err
+	}
+	source := &Source{Name: filepath.Base(server.program.Program), Path: server.program.Program}
+	frames := make([]StackFrame, 0)
+	for _, frame := range server.debugger.Frames() {
+		frames = append(frames, StackFrame{ID: frame.ID, Name: frame.Name, Source: source, Line: frame.Line - server.lineOffset, Column: max(frame.Column, 1) - server.columnOffset})
+	}
+	return map[string]any{"stackFrames": frames, "totalFrames": len(frames)}, nil
+}
+
+func (server *Server) scopes(arguments json.RawMessage) (any, error) {
+	request, err := decode[ScopesArguments](arguments)
+	if err != nil {
+		return nil, err
+	}
+	if err := server.checkPaused(); err != nil {
+		return nil, err
+	}
+	scopes, err := server.debugger.Scopes(request.FrameID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Scope, 0, len(scopes))
+	for _, scope := range scopes {
+		result = append(result, Scope{Name: scope.Name, VariablesReference: scope.Reference, Expensive: scope.Name == "Globals"})
+	}
+	return map[string]any{"scopes": result}, nil
+}
+
+func (server *Server) variables(arguments json.RawMessage) (any, error) {
+	request, err := decode[VariablesArguments](arguments)
+	if err != nil {
+		return nil, err
+	}
+	if err := server.checkPaused(); err != nil {
+		return nil, err
+	}
+	variables, err := server.debugger.Variables(request.VariablesReference)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Variable, 0, len(variables))
+	for _, variable := range variables {
+		result = append(result, toVariable(variable))
+	}
+	return map[string]any{"variables": result}, nil
+}
+
+func (server *Server) evaluate(arguments json.RawMessage) (any, error) {
+	request, err := decode[EvaluateArguments](arguments)
+	if err != nil {
+		return nil, err
+	}
+	if err := server.checkPaused(); err != nil {
+		return nil, err
+	}
+	frameID := request.FrameID
+	if frameID == 0 {
+		if frames := server.debugger.Frames(); len(frames) > 0 {
+			frameID = frames[0].ID
+		}
+	}
+	result, err := server.debugger.Evaluate(frameID, request.Expression)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{"result": result.Value, "type": result.Type, "variablesReference": result.Reference}, nil
+}
+
+func toVariable(variable debug.Variable) Variable {
+	return Variable{Name: variable.Name, Value: variable.Value, Type: variable.Type, VariablesReference: variable.Reference}
+}
+
+// output forwards what the program prints to the client as output events.
+type output struct {
+	server   *Server
+	category string
+}
+
+func (output output) Write(data []byte) (int, error) {
+	output.server.event("output", OutputEvent{Category: output.category, Output: string(data)})
+	return len(data), nil
+}
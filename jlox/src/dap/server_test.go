@@ -0,0 +1,154 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+const PROGRAM = `class Counter {
+  constructor(start) {
+    this.count = start;
+  }
+}
+func bump(counter, by) {
+  counter.count = counter.count + by;
+  return counter.count;
+}
+var counter = Counter(1);
+bump(counter, 2);
+print bump(counter, 5);
+`
+
+type client struct {
+	t      *testing.T
+	in     io.Writer
+	out    *bufio.Reader
+	seq    int
+	events []map[string]any
+}
+
+func (client *client) request(command string, arguments any) map[string]any {
+	client.seq++
+	body, _ := json.Marshal(map[string]any{"seq": client.seq, "type": "request", "command": command, "arguments": arguments})
+	if err := utils.WriteMessage(client.in, body); err != nil {
+		client.t.Fatal(err)
+	}
+	for {
+		message := client.read()
+		if message["type"] == "response" && int(message["request_seq"].(float64)) == client.seq {
+			if message["success"] != true {
+				client.t.Fatalf("%s failed: %v", command, message["message"])
+			}
+			body, _ := message["body"].(map[string]any)
+			return body
+		}
+		client.events = append(client.events, message)
+	}
+}
+
+func (client *client) event(name string) map[string]any {
+	for {
+		for i, message := range client.events {
+			if message["event"] == name {
+				client.events = append(client.events[:i], client.events[i+1:]...)
+				body, _ := message["body"].(map[string]any)
+				return body
+			}
+		}
+		client.events = append(client.events, client.read())
+	}
+}
+
+func (client *client) read() map[string]any {
+	body, err := utils.ReadMessage(client.out)
+	if err != nil {
+		client.t.Fatal(err)
+	}
+	var message map[string]any
+	json.Unmarshal(body, &message)
+	return message
+}
+
+func TestSession(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "counter.lox")
+	if err := os.WriteFile(program, []byte(PROGRAM), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+	server := Server{In: bufio.NewReader(serverIn), Out: serverOut}
+	served := make(chan error)
+	go func() { served <- server.Serve() }()
+	client := &client{t: t, in: clientOut, out: bufio.NewReader(clientIn)}
+
+	capabilities := client.request("initialize", map[string]any{"adapterID": "lox"})
+	if capabilities["supportsConditionalBreakpoints"] != true {
+		t.Errorf("expected conditional breakpoints to be supported, got %v", capabilities)
+	}
+	client.event("initialized")
+	client.request("launch", map[string]any{"program": program})
+	breakpoints := client.request("setBreakpoints", map[string]any{
+		"source":      map[string]any{"path": program},
+		"breakpoints": []any{map[string]any{"line": 7, "condition": "by < 5"}},
+	})["breakpoints"].([]any)
+	if breakpoints[0].(map[string]any)["verified"] != true {
+		t.Errorf("expected a verified breakpoint, got %v", breakpoints)
+	}
+	client.request("configurationDone", nil)
+
+	if stopped := client.event("stopped"); stopped["reason"] != "breakpoint" {
+		t.Errorf("expected a breakpoint stop, got %v", stopped)
+	}
+	frames := client.request("stackTrace", map[string]any{"threadId": THREAD_ID})["stackFrames"].([]any)
+	top := frames[0].(map[string]any)
+	if len(frames) != 2 || top["name"] != "bump" || top["line"] != 7.0 {
+		t.Fatalf("expected to stop in bump at line 7, got %v", frames)
+	}
+
+	evaluated := client.request("evaluate", map[string]any{"expression": "counter.count + by", "frameId": top["id"]})
+	if evaluated["result"] != "3" {
+		t.Errorf("expected counter.count + by to be 3, got %v", evaluated)
+	}
+
+	scopes := client.request("scopes", map[string]any{"frameId": top["id"]})["scopes"].([]any)
+	globals := scopes[len(scopes)-1].(map[string]any)
+	variables := client.request("variables", map[string]any{"variablesReference": globals["variablesReference"]})["variables"].([]any)
+	var fields []any
+	for _, variable := range variables {
+		if variable := variable.(map[string]any); variable["name"] == "counter" {
+			fields = client.request("variables", map[string]any{"variablesReference": variable["variablesReference"]})["variables"].([]any)
+		}
+	}
+	if len(fields) != 1 || fields[0].(map[string]any)["value"] != "1" {
+		t.Errorf("expected counter.count to be 1, got %v", fields)
+	}
+
+	client.request("stepOut", map[string]any{"threadId": THREAD_ID})
+	if stopped := client.event("stopped"); stopped["reason"] != "step" {
+		t.Errorf("expected a step stop, got %v", stopped)
+	}
+	frames = client.request("stackTrace", map[string]any{"threadId": THREAD_ID})["stackFrames"].([]any)
+	if top := frames[0].(map[string]any); len(frames) != 1 || top["line"] != 12.0 {
+		t.Errorf("expected to step out to line 12, got %v", frames)
+	}
+	client.request("continue", map[string]any{"threadId": THREAD_ID})
+	if output := client.event("output"); output["output"] != "8\n" {
+		t.Errorf("expected the program to print 8, got %v", output)
+	}
+	if exited := client.event("exited"); exited["exitCode"] != 0.0 {
+		t.Errorf("expected exit code 0, got %v", exited)
+	}
+	client.event("terminated")
+
+	client.request("disconnect", nil)
+	if err := <-served; err != nil {
+		t.Fatal(err)
+	}
+}
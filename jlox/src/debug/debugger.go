@@ -0,0 +1,346 @@
+// Package debug implements breakpoints, stepping and inspection of running
+// Lox programs. A Debugger is installed as the interpreter's runtime.Hook and
+// pauses the program by blocking the goroutine that runs it until one of the
+// resume methods is called. Frames, variables and expressions can only be
+// inspected while the program is paused.
+package debug
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+const (
+	STOP_ENTRY      = "entry"
+	STOP_BREAKPOINT = "breakpoint"
+	STOP_STEP       = "step"
+	STOP_PAUSE      = "pause"
+	STOP_WATCH      = "watch"
+)
+
+const (
+	RUN = iota
+	STEP_IN
+	STEP_OVER
+	STEP_OUT
+)
+
+const TERMINATE = -1
+
+type Breakpoint struct {
+	ID        int
+	Line      int
+	Condition string
+	Verified  bool
+	Message   string
+	condition grammar.Expression
+}
+
+// Watch stops the program after a statement changes the value of its
+// expression. Old and New hold the values seen at the last change.
+type Watch struct {
+	ID         int
+	Expression string
+	Old        string
+	New        string
+	known      bool
+	expr       grammar.Expression
+}
+
+type Frame struct {
+	ID          int
+	Name        string
+	Line        int
+	Column      int
+	interpreter *runtime.Interpreter
+}
+
+type Debugger struct {
+	// Stopped is called on the program goroutine every time it pauses. It may
+	// inspect the program and call a resume method before returning.
+	Stopped     func(reason string)
+	mutex       sync.Mutex
+	breakpoints map[int]*Breakpoint
+	nextID      int
+	watches     []*Watch
+	frames      []*Frame
+	mode        int
+	depth       int
+	reason      string
+	pause       bool
+	terminated  bool
+	triggered   []Watch
+	resume      chan int
+	evaluating  bool
+	handles     []any
+}
+
+func (debugger *Debugger) init() {
+	if debugger.resume == nil {
+		debugger.resume = make(chan int, 1)
+		debugger.breakpoints = make(map[int]*Breakpoint)
+	}
+}
+
+// SetBreakpoints replaces all breakpoints. Conditions are parsed up front so
+// a breakpoint with an invalid condition is reported as unverified.
+func (debugger *Debugger) SetBreakpoints(breakpoints []Breakpoint) []Breakpoint {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.init()
+
+	debugger.breakpoints = make(map[int]*Breakpoint)
+	result := make([]Breakpoint, 0, len(breakpoints))
+	for _, breakpoint := range breakpoints {
+		debugger.nextID++
+		breakpoint.ID = debugger.nextID
+		breakpoint.Verified = true
+		breakpoint.Message = ""
+		breakpoint.condition = nil
+		if breakpoint.Condition != "" {
+			condition, err := parseExpression(breakpoint.Condition)
+			if err != nil {
+				breakpoint.Verified = false
+				breakpoint.Message = err.Error()
+			}
+			breakpoint.condition = condition
+		}
+		if breakpoint.Verified {
+			debugger.breakpoints[breakpoint.Line] = &breakpoint
+		}
+		result = append(result, breakpoint)
+	}
+	return result
+}
+
+func (debugger *Debugger) Breakpoints() []Breakpoint {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+
+	breakpoints := make([]Breakpoint, 0, len(debugger.breakpoints))
+	for _, breakpoint := range debugger.breakpoints {
+		breakpoints = append(breakpoints, *breakpoint)
+	}
+	sort.Slice(breakpoints, func(i, j int) bool { return breakpoints[i].Line < breakpoints[j].Line })
+	return breakpoints
+}
+
+func (debugger *Debugger) Watch(expression string) (Watch, error) {
+	expr, err := parseExpression(expression)
+	if err != nil {
+		return Watch{}, err
+	}
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.nextID++
+	watch := &Watch{ID: debugger.nextID, Expression: expression, expr: expr}
+	debugger.watches = append(debugger.watches, watch)
+	return *watch, nil
+}
+
+// Triggered returns the watches whose value changed at the last stop.
+func (debugger *Debugger) Triggered() []Watch {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	return debugger.triggered
+}
+
+// StopOnEntry makes the program pause before its first statement.
+func (debugger *Debugger) StopOnEntry() {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.init()
+	debugger.mode = STEP_IN
+	debugger.reason = STOP_ENTRY
+}
+
+func (debugger *Debugger) Continue() {
+	debugger.send(RUN)
+}
+
+func (debugger *Debugger) StepIn() {
+	debugger.send(STEP_IN)
+}
+
+func (debugger *Debugger) StepOver() {
+	debugger.send(STEP_OVER)
+}
+
+func (debugger *Debugger) StepOut() {
+	debugger.send(STEP_OUT)
+}
+
+// Pause stops a running program at the next statement it executes.
+func (debugger *Debugger) Pause() {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.pause = true
+}
+
+// Terminate stops the program, whether it is paused or running.
+func (debugger *Debugger) Terminate() {
+	debugger.mutex.Lock()
+	debugger.terminated = true
+	debugger.mutex.Unlock()
+	debugger.send(TERMINATE)
+}
+
+func (debugger *Debugger) send(action int) {
+	debugger.mutex.Lock()
+	debugger.init()
+	debugger.mutex.Unlock()
+	select {
+	case debugger.resume <- action:
+	default:
+	}
+}
+
+func (debugger *Debugger) Statement(interpreter *runtime.Interpreter, stmt grammar.Statement) grammar.LoxError {
+	if debugger.evaluating {
+		return nil
+	}
+	switch stmt.(type) {
+	case grammar.BlockScopeStatement, grammar.ExportStatement:
+		return nil
+	}
+	token, ok := grammar.FirstToken(stmt)
+	if !ok {
+		return nil
+	}
+
+	debugger.mutex.Lock()
+	debugger.init()
+	if len(debugger.frames) == 0 {
+		debugger.frames = append(debugger.frames, &Frame{Name: "main"})
+	}
+	frame := debugger.frames[len(debugger.frames)-1]
+	frame.Line, frame.Column, frame.interpreter = token.Line, token.Column, interpreter
+	if debugger.terminated {
+		debugger.mutex.Unlock()
+		return terminated(token)
+	}
+	reason := debugger.stopReason(interpreter, token.Line)
+	debugger.mutex.Unlock()
+	if reason == "" {
+		return nil
+	}
+
+	if debugger.Stopped != nil {
+		debugger.Stopped(reason)
+	}
+	action := <-debugger.resume
+
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	debugger.handles = nil
+	if action == TERMINATE || debugger.terminated {
+		debugger.terminated = true
+		return terminated(token)
+	}
+	debugger.mode = action
+	debugger.depth = len(debugger.frames)
+	return nil
+}
+
+func (debugger *Debugger) stopReason(interpreter *runtime.Interpreter, line int) string {
+	if debugger.pause {
+		debugger.pause = false
+		return STOP_PAUSE
+	}
+	if debugger.watchesChanged(interpreter) {
+		return STOP_WATCH
+	}
+	if breakpoint, ok := debugger.breakpoints[line]; ok && debugger.conditionHolds(interpreter, breakpoint) {
+		return STOP_BREAKPOINT
+	}
+
+	depth := len(debugger.frames)
+	switch {
+	case debugger.mode == STEP_IN,
+		debugger.mode == STEP_OVER && depth <= debugger.depth,
+		debugger.mode == STEP_OUT && depth < debugger.depth:
+		reason := STOP_STEP
+		if debugger.reason != "" {
+			reason, debugger.reason = debugger.reason, ""
+		}
+		return reason
+	}
+	return ""
+}
+
+// conditionHolds evaluates a breakpoint condition in the frame about to run.
+// A condition that fails to evaluate does not stop the program.
+func (debugger *Debugger) conditionHolds(interpreter *runtime.Interpreter, breakpoint *Breakpoint) bool {
+	if breakpoint.condition == nil {
+		return true
+	}
+	debugger.evaluating = true
+	defer func() { debugger.evaluating = false }()
+	value, err := evaluate(interpreter, breakpoint.condition)
+	if err != nil {
+		return false
+	}
+	return value != nil && value != false
+}
+
+// watchesChanged re-evaluates the watched expressions before a statement
+// runs, which tells whether the previous statement changed any of them.
+// Expressions that can't be evaluated in the current frame are skipped.
+func (debugger *Debugger) watchesChanged(interpreter *runtime.Interpreter) bool {
+	debugger.triggered = nil
+	if len(debugger.watches) == 0 {
+		return false
+	}
+	debugger.evaluating = true
+	defer func() { debugger.evaluating = false }()
+	for _, watch := range debugger.watches {
+		value, err := evaluate(interpreter, watch.expr)
+		if err != nil {
+			continue
+		}
+		current := Format(value)
+		if watch.known && current != watch.New {
+			watch.Old = watch.New
+			watch.New = current
+			debugger.triggered = append(debugger.triggered, *watch)
+			continue
+		}
+		watch.New, watch.known = current, true
+	}
+	return len(debugger.triggered) > 0
+}
+
+func (debugger *Debugger) EnterFunction(interpreter *runtime.Interpreter, callee runtime.LoxCallable) {
+	function, ok := callee.(*runtime.LoxFunction)
+	if !ok || debugger.evaluating {
+		return
+	}
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	if len(debugger.frames) == 0 {
+		debugger.frames = append(debugger.frames, &Frame{Name: "main"})
+	}
+	name := function.Declaration.Name
+	debugger.frames = append(debugger.frames, &Frame{Name: fmt.Sprintf("%v", name.Lexeme), Line: name.Line, Column: name.Column, interpreter: interpreter})
+}
+
+func (debugger *Debugger) ExitFunction(interpreter *runtime.Interpreter, callee runtime.LoxCallable) {
+	if _, ok := callee.(*runtime.LoxFunction); !ok || debugger.evaluating {
+		return
+	}
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	if len(debugger.frames) > 1 {
+		debugger.frames = debugger.frames[:len(debugger.frames)-1]
+	}
+}
+
+func (debugger *Debugger) Branch(interpreter *runtime.Interpreter, node any, taken bool) {}
+
+func terminated(token grammar.Token) grammar.LoxError {
+	return runtime.RuntimeError{Token: token, Message: "Debugging session terminated."}
+}
@@ -0,0 +1,142 @@
+package debug
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/lox"
+)
+
+const PROGRAM = `func add(a, b) {
+  var sum = a + b;
+  return sum;
+}
+var total = add(1, 2);
+total = add(total, 3);
+print total;
+`
+
+// run executes PROGRAM, answering every stop with the next action and
+// recording where the program stopped.
+func run(t *testing.T, debugger *Debugger, actions []func()) ([]string, string) {
+	stops := make([]string, 0)
+	debugger.Stopped = func(reason string) {
+		frames := debugger.Frames()
+		stops = append(stops, fmt.Sprintf("%s %s:%d", reason, frames[0].Name, frames[0].Line))
+		if len(stops) > len(actions) {
+			t.Fatalf("unexpected stop %s", stops[len(stops)-1])
+		}
+		actions[len(stops)-1]()
+	}
+	stdout := &bytes.Buffer{}
+	if _, err := lox.New(lox.Options{Stdout: stdout, Hook: debugger}).Eval(PROGRAM); err != nil {
+		t.Fatal(err)
+	}
+	return stops, stdout.String()
+}
+
+func TestStepping(t *testing.T) {
+	debugger := &Debugger{}
+	debugger.StopOnEntry()
+	stops, stdout := run(t, debugger, []func(){
+		debugger.StepOver,
+		debugger.StepIn,
+		debugger.StepOver,
+		debugger.StepOut,
+		debugger.StepOver,
+		debugger.Continue,
+	})
+
+	expected := []string{
+		"entry main:1",
+		"step main:5",
+		"step add:2",
+		"step add:3",
+		"step main:6",
+		"step main:7",
+	}
+	if !reflect.DeepEqual(stops, expected) {
+		t.Errorf("expected stops %v, got %v", expected, stops)
+	}
+	if stdout != "6\n" {
+		t.Errorf("expected output 6, got %q", stdout)
+	}
+}
+
+func TestConditionalBreakpoint(t *testing.T) {
+	debugger := &Debugger{}
+	breakpoints := debugger.SetBreakpoints([]Breakpoint{{Line: 2, Condition: "b == 3"}, {Line: 3, Condition: "b =="}})
+	if !breakpoints[0].Verified || breakpoints[1].Verified {
+		t.Fatalf("expected only the first breakpoint to be verified, got %+v", breakpoints)
+	}
+
+	var frames []Frame
+	var sum Variable
+	var evalErr error
+	stops, stdout := run(t, debugger, []func(){
+		func() {
+			frames = debugger.Frames()
+			sum, evalErr = debugger.Evaluate(frames[0].ID, "a + b")
+			debugger.Continue()
+		},
+	})
+
+	if !reflect.DeepEqual(stops, []string{"breakpoint add:2"}) {
+		t.Errorf("expected a single breakpoint stop, got %v", stops)
+	}
+	if len(frames) != 2 || frames[1].Name != "main" || frames[1].Line != 6 {
+		t.Errorf("expected add called from main:6, got %+v", frames)
+	}
+	if evalErr != nil || sum.Value != "6" || sum.Type != "number" {
+		t.Errorf("expected a + b to be 6, got %+v (%v)", sum, evalErr)
+	}
+	if stdout != "6\n" {
+		t.Errorf("expected output 6, got %q", stdout)
+	}
+}
+
+func TestVariables(t *testing.T) {
+	debugger := &Debugger{}
+	debugger.SetBreakpoints([]Breakpoint{{Line: 3}})
+
+	source := `class Point { constructor(x, y) { this.x = x; this.y = y; } }
+var p = Point(1, "two");
+print p.x;
+`
+	var globals []Variable
+	var fields []Variable
+	debugger.Stopped = func(reason string) {
+		scopes, err := debugger.Scopes(debugger.Frames()[0].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		globals, _ = debugger.Variables(scopes[len(scopes)-1].Reference)
+		for _, variable := range globals {
+			if variable.Name == "p" {
+				fields, _ = debugger.Variables(variable.Reference)
+			}
+		}
+		debugger.Continue()
+	}
+	if _, err := lox.New(lox.Options{Stdout: &bytes.Buffer{}, Hook: debugger}).Eval(source); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Variable{{Name: "x", Value: "1", Type: "number"}, {Name: "y", Value: `"two"`, Type: "string"}}
+	if !reflect.DeepEqual(fields, expected) {
+		t.Errorf("expected fields %+v, got %+v (globals %+v)", expected, fields, globals)
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	debugger := &Debugger{}
+	debugger.StopOnEntry()
+	debugger.Stopped = func(reason string) { debugger.Terminate() }
+	stdout := &bytes.Buffer{}
+	_, err := lox.New(lox.Options{Stdout: stdout, Hook: debugger}).Eval(PROGRAM)
+	if err == nil || stdout.Len() != 0 {
+		t.Errorf("expected the program to stop without output, got %q (%v)", stdout.String(), err)
+	}
+}
@@ -0,0 +1,255 @@
+package debug
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/lox"
+	"github.com/DrEmbryo/jlox/src/parser"
+	"github.com/DrEmbryo/jlox/src/resolver"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+type Scope struct {
+	Name      string
+	Reference int
+}
+
+type Variable struct {
+	Name      string
+	Value     string
+	Type      string
+	Reference int
+}
+
+// scopeValues is the handle behind a scope reference. The globals scope
+// leaves out the natives registered by the standard library.
+type scopeValues struct {
+	values  map[string]any
+	globals bool
+}
+
+// Frames returns the call stack of the paused program, innermost first.
+func (debugger *Debugger) Frames() []Frame {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+
+	frames := make([]Frame, 0, len(debugger.frames))
+	for i := len(debugger.frames) - 1; i >= 0; i-- {
+		frame := *debugger.frames[i]
+		frame.ID = i + 1
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func (debugger *Debugger) frame(id int) (*Frame, error) {
+	if id < 1 || id > len(debugger.frames) || debugger.frames[id-1].interpreter == nil {
+		return nil, fmt.Errorf("unknown frame %d", id)
+	}
+	return debugger.frames[id-1], nil
+}
+
+// Scopes walks the environment chain of a frame from the innermost block out
+// to the globals. Environments sharing the same values are reported once.
+func (debugger *Debugger) Scopes(frameID int) ([]Scope, error) {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+
+	frame, err := debugger.frame(frameID)
+	if err != nil {
+		return nil, err
+	}
+	globals := frame.interpreter.Globals()
+	seen := make(map[uintptr]bool)
+	scopes := make([]Scope, 0)
+	for env := &frame.interpreter.Env; env != nil; env = env.Parent {
+		if env.Values == nil || seen[reflect.ValueOf(env.Values).Pointer()] {
+			continue
+		}
+		seen[reflect.ValueOf(env.Values).Pointer()] = true
+
+		isGlobal := env.Parent == nil || reflect.ValueOf(env.Values).Pointer() == reflect.ValueOf(globals.Values).Pointer()
+		name := "Closure"
+		switch {
+		case isGlobal:
+			name = "Globals"
+		case len(scopes) == 0:
+			name = "Locals"
+		}
+		scopes = append(scopes, Scope{Name: name, Reference: debugger.handle(scopeValues{values: env.Values, globals: isGlobal})})
+		if isGlobal {
+			break
+		}
+	}
+	return scopes, nil
+}
+
+// Variables lists the children of a scope or of a structured value: the
+// fields of an instance, the elements of a list or the exports of a module.
+func (debugger *Debugger) Variables(reference int) ([]Variable, error) {
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+
+	if reference < 1 || reference > len(debugger.handles) {
+		return nil, fmt.Errorf("unknown variables reference %d", reference)
+	}
+	variables := make([]Variable, 0)
+	switch handle := debugger.handles[reference-1].(type) {
+	case scopeValues:
+		for name, value := range handle.values {
+			if _, native := value.(runtime.NativeCall); native && handle.globals {
+				continue
+			}
+			if name == "this" || name == "super" {
+				continue
+			}
+			variables = append(variables, debugger.variable(name, value))
+		}
+		sortVariables(variables)
+	case runtime.LoxClassInstance:
+		for name, value := range handle.Class.Fields {
+			variables = append(variables, debugger.variable(fmt.Sprintf("%v", name), value))
+		}
+		sortVariables(variables)
+	case runtime.LoxList:
+		for i, value := range handle.Elements {
+			variables = append(variables, debugger.variable(fmt.Sprintf("[%d]", i), value))
+		}
+	case runtime.LoxModule:
+		for name, value := range handle.Exports {
+			variables = append(variables, debugger.variable(name, value))
+		}
+		sortVariables(variables)
+	}
+	return variables, nil
+}
+
+// Evaluate runs an expression in the environment of a paused frame. Functions
+// called by the expression run without stopping at breakpoints.
+func (debugger *Debugger) Evaluate(frameID int, source string) (Variable, error) {
+	debugger.mutex.Lock()
+	frame, err := debugger.frame(frameID)
+	debugger.mutex.Unlock()
+	if err != nil {
+		return Variable{}, err
+	}
+
+	expr, err := parseExpression(source)
+	if err != nil {
+		return Variable{}, err
+	}
+	debugger.evaluating = true
+	value, loxErr := evaluate(frame.interpreter, expr)
+	debugger.evaluating = false
+	if loxErr != nil {
+		return Variable{}, fmt.Errorf("%s", lox.Message(loxErr))
+	}
+
+	debugger.mutex.Lock()
+	defer debugger.mutex.Unlock()
+	return debugger.variable(source, value), nil
+}
+
+func (debugger *Debugger) handle(value any) int {
+	debugger.handles = append(debugger.handles, value)
+	return len(debugger.handles)
+}
+
+func (debugger *Debugger) variable(name string, value any) Variable {
+	variable := Variable{Name: name, Value: Format(value), Type: TypeName(value)}
+	switch value := value.(type) {
+	case runtime.LoxClassInstance:
+		if len(value.Class.Fields) > 0 {
+			variable.Reference = debugger.handle(value)
+		}
+	case runtime.LoxList:
+		if len(value.Elements) > 0 {
+			variable.Reference = debugger.handle(value)
+		}
+	case runtime.LoxModule:
+		if len(value.Exports) > 0 {
+			variable.Reference = debugger.handle(value)
+		}
+	}
+	return variable
+}
+
+func sortVariables(variables []Variable) {
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+}
+
+// Format renders a value the way it would appear in source code, so strings
+// are quoted and null is spelled out.
+func Format(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("%q", value)
+	}
+	return runtime.Stringify(value)
+}
+
+func TypeName(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case runtime.LoxFunction, runtime.NativeCall:
+		return "function"
+	case runtime.LoxClass:
+		return "class"
+	case runtime.LoxClassInstance:
+		return fmt.Sprintf("%v", value.Class.Name.Lexeme)
+	case runtime.LoxList:
+		return "list"
+	case runtime.LoxModule:
+		return "module"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func parseExpression(source string) (grammar.Expression, error) {
+	source = strings.TrimSuffix(strings.TrimSpace(source), ";")
+	tokens, lexErrs := lexer.Lexer{Source: []rune(source + ";")}.Tokenize()
+	if len(lexErrs) > 0 {
+		return nil, fmt.Errorf("%s", lox.Message(lexErrs[0]))
+	}
+	for i := range tokens {
+		tokens[i].Line = 0
+	}
+	stmts, err := parser.Parser{Tokens: tokens}.Parse()
+	if err != nil {
+		return nil, fmt.Errorf("%s", lox.Message(err))
+	}
+	if len(stmts) != 1 {
+		return nil, fmt.Errorf("'%s' is not an expression", source)
+	}
+	stmt, ok := stmts[0].(grammar.ExpressionStatement)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not an expression", source)
+	}
+	return stmt.Expression, nil
+}
+
+// evaluate resolves expr against the environments of the frame about to run
+// and evaluates it there. parseExpression puts its tokens on line 0, so these
+// resolutions never replace the program's own.
+func evaluate(interpreter *runtime.Interpreter, expr grammar.Expression) (any, grammar.LoxError) {
+	scopes := resolver.Resolver{Interpreter: *interpreter, Scopes: utils.Stack[map[string]bool]{}, Error: make([]grammar.LoxError, 0)}
+	if errs := scopes.ResolveExpression(&interpreter.Env, expr); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return interpreter.Evaluate(expr)
+}
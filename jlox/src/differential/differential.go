@@ -0,0 +1,146 @@
+// Package differential runs Lox scripts through both the jlox interpreter
+// and a clox binary and reports where their observable behaviour differs.
+package differential
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/conformance"
+)
+
+const (
+	EXIT_COMPILE_ERROR = 65
+	EXIT_RUNTIME_ERROR = 70
+)
+
+// PROBE is a script any clox that compiles source must run cleanly.
+const PROBE = "print 1;\n"
+
+var errorLine = regexp.MustCompile(`\[line (\d+)\]`)
+
+type Divergence struct {
+	Path    string
+	Message string
+}
+
+func (divergence Divergence) String() string {
+	return fmt.Sprintf("%s: %s", divergence.Path, divergence.Message)
+}
+
+func RunJlox(path string) conformance.Outcome {
+	return conformance.Run(path)
+}
+
+func RunClox(command []string, path string) (conformance.Outcome, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(command[0], append(command[1:], path)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return conformance.Outcome{}, err
+	}
+	return cloxOutcome(stdout.String(), stderr.String(), cmd.ProcessState.ExitCode()), nil
+}
+
+// Probe checks that the clox command runs a script file. Until clox has a
+// compiler every script would diverge, so comparing corpora would only
+// report that.
+func Probe(command []string) error {
+	dir, err := os.MkdirTemp("", "loxdiff")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "probe.lox")
+	if err := os.WriteFile(path, []byte(PROBE), 0644); err != nil {
+		return err
+	}
+	outcome, err := RunClox(command, path)
+	if err != nil {
+		return err
+	}
+	if len(outcome.Errors) > 0 {
+		return fmt.Errorf("%q failed with %v", PROBE, outcome.Errors[0])
+	}
+	if !reflect.DeepEqual(outcome.Output, []string{"1"}) {
+		return fmt.Errorf("%q printed %q instead of \"1\"", PROBE, outcome.Output)
+	}
+	return nil
+}
+
+func cloxOutcome(stdout string, stderr string, exitCode int) conformance.Outcome {
+	outcome := conformance.Outcome{Output: conformance.Lines(stdout), Errors: make([]conformance.Diagnostic, 0)}
+
+	var kind string
+	switch exitCode {
+	case 0:
+		return outcome
+	case EXIT_COMPILE_ERROR:
+		kind = conformance.COMPILE_ERROR
+	case EXIT_RUNTIME_ERROR:
+		kind = conformance.RUNTIME_ERROR
+	default:
+		kind = fmt.Sprintf("exit %d", exitCode)
+	}
+
+	diagnostic := conformance.Diagnostic{Kind: kind, Message: strings.TrimSpace(stderr)}
+	if match := errorLine.FindStringSubmatch(stderr); match != nil {
+		fmt.Sscan(match[1], &diagnostic.Line)
+		diagnostic.Message = strings.TrimSpace(strings.Replace(diagnostic.Message, match[0], "", 1))
+	}
+	outcome.Errors = append(outcome.Errors, diagnostic)
+	return outcome
+}
+
+func Compare(path string, jlox conformance.Outcome, clox conformance.Outcome) []Divergence {
+	divergences := make([]Divergence, 0)
+	for i := 0; i < max(len(jlox.Output), len(clox.Output)); i++ {
+		var jloxLine, cloxLine string
+		if i < len(jlox.Output) {
+			jloxLine = jlox.Output[i]
+		}
+		if i < len(clox.Output) {
+			cloxLine = clox.Output[i]
+		}
+		if i >= len(jlox.Output) || i >= len(clox.Output) || jloxLine != cloxLine {
+			divergences = append(divergences, Divergence{Path: path, Message: fmt.Sprintf("output line %d: jlox %q, clox %q", i+1, jloxLine, cloxLine)})
+			break
+		}
+	}
+
+	jloxError, jloxFailed := firstError(jlox)
+	cloxError, cloxFailed := firstError(clox)
+	switch {
+	case jloxFailed != cloxFailed || jloxError.Kind != cloxError.Kind:
+		divergences = append(divergences, Divergence{Path: path, Message: fmt.Sprintf("error kind: jlox %v, clox %v", describe(jloxError, jloxFailed), describe(cloxError, cloxFailed))})
+	case jloxFailed && jloxError.Line != cloxError.Line:
+		divergences = append(divergences, Divergence{Path: path, Message: fmt.Sprintf("%s error line: jlox %d, clox %d", jloxError.Kind, jloxError.Line, cloxError.Line)})
+	}
+	return divergences
+}
+
+func firstError(outcome conformance.Outcome) (conformance.Diagnostic, bool) {
+	if len(outcome.Errors) == 0 {
+		return conformance.Diagnostic{}, false
+	}
+	return outcome.Errors[0], true
+}
+
+func describe(diagnostic conformance.Diagnostic, failed bool) string {
+	if !failed {
+		return "no error"
+	}
+	return diagnostic.String()
+}
@@ -0,0 +1,98 @@
+package differential
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/conformance"
+)
+
+func TestCloxOutcome(t *testing.T) {
+	var tests = []struct {
+		name     string
+		stderr   string
+		exitCode int
+		kind     string
+		line     int
+	}{
+		{"success", "", 0, "", 0},
+		{"compile error", "[line 3] Error at ';': Expect expression.\n", EXIT_COMPILE_ERROR, conformance.COMPILE_ERROR, 3},
+		{"runtime error", "Operand must be a number.\n[line 7] in script\n", EXIT_RUNTIME_ERROR, conformance.RUNTIME_ERROR, 7},
+		{"crash", "panic: index out of range\n", 2, "exit 2", 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			outcome := cloxOutcome("1\n2\n", tc.stderr, tc.exitCode)
+			if len(outcome.Output) != 2 {
+				t.Errorf("got output %q, want two lines", outcome.Output)
+			}
+			if tc.exitCode == 0 {
+				if len(outcome.Errors) != 0 {
+					t.Errorf("got errors %v, want none", outcome.Errors)
+				}
+				return
+			}
+			if len(outcome.Errors) != 1 || outcome.Errors[0].Kind != tc.kind || outcome.Errors[0].Line != tc.line {
+				t.Errorf("got errors %v, want %v error at line %v", outcome.Errors, tc.kind, tc.line)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	runtimeError := func(line int) []conformance.Diagnostic {
+		return []conformance.Diagnostic{{Kind: conformance.RUNTIME_ERROR, Line: line}}
+	}
+
+	var tests = []struct {
+		name        string
+		jlox        conformance.Outcome
+		clox        conformance.Outcome
+		divergences int
+	}{
+		{"same output", conformance.Outcome{Output: []string{"1", "2"}}, conformance.Outcome{Output: []string{"1", "2"}}, 0},
+		{"different output", conformance.Outcome{Output: []string{"1", "2"}}, conformance.Outcome{Output: []string{"1", "3"}}, 1},
+		{"missing output", conformance.Outcome{Output: []string{"1", "2"}}, conformance.Outcome{Output: []string{"1"}}, 1},
+		{"same error", conformance.Outcome{Errors: runtimeError(2)}, conformance.Outcome{Errors: runtimeError(2)}, 0},
+		{"different error line", conformance.Outcome{Errors: runtimeError(2)}, conformance.Outcome{Errors: runtimeError(3)}, 1},
+		{"only one fails", conformance.Outcome{}, conformance.Outcome{Errors: runtimeError(1)}, 1},
+		{"different error kind", conformance.Outcome{Errors: []conformance.Diagnostic{{Kind: conformance.COMPILE_ERROR, Line: 1}}}, conformance.Outcome{Errors: runtimeError(1)}, 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if divergences := Compare("test.lox", tc.jlox, tc.clox); len(divergences) != tc.divergences {
+				t.Errorf("got %v, want %v divergences", divergences, tc.divergences)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	var tests = []struct {
+		name    string
+		command []string
+		expect  string
+	}{
+		{"runs the script", []string{"sh", "-c", `sed -n 's/^print \(.*\);$/\1/p' "$1"`, "sh"}, ""},
+		{"cannot compile", []string{"sh", "-c", "echo '[line 1] Error: Expect expression.' >&2; exit 65", "sh"}, "compile error: Error: Expect expression."},
+		{"ignores the script", []string{"sh", "-c", "echo 1.2", "sh"}, `printed ["1.2"]`},
+		{"missing binary", []string{"clox-does-not-exist"}, "executable file not found"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Probe(tc.command)
+			if tc.expect == "" {
+				if err != nil {
+					t.Errorf("got %v, want no error", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expect) {
+				t.Errorf("got %v, want error containing %q", err, tc.expect)
+			}
+		})
+	}
+}
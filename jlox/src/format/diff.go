@@ -0,0 +1,123 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+const DIFF_CONTEXT = 3
+
+type edit struct {
+	kind byte
+	text string
+}
+
+func Diff(name string, original string, formatted string) string {
+	if original == formatted {
+		return ""
+	}
+
+	edits := diffLines(splitLines(original), splitLines(formatted))
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s (formatted)\n", name, name)
+
+	for start := 0; start < len(edits); {
+		if edits[start].kind == ' ' {
+			start++
+			continue
+		}
+
+		from := max(start-DIFF_CONTEXT, 0)
+		end := start
+		for unchanged := 0; end < len(edits) && unchanged <= 2*DIFF_CONTEXT; end++ {
+			if edits[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		to := end
+		for to > start && edits[to-1].kind == ' ' && countTrailing(edits[start:to]) > DIFF_CONTEXT {
+			to--
+		}
+
+		oldLine, newLine := lineNumbers(edits, from)
+		oldCount, newCount := 0, 0
+		for _, edit := range edits[from:to] {
+			if edit.kind != '+' {
+				oldCount++
+			}
+			if edit.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldLine, oldCount, newLine, newCount)
+		for _, edit := range edits[from:to] {
+			fmt.Fprintf(&out, "%c%s\n", edit.kind, edit.text)
+		}
+		start = to
+	}
+	return out.String()
+}
+
+func diffLines(a []string, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	edits := make([]edit, 0)
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	return edits
+}
+
+func countTrailing(edits []edit) int {
+	count := 0
+	for i := len(edits) - 1; i >= 0 && edits[i].kind == ' '; i-- {
+		count++
+	}
+	return count
+}
+
+func lineNumbers(edits []edit, index int) (int, int) {
+	oldLine, newLine := 1, 1
+	for _, edit := range edits[:index] {
+		if edit.kind != '+' {
+			oldLine++
+		}
+		if edit.kind != '-' {
+			newLine++
+		}
+	}
+	return oldLine, newLine
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
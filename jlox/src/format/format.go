@@ -0,0 +1,373 @@
+// Package format rewrites Lox source into its canonical layout. It works on
+// the comment-preserving token stream, using the syntax tree to reject
+// programs that do not parse and to keep each else next to its if.
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/ast"
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/parser"
+)
+
+const (
+	INDENT    = "  "
+	MAX_WIDTH = 80
+)
+
+type piece struct {
+	token grammar.Token
+	text  string
+	space bool
+}
+
+type line struct {
+	indent int
+	pieces []piece
+}
+
+type Formatter struct {
+	tokens   []grammar.Token
+	lines    []line
+	current  line
+	indent   int
+	parens   int
+	previous *grammar.Token
+	unary    bool
+	elses    map[[2]int]bool
+}
+
+func Format(source string) (string, []grammar.LoxError) {
+	if strings.TrimSpace(source) == "" {
+		return "", nil
+	}
+	stmts, errs := validate(source)
+	if len(errs) > 0 {
+		return "", errs
+	}
+
+	lexer := lexer.Lexer{Source: []rune(source), KeepComments: true}
+	tokens, _ := lexer.Tokenize()
+	formatter := Formatter{tokens: tokens, elses: elseKeywords(stmts)}
+	return formatter.format(), nil
+}
+
+func validate(source string) ([]grammar.Statement, []grammar.LoxError) {
+	lexer := lexer.Lexer{Source: []rune(source)}
+	tokens, lexErrs := lexer.Tokenize()
+	if len(lexErrs) > 0 {
+		errs := make([]grammar.LoxError, 0)
+		for _, err := range lexErrs {
+			errs = append(errs, err)
+		}
+		return nil, errs
+	}
+	if len(tokens) == 1 {
+		return nil, nil
+	}
+
+	parser := parser.Parser{Tokens: tokens}
+	stmts, err := parser.Parse()
+	if err != nil {
+		return nil, []grammar.LoxError{err}
+	}
+	return stmts, nil
+}
+
+// elseKeywords finds the else of every if statement in the tree, keyed by
+// position. A then branch that one of them follows keeps it on its last line.
+func elseKeywords(stmts []grammar.Statement) map[[2]int]bool {
+	elses := make(map[[2]int]bool)
+	nodes, err := ast.Encode(stmts)
+	if err != nil {
+		return elses
+	}
+	var walk func(value any)
+	walk = func(value any) {
+		switch value := value.(type) {
+		case *ast.Node:
+			if keyword, ok := value.Get("else"); ok && value.Kind == "ConditionalStatement" {
+				if token, ok := keyword.(grammar.Token); ok {
+					elses[[2]int{token.Line, token.Column}] = true
+				}
+			}
+			for _, field := range value.Fields {
+				walk(field.Value)
+			}
+		case []any:
+			for _, item := range value {
+				walk(item)
+			}
+		}
+	}
+	walk(nodes)
+	return elses
+}
+
+func (formatter *Formatter) format() string {
+	for i, token := range formatter.tokens {
+		switch token.TokenType {
+		case grammar.EOF:
+			formatter.endLine()
+		case grammar.COMMENT:
+			formatter.comment(token, formatter.peek(i))
+		case grammar.LEFT_BRACE:
+			formatter.add(token, formatter.hasContent())
+			if next := formatter.peek(i); next.TokenType != grammar.RIGHT_BRACE {
+				formatter.endLine()
+				formatter.indent++
+			}
+		case grammar.RIGHT_BRACE:
+			if formatter.lastText() != "{" {
+				formatter.endLine()
+				formatter.indent--
+			}
+			formatter.add(token, false)
+			if next := formatter.peek(i); !formatter.elseFollows(i) && next.TokenType != grammar.SEMICOLON {
+				formatter.endLine()
+			}
+		case grammar.SEMICOLON:
+			formatter.add(token, false)
+			if formatter.parens == 0 && !formatter.elseFollows(i) {
+				formatter.endLine()
+			}
+		case grammar.LEFT_PAREN:
+			formatter.add(token, formatter.spaceBeforeParen())
+			formatter.parens++
+		case grammar.RIGHT_PAREN:
+			formatter.parens = max(formatter.parens-1, 0)
+			formatter.add(token, false)
+		case grammar.COMMA, grammar.DOT, grammar.COLON:
+			formatter.add(token, false)
+		case grammar.MINUS, grammar.BANG:
+			unary := token.TokenType == grammar.BANG || !formatter.afterOperand()
+			formatter.add(token, formatter.spaceBefore())
+			formatter.unary = unary
+		default:
+			formatter.add(token, formatter.spaceBefore())
+		}
+	}
+
+	lines := make([]line, 0, len(formatter.lines))
+	for _, line := range formatter.lines {
+		lines = append(lines, wrap(line)...)
+	}
+	for len(lines) > 0 && len(lines[len(lines)-1].pieces) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	for _, line := range lines {
+		out.WriteString(line.String())
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+func (formatter *Formatter) add(token grammar.Token, space bool) {
+	if !formatter.hasContent() {
+		formatter.blankLine(token)
+		formatter.current.indent = formatter.indent
+		space = false
+	}
+	formatter.current.pieces = append(formatter.current.pieces, piece{token: token, text: text(token), space: space})
+	formatter.previous = &token
+	formatter.unary = false
+}
+
+func (formatter *Formatter) comment(token grammar.Token, next grammar.Token) {
+	if formatter.previous != nil && token.Line == endLine(*formatter.previous) {
+		if !formatter.hasContent() && len(formatter.lines) > 0 {
+			last := &formatter.lines[len(formatter.lines)-1]
+			last.pieces = append(last.pieces, piece{token: token, text: text(token), space: true})
+			formatter.previous = &token
+			return
+		}
+		formatter.add(token, true)
+	} else {
+		formatter.endLine()
+		formatter.add(token, false)
+	}
+	if strings.HasPrefix(text(token), "//") || next.Line > endLine(token) {
+		formatter.endLine()
+	}
+}
+
+func (formatter *Formatter) blankLine(token grammar.Token) {
+	if formatter.previous == nil || len(formatter.lines) == 0 || token.Line <= endLine(*formatter.previous)+1 {
+		return
+	}
+	if token.TokenType == grammar.RIGHT_BRACE || formatter.lastText() == "{" {
+		return
+	}
+	formatter.lines = append(formatter.lines, line{})
+}
+
+func (formatter *Formatter) endLine() {
+	if formatter.hasContent() {
+		formatter.lines = append(formatter.lines, formatter.current)
+	}
+	formatter.current = line{}
+}
+
+func (formatter *Formatter) hasContent() bool {
+	return len(formatter.current.pieces) > 0
+}
+
+func (formatter *Formatter) peek(i int) grammar.Token {
+	if i+1 < len(formatter.tokens) {
+		return formatter.tokens[i+1]
+	}
+	return grammar.Token{TokenType: grammar.EOF}
+}
+
+// elseFollows tells whether the token after i is the else of an if, which
+// then goes on the line ending its then branch.
+func (formatter *Formatter) elseFollows(i int) bool {
+	next := formatter.peek(i)
+	return formatter.elses[[2]int{next.Line, next.Column}]
+}
+
+func (formatter *Formatter) lastText() string {
+	pieces := formatter.current.pieces
+	if len(pieces) == 0 && len(formatter.lines) > 0 {
+		pieces = formatter.lines[len(formatter.lines)-1].pieces
+	}
+	if len(pieces) == 0 {
+		return ""
+	}
+	return pieces[len(pieces)-1].text
+}
+
+func (formatter *Formatter) spaceBefore() bool {
+	if formatter.previous == nil || formatter.unary {
+		return false
+	}
+	switch formatter.previous.TokenType {
+	case grammar.LEFT_PAREN, grammar.DOT:
+		return false
+	}
+	return true
+}
+
+func (formatter *Formatter) spaceBeforeParen() bool {
+	if !formatter.spaceBefore() {
+		return false
+	}
+	switch formatter.previous.TokenType {
+	case grammar.IDENTIFIER, grammar.RIGHT_PAREN, grammar.THIS, grammar.SUPER:
+		return false
+	}
+	return true
+}
+
+func (formatter *Formatter) afterOperand() bool {
+	if formatter.previous == nil {
+		return false
+	}
+	switch formatter.previous.TokenType {
+	case grammar.IDENTIFIER, grammar.NUMBER, grammar.STRING, grammar.TRUE, grammar.FALSE, grammar.NULL, grammar.THIS, grammar.RIGHT_PAREN:
+		return true
+	}
+	return false
+}
+
+func (line line) String() string {
+	var out strings.Builder
+	if len(line.pieces) > 0 {
+		out.WriteString(strings.Repeat(INDENT, line.indent))
+	}
+	for _, piece := range line.pieces {
+		if piece.space {
+			out.WriteString(" ")
+		}
+		out.WriteString(piece.text)
+	}
+	return out.String()
+}
+
+func wrap(original line) []line {
+	if len(original.String()) <= MAX_WIDTH {
+		return []line{original}
+	}
+	for _, piece := range original.pieces {
+		if piece.token.TokenType == grammar.COMMENT {
+			return []line{original}
+		}
+	}
+
+	for open, piece := range original.pieces {
+		if piece.token.TokenType != grammar.LEFT_PAREN {
+			continue
+		}
+		close, commas := matchParen(original.pieces, open)
+		if close >= 0 && len(commas) > 0 {
+			return splitArguments(original, open, commas, close)
+		}
+	}
+	return []line{original}
+}
+
+func matchParen(pieces []piece, open int) (int, []int) {
+	depth := 0
+	commas := make([]int, 0)
+	for i := open + 1; i < len(pieces); i++ {
+		switch pieces[i].token.TokenType {
+		case grammar.LEFT_PAREN:
+			depth++
+		case grammar.RIGHT_PAREN:
+			if depth == 0 {
+				return i, commas
+			}
+			depth--
+		case grammar.COMMA:
+			if depth == 0 {
+				commas = append(commas, i)
+			}
+		}
+	}
+	return -1, commas
+}
+
+func splitArguments(original line, open int, commas []int, close int) []line {
+	pieces := original.pieces
+	lines := []line{{indent: original.indent, pieces: pieces[:open+1]}}
+
+	start := open + 1
+	for _, end := range append(commas, close) {
+		argument := append([]piece{}, pieces[start:end]...)
+		if end != close {
+			argument = append(argument, pieces[end])
+		}
+		argument[0].space = false
+		lines = append(lines, wrap(line{indent: original.indent + 1, pieces: argument})...)
+		start = end + 1
+	}
+
+	rest := append([]piece{}, pieces[close:]...)
+	rest[0].space = false
+	return append(lines, wrap(line{indent: original.indent, pieces: rest})...)
+}
+
+func text(token grammar.Token) string {
+	switch token.TokenType {
+	case grammar.STRING:
+		return fmt.Sprintf("\"%v\"", token.Lexeme)
+	case grammar.NUMBER:
+		if number, ok := token.Lexeme.(float64); ok {
+			return strconv.FormatFloat(number, 'f', -1, 64)
+		}
+	}
+	return fmt.Sprintf("%v", token.Lexeme)
+}
+
+func endLine(token grammar.Token) int {
+	return token.Line + strings.Count(text(token), "\n")
+}
@@ -0,0 +1,127 @@
+package format
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+)
+
+func TestFormat(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+		expect string
+	}{
+		{"spacing", "var   a=-1 ;var b = !true;", "var a = -1;\nvar b = !true;\n"},
+		{"binary minus", "print a-1 - -b;", "print a - 1 - -b;\n"},
+		{"blocks", "if(a<=b){print a;}else{print b;}", "if (a <= b) {\n  print a;\n} else {\n  print b;\n}\n"},
+		{"else after statement", "if (a) print 1;\nelse print 2;", "if (a) print 1; else print 2;\n"},
+		{"else if", "if (a) print 1; else if (b) { print 2; }\nelse print 3;", "if (a) print 1; else if (b) {\n  print 2;\n} else print 3;\n"},
+		{"nested if", "if (a) if (b) print 1; else print 2;", "if (a) if (b) print 1; else print 2;\n"},
+		{"empty block", "class A { m() {} }", "class A {\n  m() {}\n}\n"},
+		{"for loop", "for(var i=0;i<3;i=i+1) print i;\nfor(;;){}", "for (var i = 0; i < 3; i = i + 1) print i;\nfor (;;) {}\n"},
+		{"calls and properties", "a . b(1,2)(3) . c = this . d;", "a.b(1, 2)(3).c = this.d;\n"},
+		{"type annotations", "func f(a:number) :string { return str( a ); }", "func f(a: number): string {\n  return str(a);\n}\n"},
+		{"comments", "// lead\nvar a = 1;   // trailing\n/* own\n line */\nprint a;", "// lead\nvar a = 1; // trailing\n/* own\n line */\nprint a;\n"},
+		{"blank lines", "var a = 1;\n\n\n\nvar b = 2;\n{\n\nprint b;\n\n}", "var a = 1;\n\nvar b = 2;\n{\n  print b;\n}\n"},
+		{"numbers", "print 1.50 + 100000000000000000000;", "print 1.5 + 100000000000000000000;\n"},
+		{"multiline string", "print \"a\nb\";", "print \"a\nb\";\n"},
+		{
+			"wrapped arguments",
+			"print someVeryLongFunctionName(argumentNumberOne, argumentNumberTwo, argumentNumberThree);",
+			"print someVeryLongFunctionName(\n  argumentNumberOne,\n  argumentNumberTwo,\n  argumentNumberThree\n);\n",
+		},
+		{"empty source", "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			formatted, errs := Format(tc.source)
+			if len(errs) > 0 {
+				t.Fatalf("got errors %v", errs)
+			}
+			if formatted != tc.expect {
+				t.Errorf("got\n%s\nwant\n%s", formatted, tc.expect)
+			}
+		})
+	}
+}
+
+func TestFormatRejectsInvalidSource(t *testing.T) {
+	for _, source := range []string{"print ;", "var a = \"open", "class {"} {
+		if _, errs := Format(source); len(errs) == 0 {
+			t.Errorf("expected errors for %q", source)
+		}
+	}
+}
+
+func TestFormatPreservesTokens(t *testing.T) {
+	paths, err := filepath.Glob("../conformance/testdata/*/*.lox")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no corpus found: %v", err)
+	}
+
+	for _, path := range append(paths, "../repl/example.lox") {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		formatted, errs := Format(string(source))
+		if len(errs) > 0 {
+			continue
+		}
+		if again, _ := Format(formatted); again != formatted {
+			t.Errorf("%s: formatting is not idempotent:\n%s", path, Diff(path, formatted, again))
+		}
+		if before, after := tokens(string(source)), tokens(formatted); before != after {
+			t.Errorf("%s: formatting changed tokens", path)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	original := "a\nb\nc\nd\ne\nf\ng\nh\ni\nj\nk\n"
+	formatted := "a\nB\nc\nd\ne\nf\ng\nh\ni\nj\nK\n"
+	expect := strings.Join([]string{
+		"--- test.lox",
+		"+++ test.lox (formatted)",
+		"@@ -1,5 +1,5 @@",
+		" a",
+		"-b",
+		"+B",
+		" c",
+		" d",
+		" e",
+		"@@ -8,4 +8,4 @@",
+		" h",
+		" i",
+		" j",
+		"-k",
+		"+K",
+		"",
+	}, "\n")
+
+	if diff := Diff("test.lox", original, formatted); diff != expect {
+		t.Errorf("got\n%s\nwant\n%s", diff, expect)
+	}
+	if diff := Diff("test.lox", original, original); diff != "" {
+		t.Errorf("got %q for identical input", diff)
+	}
+}
+
+func tokens(source string) string {
+	lexer := lexer.Lexer{Source: []rune(source), KeepComments: true}
+	tokens, _ := lexer.Tokenize()
+	var out strings.Builder
+	for _, token := range tokens {
+		if token.TokenType != grammar.EOF {
+			fmt.Fprintf(&out, "%d:%v ", token.TokenType, token.Lexeme)
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,226 @@
+// Package generator produces random, syntactically valid Lox programs for
+// fuzzing the lexer, parser and interpreter.
+package generator
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const MAX_DEPTH = 4
+
+var (
+	variables = []string{"a", "b", "c", "count", "name"}
+	functions = []string{"f", "g", "fib"}
+	classes   = []string{"Point", "Shape", "Node"}
+	fields    = []string{"x", "y", "next", "value"}
+	operators = []string{"+", "-", "*", "/", "==", "!=", "<", "<=", ">", ">=", "and", "or"}
+	strs      = []string{`""`, `"lox"`, `"a b"`, `"1"`}
+	natives   = []string{"clock()", "len(\"abc\")", "sqrt(4)", "str(1)", "type(null)"}
+)
+
+type Generator struct {
+	Rand    *rand.Rand
+	out     strings.Builder
+	indent  int
+	depth   int
+	inFunc  bool
+	inClass bool
+	inSub   bool
+}
+
+func Program(seed int64, statements int) string {
+	generator := Generator{Rand: rand.New(rand.NewSource(seed))}
+	return generator.Program(statements)
+}
+
+func (generator *Generator) Program(statements int) string {
+	generator.out.Reset()
+	for i := 0; i < statements; i++ {
+		generator.declaration()
+	}
+	return generator.out.String()
+}
+
+func (generator *Generator) pick(options []string) string {
+	return options[generator.Rand.Intn(len(options))]
+}
+
+func (generator *Generator) line(format string, args ...any) {
+	generator.out.WriteString(strings.Repeat("  ", generator.indent))
+	generator.out.WriteString(fmt.Sprintf(format, args...))
+	generator.out.WriteString("\n")
+}
+
+func (generator *Generator) declaration() {
+	if generator.depth >= MAX_DEPTH {
+		generator.statement()
+		return
+	}
+
+	switch generator.Rand.Intn(10) {
+	case 0:
+		generator.function(generator.pick(functions), "func ")
+	case 1:
+		generator.class()
+	case 2, 3:
+		generator.line("var %s = %s;", generator.pick(variables), generator.expression())
+	default:
+		generator.statement()
+	}
+}
+
+func (generator *Generator) statement() {
+	if generator.depth >= MAX_DEPTH {
+		generator.line("print %s;", generator.expression())
+		return
+	}
+
+	generator.depth++
+	defer func() { generator.depth-- }()
+
+	switch generator.Rand.Intn(9) {
+	case 0:
+		generator.line("if (%s) {", generator.expression())
+		generator.block()
+		if generator.Rand.Intn(2) == 0 {
+			generator.line("} else {")
+			generator.block()
+		}
+		generator.line("}")
+	case 1:
+		generator.line("while (%s) {", generator.expression())
+		generator.block()
+		generator.line("}")
+	case 2:
+		generator.line("for (var i = 0; i < %d; i = i + 1) {", generator.Rand.Intn(4))
+		generator.block()
+		generator.line("}")
+	case 3:
+		generator.line("{")
+		generator.block()
+		generator.line("}")
+	case 4:
+		if generator.inFunc {
+			generator.line("return %s;", generator.expression())
+			return
+		}
+		fallthrough
+	case 5:
+		generator.line("%s = %s;", generator.pick(variables), generator.expression())
+	default:
+		generator.line("print %s;", generator.expression())
+	}
+}
+
+func (generator *Generator) block() {
+	generator.indent++
+	for i := generator.Rand.Intn(3); i >= 0; i-- {
+		generator.declaration()
+	}
+	generator.indent--
+}
+
+func (generator *Generator) function(name string, keyword string) {
+	params := make([]string, generator.Rand.Intn(3))
+	for i := range params {
+		params[i] = fmt.Sprintf("p%d", i)
+	}
+
+	inFunc := generator.inFunc
+	generator.inFunc = true
+	generator.depth++
+	generator.line("%s%s(%s) {", keyword, name, strings.Join(params, ", "))
+	generator.block()
+	generator.line("}")
+	generator.depth--
+	generator.inFunc = inFunc
+}
+
+func (generator *Generator) class() {
+	name := generator.pick(classes)
+	super := generator.pick(classes)
+	if super != name && generator.Rand.Intn(2) == 0 {
+		generator.line("class %s < %s {", name, super)
+		generator.inSub = true
+	} else {
+		generator.line("class %s {", name)
+	}
+
+	generator.inClass = true
+	generator.indent++
+	for i := generator.Rand.Intn(3); i >= 0; i-- {
+		if i == 0 && generator.Rand.Intn(2) == 0 {
+			generator.function("constructor", "")
+		} else {
+			generator.function(generator.pick(fields), "")
+		}
+	}
+	generator.indent--
+	generator.inClass = false
+	generator.inSub = false
+	generator.line("}")
+}
+
+func (generator *Generator) expression() string {
+	generator.depth++
+	defer func() { generator.depth-- }()
+
+	if generator.depth >= MAX_DEPTH+2 {
+		return generator.primary()
+	}
+
+	switch generator.Rand.Intn(8) {
+	case 0:
+		return fmt.Sprintf("%s %s %s", generator.expression(), generator.pick(operators), generator.expression())
+	case 1:
+		return fmt.Sprintf("%s%s", generator.pick([]string{"-", "!"}), generator.primary())
+	case 2:
+		return fmt.Sprintf("(%s)", generator.expression())
+	case 3:
+		return generator.call()
+	case 4:
+		return fmt.Sprintf("%s.%s", generator.primary(), generator.pick(fields))
+	default:
+		return generator.primary()
+	}
+}
+
+func (generator *Generator) call() string {
+	if generator.Rand.Intn(4) == 0 {
+		return generator.pick(natives)
+	}
+
+	callee := generator.pick(functions)
+	if generator.Rand.Intn(2) == 0 {
+		callee = generator.pick(classes)
+	}
+	args := make([]string, generator.Rand.Intn(3))
+	for i := range args {
+		args[i] = generator.expression()
+	}
+	return fmt.Sprintf("%s(%s)", callee, strings.Join(args, ", "))
+}
+
+func (generator *Generator) primary() string {
+	switch generator.Rand.Intn(9) {
+	case 0:
+		return fmt.Sprint(generator.Rand.Intn(100))
+	case 1:
+		return fmt.Sprintf("%d.%d", generator.Rand.Intn(10), generator.Rand.Intn(100))
+	case 2:
+		return generator.pick(strs)
+	case 3:
+		return generator.pick([]string{"true", "false", "null"})
+	case 4:
+		if generator.inClass {
+			return "this"
+		}
+	case 5:
+		if generator.inSub {
+			return fmt.Sprintf("super.%s", generator.pick(fields))
+		}
+	}
+	return generator.pick(variables)
+}
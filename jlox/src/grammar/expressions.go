@@ -1,6 +1,10 @@
 package grammar
 
-type Expression any
+// Expression is implemented by every expression node. Accept calls the
+// visitor method for the node's type.
+type Expression interface {
+	Accept(visitor ExprVisitor) (any, LoxError)
+}
 
 type BinaryExpression struct {
 	Left     Expression
@@ -0,0 +1,57 @@
+package grammar
+
+func FirstToken(node any) (Token, bool) {
+	switch nodeType := node.(type) {
+	case Token:
+		return nodeType, true
+	case ExpressionStatement:
+		return FirstToken(nodeType.Expression)
+	case PrintStatement:
+		return nodeType.Keyword, true
+	case VariableDeclarationStatement:
+		return nodeType.Name, true
+	case FunctionDeclarationStatement:
+		return nodeType.Name, true
+	case ClassDeclarationStatement:
+		return nodeType.Name, true
+	case ReturnStatement:
+		return nodeType.Keyword, true
+	case BlockScopeStatement:
+		for _, stmt := range nodeType.Statements {
+			if token, ok := FirstToken(stmt); ok {
+				return token, true
+			}
+		}
+	case ConditionalStatement:
+		return nodeType.Keyword, true
+	case WhileLoopStatement:
+		return nodeType.Keyword, true
+	case ImportStatement:
+		return nodeType.Keyword, true
+	case ExportStatement:
+		return nodeType.Keyword, true
+	case BinaryExpression:
+		return FirstToken(nodeType.Left)
+	case UnaryExpression:
+		return nodeType.Operator, true
+	case GroupingExpression:
+		return FirstToken(nodeType.Expression)
+	case VariableDeclaration:
+		return nodeType.Name, true
+	case AssignmentExpression:
+		return nodeType.Name, true
+	case LogicExpression:
+		return FirstToken(nodeType.Left)
+	case CallExpression:
+		return FirstToken(nodeType.Callee)
+	case PropertyAccessExpression:
+		return FirstToken(nodeType.Object)
+	case PropertyAssignmentExpression:
+		return FirstToken(nodeType.Object)
+	case SelfReferenceExpression:
+		return nodeType.Keyword, true
+	case BaseClassCallExpression:
+		return nodeType.Keyword, true
+	}
+	return Token{}, false
+}
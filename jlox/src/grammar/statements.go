@@ -1,29 +1,38 @@
 package grammar
 
-type Statement any
+// Statement is implemented by every statement node. The parser leaves nil
+// statements in the blocks it builds for for loops.
+type Statement interface {
+	Accept(visitor StmtVisitor) (any, LoxError)
+}
 
 type ExpressionStatement struct {
 	Expression Expression
 }
 
 type PrintStatement struct {
-	Value Expression
+	Keyword Token
+	Value   Expression
 }
 
 type VariableDeclarationStatement struct {
 	Name        Token
+	Type        *TypeAnnotation
 	Initializer Expression
 }
 
 type FunctionDeclarationStatement struct {
-	Name   Token
-	Params []Token
-	Body   BlockScopeStatement
+	Name       Token
+	Params     []Token
+	ParamTypes []*TypeAnnotation
+	ReturnType *TypeAnnotation
+	Body       BlockScopeStatement
 }
 
 type ClassDeclarationStatement struct {
 	Name    Token
 	Super   Expression
+	Fields  []VariableDeclarationStatement
 	Methods []FunctionDeclarationStatement
 }
 
@@ -33,16 +42,31 @@ type ReturnStatement struct {
 }
 
 type BlockScopeStatement struct {
+	Brace      Token
 	Statements []Statement
 }
 
 type ConditionalStatement struct {
+	Keyword    Token
 	Condition  Expression
 	ThenBranch Statement
+	Else       Token
 	ElseBranch Statement
 }
 
 type WhileLoopStatement struct {
+	Keyword   Token
 	Condition Expression
 	Body      Statement
 }
+
+type ImportStatement struct {
+	Keyword Token
+	Path    Token
+	Alias   Token
+}
+
+type ExportStatement struct {
+	Keyword     Token
+	Declaration Statement
+}
@@ -11,6 +11,7 @@ const (
 	MINUS
 	PLUS
 	SEMICOLON
+	COLON
 	SLASH
 	STAR
 
@@ -28,6 +29,7 @@ const (
 	IDENTIFIER
 	STRING
 	NUMBER
+	COMMENT
 
 	// keywords
 	AND
@@ -46,6 +48,9 @@ const (
 	TRUE
 	VAR
 	WHILE
+	IMPORT
+	EXPORT
+	AS
 	EOF
 )
 
@@ -66,12 +71,64 @@ var KEYWORDS = map[string]int{
 	"true":   TRUE,
 	"var":    VAR,
 	"while":  WHILE,
+	"import": IMPORT,
+	"export": EXPORT,
+	"as":     AS,
 }
 
-var SYNC_TOKENS = []int{CLASS, FUNC, VAR, FOR, IF, WHILE, PRINT, RETURN}
+var TOKEN_NAMES = map[int]string{
+	LEFT_PAREN:    "LEFT_PAREN",
+	RIGHT_PAREN:   "RIGHT_PAREN",
+	LEFT_BRACE:    "LEFT_BRACE",
+	RIGHT_BRACE:   "RIGHT_BRACE",
+	COMMA:         "COMMA",
+	DOT:           "DOT",
+	MINUS:         "MINUS",
+	PLUS:          "PLUS",
+	SEMICOLON:     "SEMICOLON",
+	COLON:         "COLON",
+	SLASH:         "SLASH",
+	STAR:          "STAR",
+	BANG:          "BANG",
+	BANG_EQUAL:    "BANG_EQUAL",
+	EQUAL:         "EQUAL",
+	EQUAL_EQUAL:   "EQUAL_EQUAL",
+	GREATER:       "GREATER",
+	GREATER_EQUAL: "GREATER_EQUAL",
+	LESS:          "LESS",
+	LESS_EQUAL:    "LESS_EQUAL",
+	IDENTIFIER:    "IDENTIFIER",
+	STRING:        "STRING",
+	NUMBER:        "NUMBER",
+	COMMENT:       "COMMENT",
+	AND:           "AND",
+	CLASS:         "CLASS",
+	ELSE:          "ELSE",
+	FALSE:         "FALSE",
+	FUNC:          "FUNC",
+	FOR:           "FOR",
+	IF:            "IF",
+	NULL:          "NULL",
+	OR:            "OR",
+	PRINT:         "PRINT",
+	RETURN:        "RETURN",
+	SUPER:         "SUPER",
+	THIS:          "THIS",
+	TRUE:          "TRUE",
+	VAR:           "VAR",
+	WHILE:         "WHILE",
+	IMPORT:        "IMPORT",
+	EXPORT:        "EXPORT",
+	AS:            "AS",
+	EOF:           "EOF",
+}
+
+var SYNC_TOKENS = []int{CLASS, FUNC, VAR, FOR, IF, WHILE, PRINT, RETURN, IMPORT, EXPORT}
 
 type Token struct {
 	TokenType int
 	Lexeme    any
 	Literal   any
+	Line      int
+	Column    int
 }
@@ -0,0 +1,5 @@
+package grammar
+
+type TypeAnnotation struct {
+	Name Token
+}
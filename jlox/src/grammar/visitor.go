@@ -0,0 +1,125 @@
+package grammar
+
+// ExprVisitor has a method for every expression node, so a visitor that
+// misses one does not compile.
+type ExprVisitor interface {
+	VisitBinaryExpression(expr BinaryExpression) (any, LoxError)
+	VisitUnaryExpression(expr UnaryExpression) (any, LoxError)
+	VisitLiteralExpression(expr LiteralExpression) (any, LoxError)
+	VisitGroupingExpression(expr GroupingExpression) (any, LoxError)
+	VisitVariableDeclaration(expr VariableDeclaration) (any, LoxError)
+	VisitAssignmentExpression(expr AssignmentExpression) (any, LoxError)
+	VisitLogicExpression(expr LogicExpression) (any, LoxError)
+	VisitCallExpression(expr CallExpression) (any, LoxError)
+	VisitPropertyAccessExpression(expr PropertyAccessExpression) (any, LoxError)
+	VisitPropertyAssignmentExpression(expr PropertyAssignmentExpression) (any, LoxError)
+	VisitSelfReferenceExpression(expr SelfReferenceExpression) (any, LoxError)
+	VisitBaseClassCallExpression(expr BaseClassCallExpression) (any, LoxError)
+}
+
+// StmtVisitor has a method for every statement node.
+type StmtVisitor interface {
+	VisitExpressionStatement(stmt ExpressionStatement) (any, LoxError)
+	VisitPrintStatement(stmt PrintStatement) (any, LoxError)
+	VisitVariableDeclarationStatement(stmt VariableDeclarationStatement) (any, LoxError)
+	VisitFunctionDeclarationStatement(stmt FunctionDeclarationStatement) (any, LoxError)
+	VisitClassDeclarationStatement(stmt ClassDeclarationStatement) (any, LoxError)
+	VisitReturnStatement(stmt ReturnStatement) (any, LoxError)
+	VisitBlockScopeStatement(stmt BlockScopeStatement) (any, LoxError)
+	VisitConditionalStatement(stmt ConditionalStatement) (any, LoxError)
+	VisitWhileLoopStatement(stmt WhileLoopStatement) (any, LoxError)
+	VisitImportStatement(stmt ImportStatement) (any, LoxError)
+	VisitExportStatement(stmt ExportStatement) (any, LoxError)
+}
+
+func (expr BinaryExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitBinaryExpression(expr)
+}
+
+func (expr UnaryExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitUnaryExpression(expr)
+}
+
+func (expr LiteralExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitLiteralExpression(expr)
+}
+
+func (expr GroupingExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitGroupingExpression(expr)
+}
+
+func (expr VariableDeclaration) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitVariableDeclaration(expr)
+}
+
+func (expr AssignmentExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitAssignmentExpression(expr)
+}
+
+func (expr LogicExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitLogicExpression(expr)
+}
+
+func (expr CallExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitCallExpression(expr)
+}
+
+func (expr PropertyAccessExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitPropertyAccessExpression(expr)
+}
+
+func (expr PropertyAssignmentExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitPropertyAssignmentExpression(expr)
+}
+
+func (expr SelfReferenceExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitSelfReferenceExpression(expr)
+}
+
+func (expr BaseClassCallExpression) Accept(visitor ExprVisitor) (any, LoxError) {
+	return visitor.VisitBaseClassCallExpression(expr)
+}
+
+func (stmt ExpressionStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitExpressionStatement(stmt)
+}
+
+func (stmt PrintStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitPrintStatement(stmt)
+}
+
+func (stmt VariableDeclarationStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitVariableDeclarationStatement(stmt)
+}
+
+func (stmt FunctionDeclarationStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitFunctionDeclarationStatement(stmt)
+}
+
+func (stmt ClassDeclarationStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitClassDeclarationStatement(stmt)
+}
+
+func (stmt ReturnStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitReturnStatement(stmt)
+}
+
+func (stmt BlockScopeStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitBlockScopeStatement(stmt)
+}
+
+func (stmt ConditionalStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitConditionalStatement(stmt)
+}
+
+func (stmt WhileLoopStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitWhileLoopStatement(stmt)
+}
+
+func (stmt ImportStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitImportStatement(stmt)
+}
+
+func (stmt ExportStatement) Accept(visitor StmtVisitor) (any, LoxError) {
+	return visitor.VisitExportStatement(stmt)
+}
@@ -5,14 +5,17 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/DrEmbryo/jlox/src/grammar"
 )
 
 type Lexer struct {
-	Source  []rune
-	current int
-	line    int
+	Source       []rune
+	KeepComments bool
+	current      int
+	line         int
+	lineStart    int
 }
 
 func (lexer *Lexer) consume() rune {
@@ -22,10 +25,25 @@ func (lexer *Lexer) consume() rune {
 }
 
 func (lexer *Lexer) lookahead() rune {
+	if lexer.current >= len(lexer.Source) {
+		return 0
+	}
 	char := lexer.Source[lexer.current]
 	return char
 }
 
+func (lexer *Lexer) lookaheadNext() rune {
+	if lexer.current+1 >= len(lexer.Source) {
+		return 0
+	}
+	return lexer.Source[lexer.current+1]
+}
+
+func (lexer *Lexer) newLine() {
+	lexer.line++
+	lexer.lineStart = lexer.current
+}
+
 func (lexer Lexer) Tokenize() ([]grammar.Token, []LexerError) {
 	tokens := make([]grammar.Token, 0)
 	lexErrors := make([]LexerError, 0)
@@ -35,10 +53,15 @@ func (lexer Lexer) Tokenize() ([]grammar.Token, []LexerError) {
 		return tokens, lexErrors
 	}
 
+	lexer.line = 1
 	for lexer.current <= len(lexer.Source)-1 {
+		column := lexer.current - lexer.lineStart + 1
 		char := lexer.consume()
+		line := lexer.line
 		switch token := lexer.parseSingleCharToken(&char).(type) {
 		case grammar.Token:
+			token.Line = line
+			token.Column = column
 			tokens = append(tokens, token)
 		case LexerError:
 			lexErrors = append(lexErrors, token)
@@ -46,7 +69,7 @@ func (lexer Lexer) Tokenize() ([]grammar.Token, []LexerError) {
 
 	}
 
-	tokens = append(tokens, grammar.Token{TokenType: grammar.EOF, Lexeme: "EOF"})
+	tokens = append(tokens, grammar.Token{TokenType: grammar.EOF, Lexeme: "EOF", Line: lexer.line, Column: lexer.current - lexer.lineStart + 1})
 
 	return tokens, lexErrors
 }
@@ -71,14 +94,20 @@ func (lexer *Lexer) parseSingleCharToken(char *rune) any {
 		return grammar.Token{TokenType: grammar.PLUS, Lexeme: string(*char)}
 	case ';':
 		return grammar.Token{TokenType: grammar.SEMICOLON, Lexeme: string(*char)}
+	case ':':
+		return grammar.Token{TokenType: grammar.COLON, Lexeme: string(*char)}
 	case '*':
 		return grammar.Token{TokenType: grammar.STAR, Lexeme: string(*char)}
 	case '/':
 		switch {
 		case lexer.lookahead() == '/':
+			start := lexer.current - 1
 			lexer.parseSingleLineComments(char)
+			return lexer.comment(start)
 		case lexer.lookahead() == '*':
+			start := lexer.current - 1
 			lexer.parseMultilineLineComments(char)
+			return lexer.comment(start)
 		default:
 			return grammar.Token{TokenType: grammar.SLASH, Lexeme: string(*char)}
 		}
@@ -120,7 +149,7 @@ func (lexer *Lexer) parseMultiCahrToken(char *rune) any {
 		}
 		return token
 	case '\n':
-		lexer.line++
+		lexer.newLine()
 	default:
 		switch {
 		case parseDigit(char):
@@ -145,7 +174,8 @@ func (lexer *Lexer) parseString(char *rune) (grammar.Token, grammar.LoxError) {
 		case '"':
 			return grammar.Token{TokenType: grammar.STRING, Lexeme: buff.String()}, nil
 		case '\n':
-			lexer.line++
+			lexer.newLine()
+			buff.WriteRune(*char)
 		default:
 			buff.WriteRune(*char)
 		}
@@ -156,35 +186,28 @@ func (lexer *Lexer) parseString(char *rune) (grammar.Token, grammar.LoxError) {
 func (lexer *Lexer) parseNumerics(char *rune) grammar.Token {
 	buff := bytes.NewBufferString("")
 	buff.WriteRune(*char)
-	for lexer.current <= len(lexer.Source)-1 {
-		*char = lexer.consume()
-		if parseDigit(char) {
-			buff.WriteRune(*char)
-		} else {
-			break
-		}
-	}
+	lexer.consumeDigits(buff)
 
-	if *char == '.' {
-		buff.WriteRune(*char)
-		for lexer.current <= len(lexer.Source)-1 {
-			*char = lexer.consume()
-			if parseDigit(char) {
-				buff.WriteRune(*char)
-			} else {
-				break
-			}
-		}
-	}
-
-	if !parseDigit(char) {
-		lexer.current--
+	next := lexer.lookaheadNext()
+	if lexer.lookahead() == '.' && parseDigit(&next) {
+		buff.WriteRune(lexer.consume())
+		lexer.consumeDigits(buff)
 	}
 
 	value, _ := strconv.ParseFloat(buff.String(), 64)
 	return grammar.Token{TokenType: grammar.NUMBER, Lexeme: value}
 }
 
+func (lexer *Lexer) consumeDigits(buff *bytes.Buffer) {
+	for {
+		next := lexer.lookahead()
+		if next == 0 || !parseDigit(&next) {
+			return
+		}
+		buff.WriteRune(lexer.consume())
+	}
+}
+
 func (lexer *Lexer) parseIdentifiers(char *rune) grammar.Token {
 	buff := bytes.NewBufferString("")
 	buff.WriteRune(*char)
@@ -210,7 +233,7 @@ func (lexer *Lexer) parseSingleLineComments(char *rune) {
 	for lexer.current <= len(lexer.Source)-1 {
 		*char = lexer.consume()
 		if *char == '\n' {
-			lexer.line++
+			lexer.newLine()
 			return
 		}
 	}
@@ -220,7 +243,7 @@ func (lexer *Lexer) parseMultilineLineComments(char *rune) {
 	for lexer.current <= len(lexer.Source)-1 {
 		*char = lexer.consume()
 		if *char == '\n' {
-			lexer.line++
+			lexer.newLine()
 		}
 		if *char == '/' && lexer.lookahead() == '*' {
 			*char = lexer.consume()
@@ -233,17 +256,28 @@ func (lexer *Lexer) parseMultilineLineComments(char *rune) {
 	}
 }
 
+var (
+	digitPattern     = regexp.MustCompile("[0-9]")
+	charPattern      = regexp.MustCompile("[A-Za-z_]")
+	skippablePattern = regexp.MustCompile("[\t\r ]")
+)
+
+func (lexer *Lexer) comment(start int) any {
+	if !lexer.KeepComments {
+		return nil
+	}
+	text := strings.TrimRight(string(lexer.Source[start:lexer.current]), "\r\n")
+	return grammar.Token{TokenType: grammar.COMMENT, Lexeme: text}
+}
+
 func parseDigit(char *rune) bool {
-	expr, _ := regexp.Compile("[0-9]")
-	return expr.MatchString(string(*char))
+	return digitPattern.MatchString(string(*char))
 }
 
 func parseChar(char *rune) bool {
-	expr, _ := regexp.Compile("[A-Za-z_]")
-	return expr.MatchString(string(*char))
+	return charPattern.MatchString(string(*char))
 }
 
 func parseSkippable(char *rune) bool {
-	expr, _ := regexp.Compile("[\t\r ]")
-	return expr.MatchString(string(*char))
+	return skippablePattern.MatchString(string(*char))
 }
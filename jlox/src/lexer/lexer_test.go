@@ -3,6 +3,9 @@ package lexer
 import (
 	"fmt"
 	"testing"
+
+	"github.com/DrEmbryo/jlox/src/generator"
+	"github.com/DrEmbryo/jlox/src/grammar"
 )
 
 func TestParseDigit(t *testing.T) {
@@ -80,3 +83,72 @@ func TestParseSkippable(t *testing.T) {
 	}
 
 }
+
+func FuzzTokenize(f *testing.F) {
+	for _, seed := range []string{"<", ">", "\"open", "/* open", "// open", "1.", "var a = 1;"} {
+		f.Add(seed)
+	}
+	for seed := int64(0); seed < 10; seed++ {
+		f.Add(generator.Program(seed, 5))
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		lexer := Lexer{Source: []rune(source)}
+		tokens, errs := lexer.Tokenize()
+		if len(source) > 0 && len(tokens) == 0 && len(errs) == 0 {
+			t.Errorf("got no tokens and no errors for %q", source)
+		}
+	})
+}
+
+func TestKeepComments(t *testing.T) {
+	source := "var a = 1; // one\n/* two\n lines */ print a;"
+	var tests = []struct {
+		name         string
+		keepComments bool
+		comments     []string
+	}{
+		{"discarded by default", false, []string{}},
+		{"kept on request", true, []string{"// one", "/* two\n lines */"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lexer := Lexer{Source: []rune(source), KeepComments: tc.keepComments}
+			tokens, errs := lexer.Tokenize()
+			if len(errs) > 0 {
+				t.Fatal(errs)
+			}
+			comments := make([]string, 0)
+			for _, token := range tokens {
+				if token.TokenType == grammar.COMMENT {
+					comments = append(comments, token.Lexeme.(string))
+				}
+			}
+			if fmt.Sprint(comments) != fmt.Sprint(tc.comments) {
+				t.Errorf("got %q, want %q", comments, tc.comments)
+			}
+			if last := tokens[len(tokens)-2]; last.Line != 3 {
+				t.Errorf("got last token on line %v, want 3", last.Line)
+			}
+		})
+	}
+}
+
+func TestColumns(t *testing.T) {
+	source := "var a = \"x\ny\";\n  print a; // c\n/* d\n */ a"
+	expect := [][3]any{{"var", 1, 1}, {"a", 1, 5}, {"=", 1, 7}, {"x\ny", 1, 9}, {";", 2, 3}, {"print", 3, 3}, {"a", 3, 9}, {";", 3, 10}, {"a", 5, 5}, {"EOF", 5, 6}}
+
+	tokens, errs := Lexer{Source: []rune(source)}.Tokenize()
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(tokens) != len(expect) {
+		t.Fatalf("got %d tokens, want %d", len(tokens), len(expect))
+	}
+	for i, token := range tokens {
+		if got := [3]any{token.Lexeme, token.Line, token.Column}; got != expect[i] {
+			t.Errorf("got %v, want %v", got, expect[i])
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+const (
+	UNUSED_VARIABLE       = "unused-variable"
+	UNUSED_PARAMETER      = "unused-parameter"
+	SHADOWED_NAME         = "shadowed-name"
+	UNREACHABLE_CODE      = "unreachable-code"
+	UNDECLARED_ASSIGNMENT = "undeclared-assignment"
+	WRONG_ARITY           = "wrong-arity"
+	THIS_OUTSIDE_METHOD   = "this-outside-method"
+	EMPTY_BLOCK           = "empty-block"
+)
+
+var RULES = []string{
+	UNUSED_VARIABLE,
+	UNUSED_PARAMETER,
+	SHADOWED_NAME,
+	UNREACHABLE_CODE,
+	UNDECLARED_ASSIGNMENT,
+	WRONG_ARITY,
+	THIS_OUTSIDE_METHOD,
+	EMPTY_BLOCK,
+}
+
+const (
+	CONFIG_FILE      = ".loxlint.json"
+	IGNORE_DIRECTIVE = "lint:ignore"
+)
+
+// Config switches rules on and off by name; rules that are not listed stay enabled.
+type Config struct {
+	Rules map[string]bool `json:"rules"`
+}
+
+func (config Config) Enabled(rule string) bool {
+	enabled, ok := config.Rules[rule]
+	return !ok || enabled
+}
+
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return ParseConfig(data)
+}
+
+func ParseConfig(data []byte) (Config, error) {
+	var config Config
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return Config{}, fmt.Errorf("invalid lint config: %w", err)
+	}
+	for rule := range config.Rules {
+		if !slices.Contains(RULES, rule) {
+			return Config{}, fmt.Errorf("invalid lint config: unknown rule '%s'", rule)
+		}
+	}
+	return config, nil
+}
@@ -0,0 +1,21 @@
+package lint
+
+import (
+	"fmt"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+type LintError struct {
+	Rule    string
+	Token   grammar.Token
+	Message string
+}
+
+func (e LintError) Print() {
+	fmt.Printf("[%d]: Lint warning at '%v': %s (%s)\n", e.Token.Line, e.Token.Lexeme, e.Message, e.Rule)
+}
+
+func (e LintError) Error() string {
+	return fmt.Sprintf("[%d]: Lint warning at '%v': %s (%s)", e.Token.Line, e.Token.Lexeme, e.Message, e.Rule)
+}
@@ -0,0 +1,481 @@
+// Package lint reports suspicious but valid Lox code. It walks the parsed
+// program with the same scope rules as the resolver and records how every
+// declaration is used.
+package lint
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/parser"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/stdlib"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+const (
+	VARIABLE = iota
+	PARAMETER
+	FUNCTION
+	CLASS
+	IMPORT
+	NATIVE
+)
+
+const (
+	NONE = iota
+	METHOD
+)
+
+const (
+	NATIVE_SCOPE = 0
+	GLOBAL_SCOPE = 1
+)
+
+// VARIADIC marks a callable without an upper bound on its arguments.
+const VARIADIC = -1
+
+type symbol struct {
+	token    grammar.Token
+	kind     int
+	used     bool
+	arity    bool
+	minArity int
+	maxArity int
+}
+
+type scope struct {
+	symbols map[string]*symbol
+	order   []*symbol
+}
+
+type Linter struct {
+	Config       Config
+	Error        []grammar.LoxError
+	scopes       utils.Stack[*scope]
+	currentClass int
+}
+
+func Lint(source string, config Config) []grammar.LoxError {
+	if strings.TrimSpace(source) == "" {
+		return nil
+	}
+
+	lexer := lexer.Lexer{Source: []rune(source), KeepComments: true}
+	tokens, lexErrs := lexer.Tokenize()
+	if len(lexErrs) > 0 {
+		errs := make([]grammar.LoxError, 0)
+		for _, err := range lexErrs {
+			errs = append(errs, err)
+		}
+		return errs
+	}
+
+	code := make([]grammar.Token, 0, len(tokens))
+	for _, token := range tokens {
+		if token.TokenType != grammar.COMMENT {
+			code = append(code, token)
+		}
+	}
+	parser := parser.Parser{Tokens: code}
+	statements, err := parser.Parse()
+	if err != nil {
+		return []grammar.LoxError{err}
+	}
+
+	linter := Linter{Config: config}
+	return Suppress(linter.Lint(statements), tokens)
+}
+
+func (linter *Linter) Lint(statements []grammar.Statement) []grammar.LoxError {
+	linter.beginScope()
+	for _, library := range stdlib.All {
+		for name, native := range library.Functions {
+			maxArity := native.Airity + native.OptionalAirity
+			if native.Variadic {
+				maxArity = VARIADIC
+			}
+			linter.add(name, &symbol{token: grammar.Token{Lexeme: name}, kind: NATIVE, arity: true, minArity: native.Airity, maxArity: maxArity})
+		}
+	}
+
+	linter.beginScope()
+	linter.hoist(statements)
+	linter.statements(statements)
+	linter.endScope()
+	linter.endScope()
+
+	sort.SliceStable(linter.Error, func(i, j int) bool {
+		return linter.Error[i].(LintError).Token.Line < linter.Error[j].(LintError).Token.Line
+	})
+	return linter.Error
+}
+
+// hoist declares every top-level name up front so functions may refer to
+// globals that are declared after them.
+func (linter *Linter) hoist(statements []grammar.Statement) {
+	for _, stmt := range statements {
+		if export, ok := stmt.(grammar.ExportStatement); ok {
+			stmt = export.Declaration
+		}
+		switch stmtType := stmt.(type) {
+		case grammar.VariableDeclarationStatement:
+			linter.declare(stmtType.Name, VARIABLE)
+		case grammar.FunctionDeclarationStatement:
+			linter.declareFunction(stmtType)
+		case grammar.ClassDeclarationStatement:
+			linter.declareClass(stmtType)
+		case grammar.ImportStatement:
+			linter.declare(stmtType.Alias, IMPORT)
+		}
+	}
+}
+
+func (linter *Linter) report(rule string, token grammar.Token, message string) {
+	if linter.Config.Enabled(rule) {
+		linter.Error = append(linter.Error, LintError{Rule: rule, Token: token, Message: message})
+	}
+}
+
+func (linter *Linter) beginScope() {
+	linter.scopes.Push(&scope{symbols: make(map[string]*symbol)})
+}
+
+func (linter *Linter) endScope() {
+	current, err := linter.scopes.Peek()
+	if err != nil {
+		return
+	}
+	if linter.scopes.Len() > GLOBAL_SCOPE+1 {
+		for _, symbol := range current.order {
+			name := fmt.Sprintf("%s", symbol.token.Lexeme)
+			if symbol.used || strings.HasPrefix(name, "_") {
+				continue
+			}
+			switch symbol.kind {
+			case PARAMETER:
+				linter.report(UNUSED_PARAMETER, symbol.token, fmt.Sprintf("Parameter '%s' is never used.", name))
+			case VARIABLE, FUNCTION, CLASS:
+				linter.report(UNUSED_VARIABLE, symbol.token, fmt.Sprintf("Local variable '%s' is never used.", name))
+			}
+		}
+	}
+	linter.scopes.Pop()
+}
+
+func (linter *Linter) add(name string, symbol *symbol) {
+	current, err := linter.scopes.Peek()
+	if err != nil {
+		return
+	}
+	if _, ok := current.symbols[name]; !ok {
+		current.order = append(current.order, symbol)
+	}
+	current.symbols[name] = symbol
+}
+
+func (linter *Linter) declare(name grammar.Token, kind int) *symbol {
+	lookup := fmt.Sprintf("%s", name.Lexeme)
+	current, err := linter.scopes.Peek()
+	if err != nil {
+		return nil
+	}
+	if existing, ok := current.symbols[lookup]; ok && linter.scopes.Len() == GLOBAL_SCOPE+1 {
+		existing.token = name
+		existing.kind = kind
+		return existing
+	}
+
+	if linter.scopes.Len() > GLOBAL_SCOPE+1 {
+		for i := linter.scopes.Len() - 2; i >= GLOBAL_SCOPE; i-- {
+			outer, _ := linter.scopes.Get(i)
+			if shadowed, ok := outer.symbols[lookup]; ok {
+				linter.report(SHADOWED_NAME, name, fmt.Sprintf("Declaration of '%s' shadows a variable declared on line %d.", lookup, shadowed.token.Line))
+				break
+			}
+		}
+	}
+
+	symbol := &symbol{token: name, kind: kind}
+	linter.add(lookup, symbol)
+	return symbol
+}
+
+func (linter *Linter) declareFunction(function grammar.FunctionDeclarationStatement) {
+	symbol := linter.declare(function.Name, FUNCTION)
+	if symbol != nil {
+		symbol.arity, symbol.minArity, symbol.maxArity = true, len(function.Params), len(function.Params)
+	}
+}
+
+func (linter *Linter) declareClass(class grammar.ClassDeclarationStatement) {
+	symbol := linter.declare(class.Name, CLASS)
+	if symbol == nil {
+		return
+	}
+	symbol.arity = false
+	for _, method := range class.Methods {
+		if method.Name.Lexeme == runtime.CONSTRUCTOR {
+			symbol.arity, symbol.minArity, symbol.maxArity = true, len(method.Params), len(method.Params)
+			return
+		}
+	}
+	if super, ok := class.Super.(grammar.VariableDeclaration); ok {
+		if base := linter.resolve(super.Name); base != nil && base.kind == CLASS && base != symbol {
+			symbol.arity, symbol.minArity, symbol.maxArity = base.arity, base.minArity, base.maxArity
+		}
+		return
+	}
+	symbol.arity, symbol.minArity, symbol.maxArity = true, 0, 0
+}
+
+func (linter *Linter) resolve(name grammar.Token) *symbol {
+	lookup := fmt.Sprintf("%s", name.Lexeme)
+	for i := linter.scopes.Len() - 1; i >= 0; i-- {
+		scope, err := linter.scopes.Get(i)
+		if err != nil {
+			break
+		}
+		if symbol, ok := scope.symbols[lookup]; ok {
+			return symbol
+		}
+	}
+	return nil
+}
+
+func (linter *Linter) statements(statements []grammar.Statement) {
+	var terminator grammar.Statement
+	reported := false
+	for _, stmt := range statements {
+		if stmt == nil {
+			continue
+		}
+		if terminator != nil && !reported {
+			token, ok := grammar.FirstToken(stmt)
+			if !ok {
+				token, ok = grammar.FirstToken(terminator)
+			}
+			if ok {
+				linter.report(UNREACHABLE_CODE, token, "Unreachable code.")
+			}
+			reported = true
+		}
+		linter.statement(stmt)
+		if terminator == nil && terminates(stmt) {
+			terminator = stmt
+		}
+	}
+}
+
+func terminates(stmt grammar.Statement) bool {
+	switch stmtType := stmt.(type) {
+	case grammar.ReturnStatement:
+		return true
+	case grammar.BlockScopeStatement:
+		for _, inner := range stmtType.Statements {
+			if terminates(inner) {
+				return true
+			}
+		}
+	case grammar.ConditionalStatement:
+		return stmtType.ElseBranch != nil && terminates(stmtType.ThenBranch) && terminates(stmtType.ElseBranch)
+	}
+	return false
+}
+
+func (linter *Linter) statement(stmt grammar.Statement) {
+	switch stmtType := stmt.(type) {
+	case grammar.BlockScopeStatement:
+		linter.block(stmtType)
+	case grammar.VariableDeclarationStatement:
+		linter.expression(stmtType.Initializer)
+		if linter.scopes.Len() > GLOBAL_SCOPE+1 {
+			linter.declare(stmtType.Name, VARIABLE)
+		}
+	case grammar.FunctionDeclarationStatement:
+		if linter.scopes.Len() > GLOBAL_SCOPE+1 {
+			linter.declareFunction(stmtType)
+		}
+		linter.function(stmtType)
+	case grammar.ClassDeclarationStatement:
+		linter.class(stmtType)
+	case grammar.ExpressionStatement:
+		linter.expression(stmtType.Expression)
+	case grammar.PrintStatement:
+		linter.expression(stmtType.Value)
+	case grammar.ReturnStatement:
+		linter.expression(stmtType.Expression)
+	case grammar.ConditionalStatement:
+		linter.expression(stmtType.Condition)
+		linter.statement(stmtType.ThenBranch)
+		if stmtType.ElseBranch != nil {
+			linter.statement(stmtType.ElseBranch)
+		}
+	case grammar.WhileLoopStatement:
+		linter.expression(stmtType.Condition)
+		linter.statement(stmtType.Body)
+	case grammar.ImportStatement:
+		if linter.scopes.Len() > GLOBAL_SCOPE+1 {
+			linter.declare(stmtType.Alias, IMPORT)
+		}
+	case grammar.ExportStatement:
+		linter.statement(stmtType.Declaration)
+	}
+}
+
+func (linter *Linter) block(block grammar.BlockScopeStatement) {
+	empty := true
+	for _, stmt := range block.Statements {
+		if stmt != nil {
+			empty = false
+		}
+	}
+	if empty && block.Brace.Lexeme != nil {
+		linter.report(EMPTY_BLOCK, block.Brace, "Empty block.")
+	}
+
+	linter.beginScope()
+	linter.statements(block.Statements)
+	linter.endScope()
+}
+
+func (linter *Linter) function(function grammar.FunctionDeclarationStatement) {
+	linter.beginScope()
+	for _, param := range function.Params {
+		linter.declare(param, PARAMETER)
+	}
+	linter.statements(function.Body.Statements)
+	linter.endScope()
+}
+
+func (linter *Linter) class(class grammar.ClassDeclarationStatement) {
+	if linter.scopes.Len() > GLOBAL_SCOPE+1 {
+		linter.declareClass(class)
+	}
+	if class.Super != nil {
+		linter.expression(class.Super)
+	}
+	for _, field := range class.Fields {
+		linter.expression(field.Initializer)
+	}
+
+	enclosingClass := linter.currentClass
+	linter.currentClass = METHOD
+	for _, method := range class.Methods {
+		linter.function(method)
+	}
+	linter.currentClass = enclosingClass
+}
+
+func (linter *Linter) expression(expr grammar.Expression) {
+	switch exprType := expr.(type) {
+	case grammar.VariableDeclaration:
+		if symbol := linter.resolve(exprType.Name); symbol != nil {
+			symbol.used = true
+		}
+	case grammar.AssignmentExpression:
+		linter.expression(exprType.Value)
+		symbol := linter.resolve(exprType.Name)
+		if symbol == nil {
+			linter.report(UNDECLARED_ASSIGNMENT, exprType.Name, fmt.Sprintf("Assignment to undeclared variable '%v'.", exprType.Name.Lexeme))
+		} else {
+			symbol.arity = false
+		}
+	case grammar.BinaryExpression:
+		linter.expression(exprType.Left)
+		linter.expression(exprType.Right)
+	case grammar.LogicExpression:
+		linter.expression(exprType.Left)
+		linter.expression(exprType.Right)
+	case grammar.UnaryExpression:
+		linter.expression(exprType.Right)
+	case grammar.GroupingExpression:
+		linter.expression(exprType.Expression)
+	case grammar.CallExpression:
+		linter.call(exprType)
+	case grammar.PropertyAccessExpression:
+		linter.expression(exprType.Object)
+	case grammar.PropertyAssignmentExpression:
+		linter.expression(exprType.Object)
+		linter.expression(exprType.Value)
+	case grammar.SelfReferenceExpression:
+		if linter.currentClass == NONE {
+			linter.report(THIS_OUTSIDE_METHOD, exprType.Keyword, "Can't use 'this' outside of a method.")
+		}
+	}
+}
+
+func (linter *Linter) call(expr grammar.CallExpression) {
+	linter.expression(expr.Callee)
+	for _, argument := range expr.Arguments {
+		linter.expression(argument)
+	}
+
+	callee, ok := expr.Callee.(grammar.VariableDeclaration)
+	if !ok {
+		return
+	}
+	symbol := linter.resolve(callee.Name)
+	if symbol == nil || !symbol.arity {
+		return
+	}
+
+	count := len(expr.Arguments)
+	switch {
+	case symbol.maxArity == VARIADIC && count < symbol.minArity:
+		linter.report(WRONG_ARITY, expr.Paren, fmt.Sprintf("Expect at least %v arguments but got %v.", symbol.minArity, count))
+	case symbol.maxArity != VARIADIC && symbol.maxArity != symbol.minArity && (count < symbol.minArity || count > symbol.maxArity):
+		linter.report(WRONG_ARITY, expr.Paren, fmt.Sprintf("Expect %v to %v arguments but got %v.", symbol.minArity, symbol.maxArity, count))
+	case symbol.maxArity == symbol.minArity && count != symbol.minArity:
+		linter.report(WRONG_ARITY, expr.Paren, fmt.Sprintf("Expect %v arguments but got %v.", symbol.minArity, count))
+	}
+}
+
+// Suppress drops warnings on lines marked with a lint:ignore comment. A
+// trailing comment covers its own line and a comment on a line of its own
+// covers the next line. Rule names after the directive limit which warnings
+// are dropped.
+func Suppress(errs []grammar.LoxError, tokens []grammar.Token) []grammar.LoxError {
+	ignored := make(map[int][]string)
+	for i, token := range tokens {
+		if token.TokenType != grammar.COMMENT {
+			continue
+		}
+		text := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(strings.TrimPrefix(fmt.Sprintf("%v", token.Lexeme), "//"), "/*"), "*/"))
+		if !strings.HasPrefix(text, IGNORE_DIRECTIVE) {
+			continue
+		}
+		rules := strings.Fields(strings.TrimPrefix(text, IGNORE_DIRECTIVE))
+
+		line := token.Line
+		if i == 0 || tokens[i-1].Line != token.Line {
+			for _, next := range tokens[i+1:] {
+				if next.TokenType != grammar.COMMENT {
+					line = next.Line
+					break
+				}
+			}
+		}
+		ignored[line] = append(ignored[line], rules...)
+		if len(rules) == 0 {
+			ignored[line] = append(ignored[line], "*")
+		}
+	}
+
+	kept := make([]grammar.LoxError, 0, len(errs))
+	for _, err := range errs {
+		lintErr, ok := err.(LintError)
+		if ok {
+			rules, found := ignored[lintErr.Token.Line]
+			if found && (slices.Contains(rules, "*") || slices.Contains(rules, lintErr.Rule)) {
+				continue
+			}
+		}
+		kept = append(kept, err)
+	}
+	return kept
+}
@@ -0,0 +1,84 @@
+package lint
+
+import (
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+		config string
+		expect []string
+	}{
+		{"clean code", "var a = 1; func f(b) { return a + b; } print f(2);", "", nil},
+		{"unused variable", "{ var a = 1; var _b = 2; }", "", []string{"unused-variable: Local variable 'a' is never used."}},
+		{"unused parameter", "func f(a, b) { return a; } f(1, 2);", "", []string{"unused-parameter: Parameter 'b' is never used."}},
+		{"globals may be unused", "var a = 1; func f() {}", "", nil},
+		{"shadowed name", "var a = 1; func f() { var a = 2; print a; } f();", "", []string{"shadowed-name: Declaration of 'a' shadows a variable declared on line 1."}},
+		{"unreachable code", "func f() { return 1; print \"dead\"; print \"also dead\"; } f();", "", []string{"unreachable-code: Unreachable code."}},
+		{"both branches return", "func f(a) { if (a) { return 1; } else { return 2; } print a; } f(1);", "", []string{"unreachable-code: Unreachable code."}},
+		{"undeclared assignment", "func f() { b = 1; } f(); var b;", "", nil},
+		{"assignment to missing global", "c = 1;", "", []string{"undeclared-assignment: Assignment to undeclared variable 'c'."}},
+		{"function arity", "func f(a) { print a; } f(); f(1); f(1, 2);", "", []string{
+			"wrong-arity: Expect 1 arguments but got 0.",
+			"wrong-arity: Expect 1 arguments but got 2.",
+		}},
+		{"constructor arity", "class A { constructor(x) { this.x = x; } } class B < A {} A(); B(1);", "", []string{"wrong-arity: Expect 1 arguments but got 0."}},
+		{"native arity", "print len(); print max(); print max(1, 2, 3);", "", []string{
+			"wrong-arity: Expect 1 arguments but got 0.",
+			"wrong-arity: Expect at least 1 arguments but got 0.",
+		}},
+		{"reassigned function", "func f() {} f = len; f(\"a\");", "", nil},
+		{"this outside method", "func f() { return this; } f(); class A { m() { func g() { return this; } return g; } }", "", []string{"this-outside-method: Can't use 'this' outside of a method."}},
+		{"empty blocks", "if (true) {} while (false) {} func f() {} f(); for (;;) {}", "", []string{
+			"empty-block: Empty block.",
+			"empty-block: Empty block.",
+			"empty-block: Empty block.",
+		}},
+		{"ignore comment", "{\n  // lint:ignore\n  var a = 1;\n  var b = 2; // lint:ignore unused-variable\n}", "", nil},
+		{"ignore other rule", "{\n  var a = 1; // lint:ignore empty-block\n}", "", []string{"unused-variable: Local variable 'a' is never used."}},
+		{"disabled rule", "{ var a = 1; } if (true) {}", `{"rules": {"unused-variable": false}}`, []string{"empty-block: Empty block."}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{}
+			if tc.config != "" {
+				var err error
+				if config, err = ParseConfig([]byte(tc.config)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			errs := Lint(tc.source, config)
+			if len(errs) != len(tc.expect) {
+				t.Fatalf("got %v, want %v", errs, tc.expect)
+			}
+			for i, err := range errs {
+				lintErr, ok := err.(LintError)
+				if !ok {
+					t.Fatalf("unexpected error %v", err)
+				}
+				if got := lintErr.Rule + ": " + lintErr.Message; got != tc.expect[i] {
+					t.Errorf("got %v, want %v", got, tc.expect[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	if _, err := ParseConfig([]byte(`{"rules": {"no-such-rule": true}}`)); err == nil {
+		t.Error("expected an error for an unknown rule")
+	}
+	if _, err := ParseConfig([]byte(`{"rule": {}}`)); err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+	config, err := ParseConfig([]byte(`{"rules": {"empty-block": false}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Enabled(EMPTY_BLOCK) || !config.Enabled(SHADOWED_NAME) {
+		t.Errorf("got %v", config.Rules)
+	}
+}
@@ -0,0 +1,228 @@
+package lox
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"unicode"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+type GoObject struct {
+	value reflect.Value
+}
+
+func (object GoObject) Value() any {
+	return object.value.Interface()
+}
+
+func (object GoObject) GetProperty(name grammar.Token) (any, grammar.LoxError) {
+	for _, lookup := range propertyNames(name) {
+		if method := object.value.MethodByName(lookup); method.IsValid() {
+			return bindFunc(lookup, method), nil
+		}
+
+		field, ok := object.field(lookup)
+		if !ok {
+			continue
+		}
+		value, err := toLoxValue(field)
+		if err != nil {
+			return nil, runtime.RuntimeError{Token: name, Message: err.Error()}
+		}
+		return value, nil
+	}
+	return nil, runtime.RuntimeError{Token: name, Message: fmt.Sprintf("Undefined property '%v'.", name.Lexeme)}
+}
+
+func (object GoObject) SetProperty(name grammar.Token, value any) grammar.LoxError {
+	for _, lookup := range propertyNames(name) {
+		field, ok := object.field(lookup)
+		if !ok {
+			continue
+		}
+		if !field.CanSet() {
+			return runtime.RuntimeError{Token: name, Message: fmt.Sprintf("Property '%v' is read-only.", name.Lexeme)}
+		}
+		converted, err := toGo(value, field.Type())
+		if err != nil {
+			return runtime.RuntimeError{Token: name, Message: err.Error()}
+		}
+		field.Set(converted)
+		return nil
+	}
+	return runtime.RuntimeError{Token: name, Message: fmt.Sprintf("Undefined property '%v'.", name.Lexeme)}
+}
+
+func (object GoObject) ToString() string {
+	return fmt.Sprintf("<go %v>", object.value.Type())
+}
+
+func (object GoObject) field(name string) (reflect.Value, bool) {
+	value := object.value
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return reflect.Value{}, false
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	structField, ok := value.Type().FieldByName(name)
+	if !ok || !structField.IsExported() {
+		return reflect.Value{}, false
+	}
+	return value.FieldByIndex(structField.Index), true
+}
+
+func propertyNames(name grammar.Token) []string {
+	lookup := fmt.Sprintf("%s", name.Lexeme)
+	runes := []rune(lookup)
+	if len(runes) == 0 || unicode.IsUpper(runes[0]) {
+		return []string{lookup}
+	}
+	runes[0] = unicode.ToUpper(runes[0])
+	return []string{lookup, string(runes)}
+}
+
+func bindFunc(name string, function reflect.Value) runtime.NativeCall {
+	functionType := function.Type()
+	airity := functionType.NumIn()
+	if functionType.IsVariadic() {
+		airity--
+	}
+
+	return runtime.NativeCall{
+		Name:     name,
+		Airity:   airity,
+		Variadic: functionType.IsVariadic(),
+		NativeCallFunc: func(interpreter *runtime.Interpreter, arguments []any) (any, error) {
+			values := make([]reflect.Value, 0, len(arguments))
+			for i, argument := range arguments {
+				var target reflect.Type
+				if functionType.IsVariadic() && i >= airity {
+					target = functionType.In(airity).Elem()
+				} else {
+					target = functionType.In(i)
+				}
+				value, err := toGo(argument, target)
+				if err != nil {
+					return nil, fmt.Errorf("argument %v: %v", i+1, err)
+				}
+				values = append(values, value)
+			}
+			return fromResults(function.Call(values))
+		},
+	}
+}
+
+func fromResults(results []reflect.Value) (any, error) {
+	if len(results) > 0 && results[len(results)-1].Type() == errorType {
+		if err := results[len(results)-1]; !err.IsNil() {
+			return nil, err.Interface().(error)
+		}
+		results = results[:len(results)-1]
+	}
+
+	switch len(results) {
+	case 0:
+		return nil, nil
+	case 1:
+		return toLoxValue(results[0])
+	}
+
+	elements := make([]any, 0, len(results))
+	for _, result := range results {
+		element, err := toLoxValue(result)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	return runtime.LoxList{Elements: elements}, nil
+}
+
+func toLoxValue(value reflect.Value) (any, error) {
+	switch value.Kind() {
+	case reflect.Struct:
+		if value.CanAddr() {
+			return GoObject{value: value.Addr()}, nil
+		}
+		return GoObject{value: value}, nil
+	case reflect.Pointer:
+		if !value.IsNil() && value.Elem().Kind() == reflect.Struct {
+			return GoObject{value: value}, nil
+		}
+	case reflect.Func:
+		if !value.IsNil() {
+			return bindFunc(value.Type().String(), value), nil
+		}
+	}
+	return ToLox(value.Interface())
+}
+
+func toGo(value any, target reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		switch target.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(target), nil
+		}
+		return reflect.Value{}, fmt.Errorf("can't use null as %v", target)
+	}
+
+	if object, ok := value.(GoObject); ok {
+		switch {
+		case object.value.Type().AssignableTo(target):
+			return object.value, nil
+		case object.value.Kind() == reflect.Pointer && object.value.Elem().Type().AssignableTo(target):
+			return object.value.Elem(), nil
+		}
+		return reflect.Value{}, fmt.Errorf("can't use %v as %v", object.value.Type(), target)
+	}
+
+	switch target.Kind() {
+	case reflect.Interface:
+		goValue := FromLox(value)
+		if reflect.TypeOf(goValue).AssignableTo(target) {
+			return reflect.ValueOf(goValue), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if number, ok := value.(float64); ok && number == math.Trunc(number) {
+			return reflect.ValueOf(int64(number)).Convert(target), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if number, ok := value.(float64); ok && number >= 0 && number == math.Trunc(number) {
+			return reflect.ValueOf(uint64(number)).Convert(target), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if number, ok := value.(float64); ok {
+			return reflect.ValueOf(number).Convert(target), nil
+		}
+	case reflect.String:
+		if str, ok := value.(string); ok {
+			return reflect.ValueOf(str).Convert(target), nil
+		}
+	case reflect.Bool:
+		if boolean, ok := value.(bool); ok {
+			return reflect.ValueOf(boolean).Convert(target), nil
+		}
+	case reflect.Slice:
+		if list, ok := value.(runtime.LoxList); ok {
+			slice := reflect.MakeSlice(target, 0, len(list.Elements))
+			for _, element := range list.Elements {
+				converted, err := toGo(element, target.Elem())
+				if err != nil {
+					return reflect.Value{}, err
+				}
+				slice = reflect.Append(slice, converted)
+			}
+			return slice, nil
+		}
+	}
+	return reflect.Value{}, fmt.Errorf("can't use %v as %v", runtime.TypeName(value), target)
+}
@@ -0,0 +1,75 @@
+package lox
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+func ToLox(value any) (any, error) {
+	switch valueType := value.(type) {
+	case nil, bool, float64, string:
+		return valueType, nil
+	case runtime.LoxFunction, runtime.NativeCall, runtime.LoxClass, runtime.LoxClassInstance, runtime.LoxList, runtime.LoxModule, GoObject:
+		return valueType, nil
+	case runtime.NativeCallFunc:
+		return runtime.NativeCall{Variadic: true, NativeCallFunc: valueType}, nil
+	case func(*runtime.Interpreter, []any) (any, error):
+		return runtime.NativeCall{Variadic: true, NativeCallFunc: valueType}, nil
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(reflected.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(reflected.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return reflected.Float(), nil
+	case reflect.Bool:
+		return reflected.Bool(), nil
+	case reflect.String:
+		return reflected.String(), nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]any, 0, reflected.Len())
+		for i := 0; i < reflected.Len(); i++ {
+			element, err := ToLox(reflected.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		return runtime.LoxList{Elements: elements}, nil
+	case reflect.Struct:
+		return GoObject{value: reflected}, nil
+	case reflect.Func:
+		if reflected.IsNil() {
+			return nil, nil
+		}
+		return bindFunc(reflected.Type().String(), reflected), nil
+	case reflect.Pointer, reflect.Interface:
+		if reflected.IsNil() {
+			return nil, nil
+		}
+		if reflected.Elem().Kind() == reflect.Struct {
+			return GoObject{value: reflected}, nil
+		}
+		return ToLox(reflected.Elem().Interface())
+	}
+	return nil, fmt.Errorf("can't convert value of type %T to a lox value", value)
+}
+
+func FromLox(value any) any {
+	switch valueType := value.(type) {
+	case runtime.LoxList:
+		elements := make([]any, 0, len(valueType.Elements))
+		for _, element := range valueType.Elements {
+			elements = append(elements, FromLox(element))
+		}
+		return elements
+	case GoObject:
+		return valueType.Value()
+	}
+	return value
+}
@@ -0,0 +1,39 @@
+package lox
+
+import (
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/parser"
+	"github.com/DrEmbryo/jlox/src/resolver"
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+func Line(err grammar.LoxError) int {
+	switch err := err.(type) {
+	case lexer.LexerError:
+		return err.Line
+	case parser.ParserError:
+		return err.Token.Line
+	case resolver.ResolverError:
+		return err.Token.Line
+	case runtime.RuntimeError:
+		return err.Token.Line
+	}
+	return 0
+}
+
+func Message(err grammar.LoxError) string {
+	switch err := err.(type) {
+	case lexer.LexerError:
+		return err.Message
+	case parser.ParserError:
+		return err.Message
+	case resolver.ResolverError:
+		return err.Message
+	case runtime.RuntimeError:
+		return err.Message
+	case runtime.NativeError:
+		return err.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,37 @@
+package lox
+
+import (
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+const (
+	LEXER_STAGE    = "lexer"
+	PARSER_STAGE   = "parser"
+	RESOLVER_STAGE = "resolver"
+	RUNTIME_STAGE  = "runtime"
+)
+
+type Error struct {
+	Stage  string
+	Errors []grammar.LoxError
+}
+
+func (e Error) Error() string {
+	messages := make([]string, 0)
+	for _, err := range e.Errors {
+		messages = append(messages, strings.TrimSpace(err.Error()))
+	}
+	return strings.Join(messages, "\n")
+}
+
+func (e Error) Print() {
+	for _, err := range e.Errors {
+		err.Print()
+	}
+}
+
+func (e Error) IsCompileError() bool {
+	return e.Stage != RUNTIME_STAGE
+}
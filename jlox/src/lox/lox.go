@@ -0,0 +1,207 @@
+// Package lox runs Lox scripts from Go programs.
+package lox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/DrEmbryo/jlox/src/checker"
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/modules"
+	"github.com/DrEmbryo/jlox/src/parser"
+	"github.com/DrEmbryo/jlox/src/resolver"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/stdlib"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+type Options struct {
+	Libraries   []stdlib.Library
+	SearchPaths []string
+	Limits      runtime.Limits
+	Stdout      io.Writer
+	Stdin       io.Reader
+	FS          runtime.FileSystem
+	Hook        runtime.Hook
+}
+
+type Lox struct {
+	interpreter runtime.Interpreter
+	loader      *modules.Loader
+	warnings    []grammar.LoxError
+}
+
+func New(options Options) *Lox {
+	libraries := options.Libraries
+	if libraries == nil {
+		libraries = stdlib.All
+	}
+
+	loader := &modules.Loader{
+		SearchPaths: options.SearchPaths,
+		Libraries:   libraries,
+		Limits:      options.Limits,
+		Stdout:      options.Stdout,
+		Stdin:       options.Stdin,
+		FS:          options.FS,
+	}
+	env := runtime.Environment{Values: make(map[string]any), Parent: nil}
+	stdlib.Register(&env, libraries...)
+
+	return &Lox{
+		interpreter: runtime.Interpreter{
+			Env:      env,
+			LocalEnv: make(map[any]int),
+			Modules:  loader,
+			Limits:   options.Limits,
+			Stdout:   options.Stdout,
+			Stdin:    options.Stdin,
+			FS:       options.FS,
+			Hook:     options.Hook,
+		},
+		loader: loader,
+	}
+}
+
+func (lox *Lox) Eval(source string) (any, error) {
+	return lox.EvalContext(context.Background(), source)
+}
+
+func (lox *Lox) EvalContext(ctx context.Context, source string) (any, error) {
+	stmts, err := lox.compile(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var last grammar.ExpressionStatement
+	hasValue := false
+	if len(stmts) > 0 {
+		last, hasValue = stmts[len(stmts)-1].(grammar.ExpressionStatement)
+		if hasValue {
+			stmts = stmts[:len(stmts)-1]
+		}
+	}
+
+	end := lox.interpreter.Begin(ctx)
+	defer end()
+
+	if errs := lox.interpreter.InterpretContext(ctx, stmts); len(errs) > 0 {
+		return nil, Error{Stage: RUNTIME_STAGE, Errors: errs}
+	}
+	if !hasValue {
+		return nil, nil
+	}
+
+	if lox.interpreter.Hook != nil {
+		if err := lox.interpreter.Hook.Statement(&lox.interpreter, last); err != nil {
+			return nil, Error{Stage: RUNTIME_STAGE, Errors: []grammar.LoxError{err}}
+		}
+	}
+	value, runtimeErr := lox.interpreter.EvaluateContext(ctx, last.Expression)
+	if runtimeErr != nil {
+		return nil, Error{Stage: RUNTIME_STAGE, Errors: []grammar.LoxError{runtimeErr}}
+	}
+	return FromLox(value), nil
+}
+
+func (lox *Lox) RunFile(path string) (any, error) {
+	return lox.RunFileContext(context.Background(), path)
+}
+
+func (lox *Lox) RunFileContext(ctx context.Context, path string) (any, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	root := lox.loader.Root
+	lox.loader.Root = path
+	defer func() {
+		lox.loader.Root = root
+	}()
+	return lox.EvalContext(ctx, string(source))
+}
+
+func (lox *Lox) Call(name string, args ...any) (any, error) {
+	return lox.CallContext(context.Background(), name, args...)
+}
+
+func (lox *Lox) CallContext(ctx context.Context, name string, args ...any) (any, error) {
+	callee, ok := lox.interpreter.Env.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("undefined global '%s'", name)
+	}
+
+	arguments := make([]any, 0, len(args))
+	for _, arg := range args {
+		argument, err := ToLox(arg)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, argument)
+	}
+
+	value, err := lox.interpreter.CallContext(ctx, callee, arguments)
+	if err != nil {
+		return nil, Error{Stage: RUNTIME_STAGE, Errors: []grammar.LoxError{err}}
+	}
+	return FromLox(value), nil
+}
+
+func (lox *Lox) SetGlobal(name string, value any) error {
+	converted, err := ToLox(value)
+	if err != nil {
+		return err
+	}
+	lox.interpreter.Env.Define(name, converted)
+	return nil
+}
+
+func (lox *Lox) GetGlobal(name string) (any, bool) {
+	value, ok := lox.interpreter.Env.Get(name)
+	if !ok {
+		return nil, false
+	}
+	return FromLox(value), true
+}
+
+func (lox *Lox) Usage() runtime.Usage {
+	return lox.interpreter.Usage()
+}
+
+// Warnings returns the type checker warnings of the last Eval or RunFile.
+// They do not stop the script from running, as in the lox command.
+func (lox *Lox) Warnings() []grammar.LoxError {
+	return lox.warnings
+}
+
+func (lox *Lox) compile(source string) ([]grammar.Statement, error) {
+	lox.warnings = nil
+	lexer := lexer.Lexer{Source: []rune(source)}
+	tokens, lexErrs := lexer.Tokenize()
+	if len(lexErrs) > 0 {
+		errs := make([]grammar.LoxError, 0)
+		for _, e := range lexErrs {
+			errs = append(errs, e)
+		}
+		return nil, Error{Stage: LEXER_STAGE, Errors: errs}
+	}
+
+	parser := parser.Parser{Tokens: tokens}
+	stmts, err := parser.Parse()
+	if err != nil {
+		return nil, Error{Stage: PARSER_STAGE, Errors: []grammar.LoxError{err}}
+	}
+
+	resolver := resolver.Resolver{Interpreter: lox.interpreter, Scopes: utils.Stack[map[string]bool]{}, Error: make([]grammar.LoxError, 0)}
+	if errs := resolver.Resolve(stmts); len(errs) > 0 {
+		return nil, Error{Stage: RESOLVER_STAGE, Errors: errs}
+	}
+
+	checker := checker.TypeChecker{}
+	lox.warnings = checker.Check(stmts)
+	return stmts, nil
+}
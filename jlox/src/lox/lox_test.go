@@ -0,0 +1,517 @@
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DrEmbryo/jlox/src/generator"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/stdlib"
+)
+
+func TestEval(t *testing.T) {
+	interpreter := New(Options{})
+
+	if _, err := interpreter.Eval("var total = 40;"); err != nil {
+		t.Fatal(err)
+	}
+	value, err := interpreter.Eval("total + 2;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 42.0 {
+		t.Errorf("got %v, want 42", value)
+	}
+
+	value, err = interpreter.Eval("split(\"a,b\", \",\");")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(value, []any{"a", "b"}) {
+		t.Errorf("got %v, want [a b]", value)
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+		stage  string
+	}{
+		{"lexer error", "var a = @;", LEXER_STAGE},
+		{"parser error", "var = 1;", PARSER_STAGE},
+		{"runtime error", "1 - \"a\";", RUNTIME_STAGE},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(Options{}).Eval(tc.source)
+			var loxErr Error
+			if !errors.As(err, &loxErr) {
+				t.Fatalf("got %v, want lox error", err)
+			}
+			if loxErr.Stage != tc.stage {
+				t.Errorf("got %v, want %v", loxErr.Stage, tc.stage)
+			}
+		})
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	interpreter := New(Options{Libraries: []stdlib.Library{}})
+
+	if err := interpreter.SetGlobal("limit", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := interpreter.SetGlobal("names", []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := interpreter.SetGlobal("broken", map[string]int{}); err == nil {
+		t.Error("expected conversion error for map value")
+	}
+	err := interpreter.SetGlobal("twice", func(interpreter *runtime.Interpreter, arguments []any) (any, error) {
+		number, err := runtime.NumberArg(arguments, 0)
+		return number * 2, err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := interpreter.Eval("var result = twice(limit); func add(a, b) { return a + b; }"); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := interpreter.GetGlobal("result"); !ok || value != 6.0 {
+		t.Errorf("got %v, want 6", value)
+	}
+	if value, ok := interpreter.GetGlobal("names"); !ok || !reflect.DeepEqual(value, []any{"a", "b"}) {
+		t.Errorf("got %v, want [a b]", value)
+	}
+	if _, ok := interpreter.GetGlobal("sqrt"); ok {
+		t.Error("expected stdlib to be disabled")
+	}
+
+	value, err := interpreter.Call("add", 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != 3.0 {
+		t.Errorf("got %v, want 3", value)
+	}
+	if _, err := interpreter.Call("missing"); err == nil {
+		t.Error("expected error for undefined function")
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	var stdout strings.Builder
+	interpreter := New(Options{Stdout: &stdout})
+	if _, err := interpreter.Eval("var a: number = \"b\"; print a;"); err != nil {
+		t.Fatal(err)
+	}
+	if stdout.String() != "b\n" {
+		t.Errorf("got output %q, want the script to run despite warnings", stdout.String())
+	}
+	warnings := interpreter.Warnings()
+	expect := "Cannot assign value of type string to variable 'a' of type number."
+	if len(warnings) != 1 || !strings.Contains(warnings[0].Error(), expect) {
+		t.Errorf("got %v, want %q", warnings, expect)
+	}
+
+	if _, err := interpreter.Eval("print 1;"); err != nil {
+		t.Fatal(err)
+	}
+	if warnings := interpreter.Warnings(); len(warnings) != 0 {
+		t.Errorf("got %v, want warnings of the last run only", warnings)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	first, second := New(Options{}), New(Options{})
+	if _, err := first.Eval("seed(1);"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Eval("seed(1);"); err != nil {
+		t.Fatal(err)
+	}
+	expect, err := first.Eval("random();")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := first.Eval("seed(2);"); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := second.Eval("random();"); err != nil || value != expect {
+		t.Errorf("got %v, %v, want %v unaffected by the other interpreter's seed", value, err, expect)
+	}
+}
+
+func TestRunFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "lib.lox"), []byte("export var answer = 42;"), 0644)
+	os.WriteFile(filepath.Join(dir, "main.lox"), []byte("import \"lib.lox\" as lib; var answer = lib.answer;"), 0644)
+
+	interpreter := New(Options{})
+	if _, err := interpreter.RunFile(filepath.Join(dir, "main.lox")); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := interpreter.GetGlobal("answer"); value != 42.0 {
+		t.Errorf("got %v, want 42", value)
+	}
+}
+
+func TestImportFileSystem(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib.lox")
+	os.WriteFile(lib, []byte("export var answer = 42;"), 0644)
+	readOnly := runtime.ReadOnlyFileSystem{FS: fstest.MapFS{"lib.lox": {Data: []byte("export var answer = 7;")}}}
+
+	var tests = []struct {
+		name   string
+		fs     runtime.FileSystem
+		source string
+		expect string
+	}{
+		{"deny absolute path", runtime.DenyFileSystem{}, fmt.Sprintf("import %q as lib;", lib), "access denied"},
+		{"deny search path", runtime.DenyFileSystem{}, "import \"lib.lox\" as lib;", "access denied"},
+		{"read only outside its root", readOnly, fmt.Sprintf("import %q as lib;", lib), "Unable to find module"},
+		{"read only escaping its root", readOnly, "import \"../lib.lox\" as lib;", "Unable to find module"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			interpreter := New(Options{FS: tc.fs, SearchPaths: []string{dir}})
+			if _, err := interpreter.Eval(tc.source); err == nil || !strings.Contains(err.Error(), tc.expect) {
+				t.Errorf("got error %v, want %q", err, tc.expect)
+			}
+		})
+	}
+
+	interpreter := New(Options{FS: readOnly, SearchPaths: []string{dir}})
+	if value, err := interpreter.Eval("import \"lib.lox\" as lib; lib.answer;"); err != nil || value != 7.0 {
+		t.Errorf("got %v, %v, want 7 from the read-only file system", value, err)
+	}
+}
+
+type account struct {
+	Owner   string
+	Balance float64
+	Tags    []string
+	limit   int
+}
+
+func (a *account) Deposit(amount float64) error {
+	if amount <= 0 {
+		return errors.New("amount must be positive")
+	}
+	a.Balance += amount
+	return nil
+}
+
+func (a account) Describe(separator string, parts ...string) string {
+	description := a.Owner
+	for _, part := range parts {
+		description += separator + part
+	}
+	return description
+}
+
+func TestBindings(t *testing.T) {
+	acct := &account{Owner: "ada", Balance: 10, Tags: []string{"a", "b"}}
+	interpreter := New(Options{})
+	if err := interpreter.SetGlobal("acct", acct); err != nil {
+		t.Fatal(err)
+	}
+	if err := interpreter.SetGlobal("greet", func(name string, times int) string { return name + string(rune('0'+times)) }); err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		name   string
+		source string
+		expect any
+	}{
+		{"read field", "acct.owner;", "ada"},
+		{"write field", "acct.Balance = acct.balance + 5; acct.balance;", 15.0},
+		{"call pointer method", "acct.deposit(5); acct.balance;", 20.0},
+		{"call variadic method", "acct.describe(\"-\", \"x\", \"y\");", "ada-x-y"},
+		{"slice field", "len(acct.tags);", 2.0},
+		{"write slice field", "acct.tags = split(\"c,d,e\", \",\"); len(acct.tags);", 3.0},
+		{"bound function", "greet(\"hi\", 3);", "hi3"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			value, err := interpreter.Eval(tc.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if value != tc.expect {
+				t.Errorf("got %v, want %v", value, tc.expect)
+			}
+		})
+	}
+
+	if acct.Balance != 20 || len(acct.Tags) != 3 {
+		t.Errorf("go value was not updated: %+v", acct)
+	}
+
+	var failures = []struct {
+		name   string
+		source string
+		expect string
+	}{
+		{"go error", "acct.deposit(-1);", "Deposit: amount must be positive"},
+		{"unexported field", "acct.limit;", "Undefined property 'limit'."},
+		{"wrong field type", "acct.balance = \"x\";", "can't use string as float64"},
+		{"fractional integer argument", "greet(\"x\", 1.5);", "argument 2: can't use number as int"},
+	}
+
+	for _, tc := range failures {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := interpreter.Eval(tc.source)
+			var loxErr Error
+			if !errors.As(err, &loxErr) {
+				t.Fatalf("got %v, want lox error", err)
+			}
+			message := loxErr.Errors[0].(runtime.RuntimeError).Message
+			if !strings.HasSuffix(message, tc.expect) {
+				t.Errorf("got %v, want %v", message, tc.expect)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var tests = []struct {
+		name   string
+		limits runtime.Limits
+		ctx    context.Context
+		source string
+		expect string
+	}{
+		{"step limit", runtime.Limits{MaxSteps: 100}, context.Background(), "while (true) {}", "Execution step limit exceeded."},
+		{"timeout", runtime.Limits{Timeout: 10 * time.Millisecond}, context.Background(), "while (true) {}", "Execution timed out."},
+		{"cancelled context", runtime.Limits{}, cancelled, "while (true) {}", "Execution cancelled."},
+		{"call depth", runtime.Limits{MaxCallDepth: 50}, context.Background(), "func f() { f(); } f();", "Stack overflow."},
+		{"default call depth", runtime.Limits{}, context.Background(), "func f() { f(); } f();", "Stack overflow."},
+		{"constructor step limit", runtime.Limits{MaxSteps: 500}, context.Background(), `class A { constructor() { while (true) {} } } print "before"; A(); print "after";`, "Execution step limit exceeded."},
+		{"constructor call depth", runtime.Limits{MaxCallDepth: 50}, context.Background(), "class A { constructor() { A(); } } A();", "Stack overflow."},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(Options{Limits: tc.limits}).EvalContext(tc.ctx, tc.source)
+			var loxErr Error
+			if !errors.As(err, &loxErr) {
+				t.Fatalf("got %v, want lox error", err)
+			}
+			if len(loxErr.Errors) != 1 {
+				t.Fatalf("got %v, want a single error", loxErr.Errors)
+			}
+			if message := loxErr.Errors[0].(runtime.RuntimeError).Message; message != tc.expect {
+				t.Errorf("got %v, want %v", message, tc.expect)
+			}
+		})
+	}
+
+	interpreter := New(Options{Limits: runtime.Limits{MaxSteps: 100}})
+	if _, err := interpreter.Eval("func spin() { while (true) {} }"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interpreter.Call("spin"); err == nil {
+		t.Error("expected step limit error from call")
+	}
+	if _, err := interpreter.Eval("var ok = true;"); err != nil {
+		t.Errorf("limits should reset between evaluations, got %v", err)
+	}
+}
+
+func TestImportLimits(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "spin.lox"), []byte("while (true) {}"), 0644)
+
+	background := func() (context.Context, context.CancelFunc) {
+		return context.WithCancel(context.Background())
+	}
+
+	var tests = []struct {
+		name   string
+		limits runtime.Limits
+		ctx    func() (context.Context, context.CancelFunc)
+		expect string
+	}{
+		{"step limit", runtime.Limits{MaxSteps: 100}, background, "Execution step limit exceeded."},
+		{"timeout", runtime.Limits{Timeout: 20 * time.Millisecond}, background, "Execution timed out."},
+		{"caller deadline", runtime.Limits{}, func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 20*time.Millisecond)
+		}, "Execution timed out."},
+		{"caller cancellation", runtime.Limits{}, func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(20*time.Millisecond, cancel)
+			return ctx, cancel
+		}, "Execution cancelled."},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := tc.ctx()
+			defer cancel()
+			interpreter := New(Options{Limits: tc.limits, SearchPaths: []string{dir}})
+			var err error
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, err = interpreter.EvalContext(ctx, "import \"spin.lox\" as spin; print \"unreachable\";")
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("imported module ignored the importer's limits")
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.expect) {
+				t.Errorf("got error %v, want %q", err, tc.expect)
+			}
+		})
+	}
+}
+
+func TestMemory(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+	}{
+		{"string concatenation", `var s = "a"; while (true) s = s + s;`},
+		{"instances", "class Point {} while (true) Point();"},
+		{"nested blocks", "func f() { { f(); } } f();"},
+		{"native allocation", `var s = "a,b"; while (true) { s = s + s; split(s, ","); }`},
+		{"substr", `while (true) substr("abc", 1);`},
+		{"upper", `while (true) upper("abc");`},
+		{"replace", `while (true) replace("abc", "b", "x");`},
+		{"str", "while (true) str(12);"},
+		{"readFile", `while (true) readFile("data.txt");`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var stdout strings.Builder
+			interpreter := New(Options{
+				Limits: runtime.Limits{MaxMemory: 4096},
+				Stdout: &stdout,
+				FS:     runtime.ReadOnlyFileSystem{FS: fstest.MapFS{"data.txt": {Data: []byte("data")}}},
+			})
+			_, err := interpreter.Eval(tc.source + ` print "after";`)
+			var loxErr Error
+			if !errors.As(err, &loxErr) || loxErr.Stage != RUNTIME_STAGE {
+				t.Fatalf("got %v, want runtime error", err)
+			}
+			if len(loxErr.Errors) != 1 || stdout.Len() > 0 {
+				t.Errorf("got errors %v and output %q, want the run to halt", loxErr.Errors, stdout.String())
+			}
+			if message := loxErr.Errors[0].(runtime.RuntimeError).Message; message != "Memory limit exceeded." {
+				t.Errorf("got %v, want memory limit error", message)
+			}
+			if peak := interpreter.Usage().PeakMemory; peak == 0 || peak > 4096 {
+				t.Errorf("got peak usage %v, want within budget", peak)
+			}
+		})
+	}
+
+	var stdout strings.Builder
+	interpreter := New(Options{Limits: runtime.Limits{MaxMemory: 4096}, Stdout: &stdout})
+	if _, err := interpreter.Eval("func step(i) { { return i + 1; } } var n = 0; while (n < 1000) n = step(n); print n;"); err != nil {
+		t.Fatalf("environments should be released when they exit, got %v", err)
+	}
+	if stdout.String() != "1000\n" {
+		t.Errorf("got output %q, want 1000", stdout.String())
+	}
+
+	interpreter = New(Options{})
+	if _, err := interpreter.Eval("{ { var s = \"ab\" + \"cd\"; } } func f() {} f(); f();"); err != nil {
+		t.Fatal(err)
+	}
+	usage := interpreter.Usage()
+	if want := int64(2*runtime.ENVIRONMENT_SIZE + runtime.STRING_HEADER_SIZE + 4); usage.PeakMemory != want {
+		t.Errorf("got peak usage %v, want %v", usage.PeakMemory, want)
+	}
+	if want := int64(runtime.STRING_HEADER_SIZE + 4); usage.Memory != want {
+		t.Errorf("got live usage %v, want %v", usage.Memory, want)
+	}
+}
+
+func TestIO(t *testing.T) {
+	var stdout strings.Builder
+	interpreter := New(Options{
+		Stdout: &stdout,
+		Stdin:  strings.NewReader("first\nsecond"),
+		FS:     runtime.ReadOnlyFileSystem{FS: fstest.MapFS{"greeting.txt": {Data: []byte("hello")}}},
+	})
+
+	source := `
+		print readLine();
+		print readLine();
+		print readLine() == null;
+		print readFile("greeting.txt");
+	`
+	if _, err := interpreter.Eval(source); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "first\nsecond\ntrue\nhello\n"; got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+
+	if _, err := interpreter.Eval(`writeFile("greeting.txt", "bye");`); err == nil {
+		t.Error("expected write to a read-only file system to fail")
+	}
+
+	denied := New(Options{FS: runtime.DenyFileSystem{}, Stdout: &stdout})
+	if _, err := denied.Eval(`readFile("greeting.txt");`); err == nil || !strings.Contains(err.Error(), "access denied") {
+		t.Errorf("got %v, want access denied error", err)
+	}
+}
+
+func FuzzEval(f *testing.F) {
+	for _, seed := range []string{
+		"class A < B {} var B = 1;",
+		"var B = 1; class A < B {}",
+		"class A { m() { return this; } } A().m().m();",
+		"print 1 + \"a\";",
+	} {
+		f.Add(seed)
+	}
+	for seed := int64(0); seed < 20; seed++ {
+		f.Add(generator.Program(seed, 8))
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		interpreter := New(Options{
+			Limits: runtime.Limits{MaxSteps: 10_000, MaxCallDepth: 64, MaxMemory: 1 << 20, Timeout: 100 * time.Millisecond},
+			Stdout: io.Discard,
+			Stdin:  strings.NewReader(""),
+			FS:     runtime.DenyFileSystem{},
+		})
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			interpreter.Eval(source)
+		}()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("evaluation did not stop within its limits")
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/DrEmbryo/jlox/src/coverage"
+)
+
+const (
+	EXIT_USAGE    = 64
+	EXIT_IO_ERROR = 74
+)
+
+func main() {
+	flags := flag.NewFlagSet("loxcov", flag.ExitOnError)
+	htmlPath := flags.String("html", "", "Write an HTML report to the given file")
+	lcovPath := flags.String("lcov", "", "Write an lcov tracefile to the given file")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: loxcov [-html file] [-lcov file] profile.json...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(EXIT_USAGE)
+	}
+
+	merged := &coverage.Profile{}
+	for _, path := range flags.Args() {
+		profile, err := coverage.Load(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(EXIT_IO_ERROR)
+		}
+		merged.Merge(profile)
+	}
+
+	merged.WriteText(os.Stdout)
+	if *htmlPath != "" {
+		write(*htmlPath, merged.WriteHTML)
+	}
+	if *lcovPath != "" {
+		write(*lcovPath, func(writer io.Writer) error {
+			merged.WriteLcov(writer)
+			return nil
+		})
+	}
+}
+
+func write(path string, report func(writer io.Writer) error) {
+	file, err := os.Create(path)
+	if err == nil {
+		err = report(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(EXIT_IO_ERROR)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/differential"
+)
+
+func main() {
+	options := flag.NewFlagSet("loxdiff", flag.ExitOnError)
+	clox := options.String("clox", "clox", "Command used to run clox, the script path is appended as the last argument")
+	options.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: loxdiff [-clox command] <file or directory>...")
+		options.PrintDefaults()
+	}
+	options.Parse(os.Args[1:])
+	if options.NArg() == 0 || len(strings.Fields(*clox)) == 0 {
+		options.Usage()
+		os.Exit(64)
+	}
+
+	scripts, err := collect(options.Args())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(74)
+	}
+
+	command := strings.Fields(*clox)
+	if err := differential.Probe(command); err != nil {
+		fmt.Fprintf(os.Stderr, "clox can't run Lox scripts yet, so there is nothing to compare: %v\n", err)
+		os.Exit(1)
+	}
+
+	diverged := 0
+	for _, script := range scripts {
+		cloxOutcome, err := differential.RunClox(command, script)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Unable to run clox: %v\n", err)
+			os.Exit(74)
+		}
+
+		divergences := differential.Compare(script, differential.RunJlox(script), cloxOutcome)
+		if len(divergences) > 0 {
+			diverged++
+		}
+		for _, divergence := range divergences {
+			fmt.Println(divergence)
+		}
+	}
+
+	fmt.Printf("%d of %d scripts diverged\n", diverged, len(scripts))
+	if diverged > 0 {
+		os.Exit(1)
+	}
+}
+
+func collect(paths []string) ([]string, error) {
+	scripts := make([]string, 0)
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err == nil && !entry.IsDir() && filepath.Ext(path) == ".lox" {
+				scripts = append(scripts, path)
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return scripts, nil
+}
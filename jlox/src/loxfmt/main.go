@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/format"
+)
+
+const (
+	EXIT_UNFORMATTED = 1
+	EXIT_DATA_ERROR  = 65
+	EXIT_IO_ERROR    = 74
+)
+
+type options struct {
+	check bool
+	diff  bool
+	write bool
+}
+
+func main() {
+	flags := flag.NewFlagSet("loxfmt", flag.ExitOnError)
+	check := flags.Bool("check", false, "List files whose formatting differs and exit with status 1")
+	diff := flags.Bool("diff", false, "Print a diff of the formatting changes and exit with status 1 if there are any")
+	write := flags.Bool("w", false, "Write the result back to the source file instead of stdout")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: loxfmt [-check] [-diff] [-w] [file or directory]...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+	opts := options{check: *check, diff: *diff, write: *write}
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(EXIT_IO_ERROR)
+		}
+		opts.write = false
+		os.Exit(formatSource("<stdin>", string(source), opts))
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || (filepath.Ext(file) != ".lox" && file != path) {
+				return err
+			}
+			source, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			status = max(status, formatSource(file, string(source), opts))
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = EXIT_IO_ERROR
+		}
+	}
+	os.Exit(status)
+}
+
+func formatSource(path string, source string, opts options) int {
+	formatted, errs := format.Format(source)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, strings.TrimSpace(err.Error()))
+		}
+		return EXIT_DATA_ERROR
+	}
+
+	changed := formatted != source
+	switch {
+	case opts.check || opts.diff:
+		if opts.check && changed {
+			fmt.Println(path)
+		}
+		if opts.diff {
+			fmt.Print(format.Diff(path, source, formatted))
+		}
+		if changed {
+			return EXIT_UNFORMATTED
+		}
+	case opts.write:
+		if changed {
+			if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return EXIT_IO_ERROR
+			}
+		}
+	default:
+		fmt.Print(formatted)
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/lint"
+)
+
+const (
+	EXIT_WARNINGS   = 1
+	EXIT_USAGE      = 64
+	EXIT_DATA_ERROR = 65
+	EXIT_IO_ERROR   = 74
+)
+
+func main() {
+	flags := flag.NewFlagSet("loxlint", flag.ExitOnError)
+	configPath := flags.String("config", "", fmt.Sprintf("Path to a JSON config file (defaults to %s in the current directory)", lint.CONFIG_FILE))
+	listRules := flags.Bool("rules", false, "List the available rules and exit")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: loxlint [-config file] [file or directory]...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+
+	if *listRules {
+		fmt.Println(strings.Join(lint.RULES, "\n"))
+		return
+	}
+
+	config, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(EXIT_USAGE)
+	}
+
+	if flags.NArg() == 0 {
+		source, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(EXIT_IO_ERROR)
+		}
+		os.Exit(lintSource("<stdin>", string(source), config))
+	}
+
+	status := 0
+	for _, path := range flags.Args() {
+		err := filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || (filepath.Ext(path) != ".lox" && path != entry.Name()) {
+				return err
+			}
+			source, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			status = max(status, lintSource(path, string(source), config))
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = EXIT_IO_ERROR
+		}
+	}
+	os.Exit(status)
+}
+
+func loadConfig(path string) (lint.Config, error) {
+	if path != "" {
+		return lint.LoadConfig(path)
+	}
+	config, err := lint.LoadConfig(lint.CONFIG_FILE)
+	if errors.Is(err, fs.ErrNotExist) {
+		return lint.Config{}, nil
+	}
+	return config, err
+}
+
+func lintSource(path string, source string, config lint.Config) int {
+	status := 0
+	for _, err := range lint.Lint(source, config) {
+		lintErr, ok := err.(lint.LintError)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, strings.TrimSpace(err.Error()))
+			return EXIT_DATA_ERROR
+		}
+		fmt.Printf("%s:%d: %s (%s)\n", path, lintErr.Token.Line, lintErr.Message, lintErr.Rule)
+		status = EXIT_WARNINGS
+	}
+	return status
+}
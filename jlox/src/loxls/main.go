@@ -0,0 +1,17 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/DrEmbryo/jlox/src/lsp"
+)
+
+func main() {
+	server := lsp.Server{In: bufio.NewReader(os.Stdin), Out: os.Stdout, Log: os.Stderr}
+	if err := server.Serve(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,219 @@
+package lsp
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/DrEmbryo/jlox/src/checker"
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/lox"
+	"github.com/DrEmbryo/jlox/src/parser"
+	"github.com/DrEmbryo/jlox/src/resolver"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+const DIAGNOSTIC_SOURCE = "lox"
+
+type Document struct {
+	URI         string
+	Version     int
+	Text        string
+	Diagnostics []Diagnostic
+	lines       [][]rune
+	index       *Index
+}
+
+// Update re-analyses the document. When the new text does not parse the
+// index of the last version that did is kept, so navigation and completion
+// keep working while the user is typing.
+func (document *Document) Update(text string, version int) {
+	document.Text = text
+	document.Version = version
+	document.Diagnostics = make([]Diagnostic, 0)
+	document.lines = make([][]rune, 0)
+	for _, line := range strings.Split(text, "\n") {
+		document.lines = append(document.lines, []rune(line))
+	}
+	if document.index == nil {
+		document.index = &Index{}
+	}
+	if strings.TrimSpace(text) == "" {
+		document.index = &Index{}
+		return
+	}
+
+	tokens, lexErrs := lexer.Lexer{Source: []rune(text)}.Tokenize()
+	for _, err := range lexErrs {
+		line, column := document.offsetPosition(err.Position - 1)
+		document.report(err, grammar.Token{Line: line, Column: column, Lexeme: " "}, SEVERITY_ERROR)
+	}
+	if len(lexErrs) > 0 {
+		return
+	}
+
+	statements, err := parser.Parser{Tokens: tokens}.Parse()
+	if err != nil {
+		if parserErr, ok := err.(parser.ParserError); ok {
+			document.report(err, parserErr.Token, SEVERITY_ERROR)
+		}
+		return
+	}
+	document.index = BuildIndex(statements, tokens)
+
+	interpreter := runtime.Interpreter{LocalEnv: make(map[any]int)}
+	scopeResolver := resolver.Resolver{Interpreter: interpreter, Scopes: utils.Stack[map[string]bool]{}, Error: make([]grammar.LoxError, 0)}
+	for _, err := range scopeResolver.Resolve(statements) {
+		switch err := err.(type) {
+		case resolver.ResolverError:
+			document.report(err, err.Token, SEVERITY_ERROR)
+		case runtime.RuntimeError:
+			document.report(err, err.Token, SEVERITY_ERROR)
+		}
+	}
+
+	typeChecker := checker.TypeChecker{}
+	for _, err := range typeChecker.Check(statements) {
+		if checkerErr, ok := err.(checker.CheckerError); ok {
+			document.report(err, checkerErr.Token, SEVERITY_WARNING)
+		}
+	}
+}
+
+func (document *Document) report(err grammar.LoxError, token grammar.Token, severity int) {
+	message := lox.Message(err)
+	if checkerErr, ok := err.(checker.CheckerError); ok {
+		message = checkerErr.Message
+	}
+	document.Diagnostics = append(document.Diagnostics, Diagnostic{Range: document.tokenRange(token), Severity: severity, Source: DIAGNOSTIC_SOURCE, Message: message})
+}
+
+func (document *Document) offsetPosition(offset int) (int, int) {
+	line, column := 1, 1
+	for i, char := range []rune(document.Text) {
+		if i >= offset {
+			break
+		}
+		column++
+		if char == '\n' {
+			line, column = line+1, 1
+		}
+	}
+	return line, column
+}
+
+// position converts a 1-based line and rune column into the 0-based line and
+// UTF-16 offset used by the protocol.
+func (document *Document) position(line int, column int) Position {
+	if line < 1 || line > len(document.lines) {
+		return Position{Line: max(line-1, 0)}
+	}
+	runes := document.lines[line-1]
+	column = min(max(column-1, 0), len(runes))
+	return Position{Line: line - 1, Character: len(utf16.Encode(runes[:column]))}
+}
+
+func (document *Document) location(position Position) (int, int) {
+	if position.Line < 0 || position.Line >= len(document.lines) {
+		return position.Line + 1, 1
+	}
+	runes := document.lines[position.Line]
+	units := 0
+	for i, char := range runes {
+		if units >= position.Character {
+			return position.Line + 1, i + 1
+		}
+		units += len(utf16.Encode([]rune{char}))
+	}
+	return position.Line + 1, len(runes) + 1
+}
+
+func (document *Document) tokenRange(token grammar.Token) Range {
+	length := len([]rune(fmt.Sprintf("%v", token.Lexeme)))
+	if token.TokenType == grammar.EOF || length == 0 {
+		length = 1
+	}
+	start := document.position(token.Line, token.Column)
+	end := document.position(token.Line, token.Column+length)
+	if token.TokenType == grammar.EOF {
+		end = start
+	}
+	return Range{Start: start, End: end}
+}
+
+func (document *Document) symbolRange(symbol *Symbol) Range {
+	selection := document.tokenRange(symbol.Token)
+	if symbol.End.Line == 0 {
+		return selection
+	}
+	return Range{Start: selection.Start, End: document.tokenRange(symbol.End).End}
+}
+
+// referenceAt finds the identifier under the cursor.
+func (document *Document) referenceAt(position Position) (Reference, bool) {
+	line, column := document.location(position)
+	for _, reference := range document.index.References {
+		length := len([]rune(fmt.Sprintf("%v", reference.Token.Lexeme)))
+		if reference.Token.Line == line && column >= reference.Token.Column && column <= reference.Token.Column+length {
+			return reference, true
+		}
+	}
+	return Reference{}, false
+}
+
+func (document *Document) references(symbol *Symbol, includeDeclaration bool) []Reference {
+	references := make([]Reference, 0)
+	for _, reference := range document.index.References {
+		if reference.Symbol != symbol {
+			continue
+		}
+		if !includeDeclaration && key(reference.Token) == key(symbol.Token) {
+			continue
+		}
+		references = append(references, reference)
+	}
+	return references
+}
+
+// scopeAt returns the innermost scope containing the cursor.
+func (document *Document) scopeAt(line int, column int) *scope {
+	cursor := grammar.Token{Line: line, Column: column}
+	var innermost *scope
+	for _, scope := range document.index.scopes {
+		if before(cursor, scope.start) || (scope.end.Line > 0 && before(scope.end, cursor)) {
+			continue
+		}
+		if innermost == nil || !before(scope.start, innermost.start) {
+			innermost = scope
+		}
+	}
+	return innermost
+}
+
+func (document *Document) visibleSymbols(line int, column int) []*Symbol {
+	cursor := grammar.Token{Line: line, Column: column}
+	seen := make(map[string]bool)
+	symbols := make([]*Symbol, 0)
+	for scope := document.scopeAt(line, column); scope != nil; scope = scope.parent {
+		local := scope.parent != nil && scope.parent.parent != nil
+		for name, symbol := range scope.symbols {
+			if seen[name] || (local && !before(symbol.Token, cursor)) {
+				continue
+			}
+			seen[name] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	return symbols
+}
+
+func (document *Document) classAt(line int, column int) *Symbol {
+	for scope := document.scopeAt(line, column); scope != nil; scope = scope.parent {
+		if scope.class != nil {
+			return scope.class
+		}
+	}
+	return nil
+}
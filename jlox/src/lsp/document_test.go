@@ -0,0 +1,137 @@
+package lsp
+
+import (
+	"slices"
+	"testing"
+)
+
+func open(text string) *Document {
+	document := &Document{URI: "file:///a.lox"}
+	document.Update(text, 1)
+	return document
+}
+
+func TestReferences(t *testing.T) {
+	var tests = []struct {
+		name     string
+		source   string
+		position Position
+		expect   []Position
+	}{
+		{"global variable", SAMPLE, Position{Line: 17, Character: 7}, []Position{{13, 4}, {17, 6}, {18, 6}}},
+		{"parameter", SAMPLE, Position{Line: 15, Character: 9}, []Position{{14, 11}, {15, 8}}},
+		{"inherited method", SAMPLE, Position{Line: 10, Character: 17}, []Position{{4, 2}, {10, 16}}},
+		{"field assigned in constructor", SAMPLE, Position{Line: 5, Character: 17}, []Position{{2, 9}, {5, 16}}},
+		{"shadowed local", "var a = 1;\n{\n  var a = 2;\n  print a;\n}\nprint a;", Position{Line: 3, Character: 8}, []Position{{2, 6}, {3, 8}}},
+		{"utf-16 columns", "var s = \"😀\"; var b = s;", Position{Line: 0, Character: 22}, []Position{{0, 4}, {0, 22}}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			document := open(tc.source)
+			positions := make([]Position, 0)
+			for _, location := range document.findReferences(tc.position, true) {
+				positions = append(positions, location.Range.Start)
+			}
+			if !slices.Equal(positions, tc.expect) {
+				t.Errorf("got %v, want %v", positions, tc.expect)
+			}
+		})
+	}
+}
+
+func TestHover(t *testing.T) {
+	var tests = []struct {
+		name     string
+		source   string
+		position Position
+		expect   string
+	}{
+		{"function", SAMPLE, Position{Line: 17, Character: 1}, "```lox\nfunc greet(animal)\n```"},
+		{"class hierarchy", SAMPLE, Position{Line: 13, Character: 11}, "```lox\nclass Dog < Animal\n```\n\nInherits: Dog → Animal\n\nMethods:\n- bark(times)"},
+		{"method", SAMPLE, Position{Line: 18, Character: 11}, "```lox\nDog.bark(times)\n```"},
+		{"typed function", "func add(a: number, b: number): number { return a + b; }\nadd(1, 2);", Position{Line: 1, Character: 0}, "```lox\nfunc add(a: number, b: number): number\n```"},
+		{"native", "print len(\"a\");", Position{Line: 0, Character: 7}, "```lox\nnative func len(arg1)\n```"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			hover := open(tc.source).hover(tc.position)
+			if hover == nil || hover.Contents.Value != tc.expect {
+				t.Errorf("got %v, want %q", hover, tc.expect)
+			}
+		})
+	}
+}
+
+func TestRename(t *testing.T) {
+	document := open(SAMPLE)
+	edit, err := document.rename(Position{Line: 13, Character: 5}, "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edits := edit.Changes[document.URI]; len(edits) != 3 {
+		t.Errorf("got %v", edits)
+	}
+	for _, name := range []string{"class", "1abc", "a-b"} {
+		if _, err := document.rename(Position{Line: 13, Character: 5}, name); err == nil {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+	if _, err := document.rename(Position{Line: 0, Character: 0}, "x"); err == nil {
+		t.Error("expected an error without a symbol")
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	var tests = []struct {
+		name     string
+		source   string
+		position Position
+		include  []string
+		exclude  []string
+	}{
+		{"members of this", SAMPLE, Position{Line: 10, Character: 16}, []string{"speak", "bark", "name"}, []string{"constructor", "rex"}},
+		{"members of a variable", SAMPLE + "rex.", Position{Line: 19, Character: 4}, []string{"bark", "speak"}, []string{"greet"}},
+		{"names in scope", SAMPLE, Position{Line: 15, Character: 2}, []string{"animal", "greet", "rex", "Dog", "len", "print"}, []string{"times"}},
+		{"locals after declaration", "{\n  var a = 1;\n  \n}\n", Position{Line: 0, Character: 1}, []string{"len"}, []string{"a"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			document := open(SAMPLE)
+			document.Update(tc.source, 2)
+			labels := make([]string, 0)
+			for _, item := range document.completion(tc.position) {
+				labels = append(labels, item.Label)
+			}
+			for _, label := range tc.include {
+				if !slices.Contains(labels, label) {
+					t.Errorf("missing %s in %v", label, labels)
+				}
+			}
+			for _, label := range tc.exclude {
+				if slices.Contains(labels, label) {
+					t.Errorf("unexpected %s in %v", label, labels)
+				}
+			}
+		})
+	}
+}
+
+func TestDocumentSymbols(t *testing.T) {
+	symbols := open(SAMPLE).documentSymbols()
+	names := make([]string, 0)
+	for _, symbol := range symbols {
+		names = append(names, symbol.Name)
+	}
+	if !slices.Equal(names, []string{"Animal", "Dog", "rex", "greet"}) {
+		t.Fatalf("got %v", names)
+	}
+	if children := symbols[0].Children; len(children) != 3 || children[0].Kind != SYMBOL_CONSTRUCTOR || children[2].Kind != SYMBOL_FIELD {
+		t.Errorf("got %v", children)
+	}
+	if end := symbols[1].Range.End; end != (Position{Line: 12, Character: 1}) {
+		t.Errorf("got class range end %v", end)
+	}
+}
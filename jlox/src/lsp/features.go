@@ -0,0 +1,280 @@
+package lsp
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func (document *Document) definition(position Position) *Location {
+	reference, ok := document.referenceAt(position)
+	if !ok || reference.Symbol.Kind == NATIVE {
+		return nil
+	}
+	return &Location{URI: document.URI, Range: document.tokenRange(reference.Symbol.Token)}
+}
+
+func (document *Document) findReferences(position Position, includeDeclaration bool) []Location {
+	locations := make([]Location, 0)
+	reference, ok := document.referenceAt(position)
+	if !ok {
+		return locations
+	}
+	for _, reference := range document.references(reference.Symbol, includeDeclaration) {
+		locations = append(locations, Location{URI: document.URI, Range: document.tokenRange(reference.Token)})
+	}
+	return locations
+}
+
+func (document *Document) rename(position Position, name string) (*WorkspaceEdit, *ResponseError) {
+	if _, keyword := grammar.KEYWORDS[name]; keyword || !identifierPattern.MatchString(name) {
+		return nil, &ResponseError{Code: REQUEST_FAILED, Message: fmt.Sprintf("'%s' is not a valid name.", name)}
+	}
+	reference, ok := document.referenceAt(position)
+	if !ok {
+		return nil, &ResponseError{Code: REQUEST_FAILED, Message: "No symbol to rename at this position."}
+	}
+	if reference.Symbol.Kind == NATIVE {
+		return nil, &ResponseError{Code: REQUEST_FAILED, Message: "Can't rename a native function."}
+	}
+
+	edits := make([]TextEdit, 0)
+	for _, reference := range document.references(reference.Symbol, true) {
+		edits = append(edits, TextEdit{Range: document.tokenRange(reference.Token), NewText: name})
+	}
+	return &WorkspaceEdit{Changes: map[string][]TextEdit{document.URI: edits}}, nil
+}
+
+func (document *Document) hover(position Position) *Hover {
+	reference, ok := document.referenceAt(position)
+	if !ok {
+		return nil
+	}
+	text := "```lox\n" + signature(reference.Symbol) + "\n```"
+	if reference.Symbol.Kind == CLASS {
+		if hierarchy := hierarchy(reference.Symbol); hierarchy != "" {
+			text += "\n\n" + hierarchy
+		}
+	}
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: text}, Range: document.tokenRange(reference.Token)}
+}
+
+func signature(symbol *Symbol) string {
+	switch symbol.Kind {
+	case FUNCTION:
+		return "func " + functionSignature(symbol)
+	case METHOD:
+		return fmt.Sprintf("%s.%s", symbol.Owner.Name, functionSignature(symbol))
+	case CLASS:
+		if symbol.Super != nil {
+			return fmt.Sprintf("class %s < %s", symbol.Name, symbol.Super.Name)
+		}
+		return "class " + symbol.Name
+	case FIELD:
+		return fmt.Sprintf("%s.%s%s", symbol.Owner.Name, symbol.Name, annotation(symbol.Type))
+	case PARAMETER:
+		return fmt.Sprintf("(parameter) %s%s", symbol.Name, annotation(symbol.Type))
+	case IMPORT:
+		return fmt.Sprintf("import \"%v\" as %s", symbol.Import.Path.Lexeme, symbol.Name)
+	case NATIVE:
+		return "native func " + nativeSignature(symbol.Native)
+	default:
+		text := "var " + symbol.Name + annotation(symbol.Type)
+		if symbol.Type == nil && symbol.InstanceOf != nil {
+			text += " // " + symbol.InstanceOf.Name
+		}
+		return text
+	}
+}
+
+func functionSignature(symbol *Symbol) string {
+	if symbol.Function == nil {
+		return symbol.Name + "()"
+	}
+	params := make([]string, 0)
+	for i, param := range symbol.Function.Params {
+		var paramType *grammar.TypeAnnotation
+		if i < len(symbol.Function.ParamTypes) {
+			paramType = symbol.Function.ParamTypes[i]
+		}
+		params = append(params, fmt.Sprintf("%v%s", param.Lexeme, annotation(paramType)))
+	}
+	return fmt.Sprintf("%s(%s)%s", symbol.Name, strings.Join(params, ", "), annotation(symbol.Function.ReturnType))
+}
+
+func nativeSignature(native *runtime.NativeCall) string {
+	params := make([]string, 0)
+	for i := 0; i < native.Airity; i++ {
+		params = append(params, fmt.Sprintf("arg%d", i+1))
+	}
+	for i := 0; i < native.OptionalAirity; i++ {
+		params = append(params, fmt.Sprintf("arg%d?", native.Airity+i+1))
+	}
+	if native.Variadic {
+		params = append(params, "...")
+	}
+	return fmt.Sprintf("%s(%s)", native.Name, strings.Join(params, ", "))
+}
+
+func annotation(annotation *grammar.TypeAnnotation) string {
+	if annotation == nil {
+		return ""
+	}
+	return fmt.Sprintf(": %v", annotation.Name.Lexeme)
+}
+
+func hierarchy(class *Symbol) string {
+	names := []string{class.Name}
+	visited := map[*Symbol]bool{class: true}
+	for super := class.Super; super != nil && !visited[super]; super = super.Super {
+		visited[super] = true
+		names = append(names, super.Name)
+	}
+	methods := make([]string, 0)
+	for _, member := range class.Members {
+		if member.Kind == METHOD {
+			methods = append(methods, "- "+functionSignature(member))
+		}
+	}
+
+	text := ""
+	if len(names) > 1 {
+		text = "Inherits: " + strings.Join(names, " → ")
+	}
+	if len(methods) > 0 {
+		if text != "" {
+			text += "\n\n"
+		}
+		text += "Methods:\n" + strings.Join(methods, "\n")
+	}
+	return text
+}
+
+func (document *Document) documentSymbols() []DocumentSymbol {
+	return document.toDocumentSymbols(document.index.Symbols)
+}
+
+func (document *Document) toDocumentSymbols(symbols []*Symbol) []DocumentSymbol {
+	result := make([]DocumentSymbol, 0)
+	for _, symbol := range symbols {
+		kind := SYMBOL_VARIABLE
+		switch symbol.Kind {
+		case PARAMETER:
+			continue
+		case FUNCTION:
+			kind = SYMBOL_FUNCTION
+		case CLASS:
+			kind = SYMBOL_CLASS
+		case METHOD:
+			kind = SYMBOL_METHOD
+			if symbol.Name == runtime.CONSTRUCTOR {
+				kind = SYMBOL_CONSTRUCTOR
+			}
+		case FIELD:
+			kind = SYMBOL_FIELD
+		case IMPORT:
+			kind = SYMBOL_MODULE
+		}
+		result = append(result, DocumentSymbol{
+			Name:           symbol.Name,
+			Detail:         signature(symbol),
+			Kind:           kind,
+			Range:          document.symbolRange(symbol),
+			SelectionRange: document.tokenRange(symbol.Token),
+			Children:       document.toDocumentSymbols(symbol.Children),
+		})
+	}
+	return result
+}
+
+func (document *Document) completion(position Position) []CompletionItem {
+	line, column := document.location(position)
+	prefix := ""
+	if line-1 < len(document.lines) {
+		runes := document.lines[line-1]
+		prefix = string(runes[:min(column-1, len(runes))])
+	}
+	word := strings.TrimRightFunc(prefix, func(char rune) bool {
+		return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+	})
+
+	items := make([]CompletionItem, 0)
+	if strings.HasSuffix(word, ".") {
+		class := document.receiverClass(strings.TrimSpace(strings.TrimSuffix(word, ".")), line, column)
+		visited := make(map[*Symbol]bool)
+		seen := make(map[string]bool)
+		for ; class != nil && !visited[class]; class = class.Super {
+			visited[class] = true
+			for _, member := range class.Members {
+				if !seen[member.Name] && member.Name != runtime.CONSTRUCTOR {
+					seen[member.Name] = true
+					items = append(items, completionItem(member))
+				}
+			}
+		}
+		return items
+	}
+
+	for _, symbol := range document.visibleSymbols(line, column) {
+		items = append(items, completionItem(symbol))
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Label < items[j].Label })
+
+	keywords := make([]string, 0, len(grammar.KEYWORDS))
+	for keyword := range grammar.KEYWORDS {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+	for _, keyword := range keywords {
+		items = append(items, CompletionItem{Label: keyword, Kind: COMPLETION_KEYWORD})
+	}
+	return items
+}
+
+// receiverClass works out which class the expression before a '.' belongs to
+// by looking at the identifier that ends the text.
+func (document *Document) receiverClass(text string, line int, column int) *Symbol {
+	start := len(text)
+	for start > 0 && identifierPattern.MatchString(text[start-1:]) {
+		start--
+	}
+	receiver := text[start:]
+	switch receiver {
+	case "this":
+		return document.classAt(line, column)
+	case "super":
+		if class := document.classAt(line, column); class != nil {
+			return class.Super
+		}
+		return nil
+	}
+	for _, symbol := range document.visibleSymbols(line, column) {
+		if symbol.Name == receiver {
+			return symbol.InstanceOf
+		}
+	}
+	return nil
+}
+
+func completionItem(symbol *Symbol) CompletionItem {
+	kind := COMPLETION_VARIABLE
+	switch symbol.Kind {
+	case FUNCTION, NATIVE:
+		kind = COMPLETION_FUNCTION
+	case CLASS:
+		kind = COMPLETION_CLASS
+	case METHOD:
+		kind = COMPLETION_METHOD
+	case FIELD:
+		kind = COMPLETION_FIELD
+	case IMPORT:
+		kind = COMPLETION_MODULE
+	}
+	return CompletionItem{Label: symbol.Name, Kind: kind, Detail: signature(symbol)}
+}
@@ -0,0 +1,419 @@
+package lsp
+
+import (
+	"fmt"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/stdlib"
+)
+
+const (
+	VARIABLE = iota
+	PARAMETER
+	FUNCTION
+	CLASS
+	METHOD
+	FIELD
+	IMPORT
+	NATIVE
+)
+
+type Symbol struct {
+	Name       string
+	Kind       int
+	Token      grammar.Token
+	End        grammar.Token
+	Function   *grammar.FunctionDeclarationStatement
+	Type       *grammar.TypeAnnotation
+	Import     *grammar.ImportStatement
+	Native     *runtime.NativeCall
+	Owner      *Symbol
+	Super      *Symbol
+	InstanceOf *Symbol
+	Members    []*Symbol
+	Children   []*Symbol
+}
+
+// Member looks a method or field up in the class and its superclasses.
+func (symbol *Symbol) Member(name string) *Symbol {
+	visited := make(map[*Symbol]bool)
+	for class := symbol; class != nil && !visited[class]; class = class.Super {
+		visited[class] = true
+		for _, member := range class.Members {
+			if member.Name == name {
+				return member
+			}
+		}
+	}
+	return nil
+}
+
+type Reference struct {
+	Token  grammar.Token
+	Symbol *Symbol
+}
+
+type scope struct {
+	parent  *scope
+	symbols map[string]*Symbol
+	start   grammar.Token
+	end     grammar.Token
+	class   *Symbol
+}
+
+// Index records every declaration in a program and every identifier that
+// refers to one. It follows the resolver's scoping rules: globals are visible
+// everywhere, locals only after their declaration.
+type Index struct {
+	Symbols    []*Symbol
+	References []Reference
+	scopes     []*scope
+}
+
+type pendingMember struct {
+	class *Symbol
+	name  grammar.Token
+}
+
+type indexer struct {
+	index   *Index
+	tokens  []grammar.Token
+	braces  map[[2]int]grammar.Token
+	root    *scope
+	current *scope
+	class   *Symbol
+	owner   *Symbol
+	hoisted map[[2]int]*Symbol
+	pending []pendingMember
+}
+
+func BuildIndex(statements []grammar.Statement, tokens []grammar.Token) *Index {
+	natives := &scope{symbols: make(map[string]*Symbol)}
+	for _, library := range stdlib.All {
+		for name, native := range library.Functions {
+			native.Name = name
+			natives.symbols[name] = &Symbol{Name: name, Kind: NATIVE, Native: &native}
+		}
+	}
+
+	index := &Index{}
+	root := &scope{parent: natives, symbols: make(map[string]*Symbol), start: grammar.Token{Line: 1, Column: 1}}
+	index.scopes = append(index.scopes, root)
+
+	indexer := indexer{index: index, tokens: tokens, braces: matchBraces(tokens), root: root, current: root, hoisted: make(map[[2]int]*Symbol)}
+	indexer.hoist(statements)
+	indexer.statements(statements)
+	for _, pending := range indexer.pending {
+		if member := pending.class.Member(fmt.Sprintf("%v", pending.name.Lexeme)); member != nil {
+			index.References = append(index.References, Reference{Token: pending.name, Symbol: member})
+		}
+	}
+	return index
+}
+
+func matchBraces(tokens []grammar.Token) map[[2]int]grammar.Token {
+	braces := make(map[[2]int]grammar.Token)
+	open := make([]grammar.Token, 0)
+	for _, token := range tokens {
+		switch token.TokenType {
+		case grammar.LEFT_BRACE:
+			open = append(open, token)
+		case grammar.RIGHT_BRACE:
+			if len(open) > 0 {
+				braces[key(open[len(open)-1])] = token
+				open = open[:len(open)-1]
+			}
+		}
+	}
+	return braces
+}
+
+func key(token grammar.Token) [2]int {
+	return [2]int{token.Line, token.Column}
+}
+
+// bodyEnd finds the closing brace of the first block that starts after token.
+func (indexer *indexer) bodyEnd(token grammar.Token) grammar.Token {
+	for _, candidate := range indexer.tokens {
+		if candidate.TokenType == grammar.LEFT_BRACE && before(token, candidate) {
+			return indexer.braces[key(candidate)]
+		}
+	}
+	return grammar.Token{}
+}
+
+func before(a grammar.Token, b grammar.Token) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Column < b.Column)
+}
+
+func (indexer *indexer) hoist(statements []grammar.Statement) {
+	for _, stmt := range statements {
+		if export, ok := stmt.(grammar.ExportStatement); ok {
+			stmt = export.Declaration
+		}
+		var symbol *Symbol
+		switch stmtType := stmt.(type) {
+		case grammar.VariableDeclarationStatement:
+			symbol = indexer.declare(stmtType.Name, VARIABLE)
+		case grammar.FunctionDeclarationStatement:
+			symbol = indexer.declare(stmtType.Name, FUNCTION)
+		case grammar.ClassDeclarationStatement:
+			symbol = indexer.declare(stmtType.Name, CLASS)
+		case grammar.ImportStatement:
+			symbol = indexer.declare(stmtType.Alias, IMPORT)
+		}
+		if symbol != nil {
+			indexer.hoisted[key(symbol.Token)] = symbol
+		}
+	}
+}
+
+func (indexer *indexer) beginScope(start grammar.Token, end grammar.Token) {
+	indexer.current = &scope{parent: indexer.current, symbols: make(map[string]*Symbol), start: start, end: end}
+	indexer.index.scopes = append(indexer.index.scopes, indexer.current)
+}
+
+func (indexer *indexer) endScope() {
+	indexer.current = indexer.current.parent
+}
+
+func (indexer *indexer) declare(name grammar.Token, kind int) *Symbol {
+	lookup := fmt.Sprintf("%v", name.Lexeme)
+	if symbol, ok := indexer.hoisted[key(name)]; ok && indexer.current == indexer.root {
+		indexer.root.symbols[lookup] = symbol
+		return symbol
+	}
+
+	symbol := &Symbol{Name: lookup, Kind: kind, Token: name}
+	indexer.current.symbols[lookup] = symbol
+	indexer.index.References = append(indexer.index.References, Reference{Token: name, Symbol: symbol})
+	indexer.addChild(symbol)
+	return symbol
+}
+
+func (indexer *indexer) addChild(symbol *Symbol) {
+	if indexer.owner != nil {
+		indexer.owner.Children = append(indexer.owner.Children, symbol)
+	} else {
+		indexer.index.Symbols = append(indexer.index.Symbols, symbol)
+	}
+}
+
+func (indexer *indexer) lookup(name grammar.Token) *Symbol {
+	lookup := fmt.Sprintf("%v", name.Lexeme)
+	for scope := indexer.current; scope != nil; scope = scope.parent {
+		if symbol, ok := scope.symbols[lookup]; ok {
+			return symbol
+		}
+	}
+	return nil
+}
+
+func (indexer *indexer) reference(name grammar.Token) {
+	if symbol := indexer.lookup(name); symbol != nil {
+		indexer.index.References = append(indexer.index.References, Reference{Token: name, Symbol: symbol})
+	}
+}
+
+func (indexer *indexer) classNamed(annotation *grammar.TypeAnnotation) *Symbol {
+	if annotation == nil {
+		return nil
+	}
+	if symbol := indexer.lookup(annotation.Name); symbol != nil && symbol.Kind == CLASS {
+		indexer.index.References = append(indexer.index.References, Reference{Token: annotation.Name, Symbol: symbol})
+		return symbol
+	}
+	return nil
+}
+
+func (indexer *indexer) classOf(expr grammar.Expression) *Symbol {
+	switch exprType := expr.(type) {
+	case grammar.SelfReferenceExpression:
+		return indexer.class
+	case grammar.GroupingExpression:
+		return indexer.classOf(exprType.Expression)
+	case grammar.VariableDeclaration:
+		if symbol := indexer.lookup(exprType.Name); symbol != nil {
+			return symbol.InstanceOf
+		}
+	case grammar.CallExpression:
+		if callee, ok := exprType.Callee.(grammar.VariableDeclaration); ok {
+			if symbol := indexer.lookup(callee.Name); symbol != nil && symbol.Kind == CLASS {
+				return symbol
+			}
+		}
+	}
+	return nil
+}
+
+func (indexer *indexer) statements(statements []grammar.Statement) {
+	for _, stmt := range statements {
+		indexer.statement(stmt)
+	}
+}
+
+func (indexer *indexer) statement(stmt grammar.Statement) {
+	switch stmtType := stmt.(type) {
+	case grammar.BlockScopeStatement:
+		indexer.block(stmtType)
+	case grammar.VariableDeclarationStatement:
+		indexer.expression(stmtType.Initializer)
+		symbol := indexer.declare(stmtType.Name, VARIABLE)
+		symbol.Type = stmtType.Type
+		symbol.InstanceOf = indexer.classNamed(stmtType.Type)
+		if symbol.InstanceOf == nil {
+			symbol.InstanceOf = indexer.classOf(stmtType.Initializer)
+		}
+	case grammar.FunctionDeclarationStatement:
+		symbol := indexer.declare(stmtType.Name, FUNCTION)
+		symbol.Function = &stmtType
+		indexer.function(symbol, stmtType)
+	case grammar.ClassDeclarationStatement:
+		indexer.classDeclaration(stmtType)
+	case grammar.ExpressionStatement:
+		indexer.expression(stmtType.Expression)
+	case grammar.PrintStatement:
+		indexer.expression(stmtType.Value)
+	case grammar.ReturnStatement:
+		indexer.expression(stmtType.Expression)
+	case grammar.ConditionalStatement:
+		indexer.expression(stmtType.Condition)
+		indexer.statement(stmtType.ThenBranch)
+		indexer.statement(stmtType.ElseBranch)
+	case grammar.WhileLoopStatement:
+		indexer.expression(stmtType.Condition)
+		indexer.statement(stmtType.Body)
+	case grammar.ImportStatement:
+		symbol := indexer.declare(stmtType.Alias, IMPORT)
+		symbol.Import = &stmtType
+	case grammar.ExportStatement:
+		indexer.statement(stmtType.Declaration)
+	}
+}
+
+func (indexer *indexer) block(block grammar.BlockScopeStatement) {
+	start, end := block.Brace, indexer.braces[key(block.Brace)]
+	if block.Brace.Lexeme == nil {
+		// Blocks produced by desugaring a for loop have no braces, so their
+		// scope runs from the first statement to the end of the enclosing one.
+		start, end = indexer.current.end, indexer.current.end
+		for _, stmt := range block.Statements {
+			if token, ok := grammar.FirstToken(stmt); ok {
+				start = token
+				break
+			}
+		}
+	}
+	indexer.beginScope(start, end)
+	indexer.statements(block.Statements)
+	indexer.endScope()
+}
+
+func (indexer *indexer) function(symbol *Symbol, function grammar.FunctionDeclarationStatement) {
+	symbol.End = indexer.braces[key(function.Body.Brace)]
+	enclosingOwner := indexer.owner
+	indexer.owner = symbol
+	indexer.beginScope(function.Body.Brace, symbol.End)
+	if symbol.Kind == METHOD {
+		indexer.current.class = symbol.Owner
+	}
+	for i, param := range function.Params {
+		parameter := indexer.declare(param, PARAMETER)
+		if i < len(function.ParamTypes) {
+			parameter.Type = function.ParamTypes[i]
+			parameter.InstanceOf = indexer.classNamed(parameter.Type)
+		}
+	}
+	indexer.classNamed(function.ReturnType)
+	indexer.statements(function.Body.Statements)
+	indexer.endScope()
+	indexer.owner = enclosingOwner
+}
+
+func (indexer *indexer) classDeclaration(class grammar.ClassDeclarationStatement) {
+	symbol := indexer.declare(class.Name, CLASS)
+	symbol.End = indexer.bodyEnd(class.Name)
+	if super, ok := class.Super.(grammar.VariableDeclaration); ok {
+		indexer.reference(super.Name)
+		if base := indexer.lookup(super.Name); base != nil && base.Kind == CLASS && base != symbol {
+			symbol.Super = base
+		}
+	}
+
+	symbol.Members = make([]*Symbol, 0)
+	for _, field := range class.Fields {
+		indexer.expression(field.Initializer)
+		member := indexer.member(symbol, field.Name, FIELD)
+		member.Type = field.Type
+		member.InstanceOf = indexer.classNamed(field.Type)
+	}
+	methods := make([]*Symbol, 0)
+	for _, method := range class.Methods {
+		member := indexer.member(symbol, method.Name, METHOD)
+		member.Function = &method
+		methods = append(methods, member)
+	}
+
+	enclosingClass := indexer.class
+	indexer.class = symbol
+	for i, method := range class.Methods {
+		indexer.function(methods[i], method)
+	}
+	indexer.class = enclosingClass
+}
+
+func (indexer *indexer) member(class *Symbol, name grammar.Token, kind int) *Symbol {
+	member := &Symbol{Name: fmt.Sprintf("%v", name.Lexeme), Kind: kind, Token: name, Owner: class}
+	class.Members = append(class.Members, member)
+	class.Children = append(class.Children, member)
+	indexer.index.References = append(indexer.index.References, Reference{Token: name, Symbol: member})
+	return member
+}
+
+func (indexer *indexer) expression(expr grammar.Expression) {
+	switch exprType := expr.(type) {
+	case grammar.VariableDeclaration:
+		indexer.reference(exprType.Name)
+	case grammar.AssignmentExpression:
+		indexer.expression(exprType.Value)
+		indexer.reference(exprType.Name)
+	case grammar.BinaryExpression:
+		indexer.expression(exprType.Left)
+		indexer.expression(exprType.Right)
+	case grammar.LogicExpression:
+		indexer.expression(exprType.Left)
+		indexer.expression(exprType.Right)
+	case grammar.UnaryExpression:
+		indexer.expression(exprType.Right)
+	case grammar.GroupingExpression:
+		indexer.expression(exprType.Expression)
+	case grammar.CallExpression:
+		indexer.expression(exprType.Callee)
+		for _, argument := range exprType.Arguments {
+			indexer.expression(argument)
+		}
+	case grammar.PropertyAccessExpression:
+		indexer.expression(exprType.Object)
+		if class := indexer.classOf(exprType.Object); class != nil {
+			indexer.pending = append(indexer.pending, pendingMember{class: class, name: exprType.Name})
+		}
+	case grammar.PropertyAssignmentExpression:
+		indexer.expression(exprType.Object)
+		indexer.expression(exprType.Value)
+		class := indexer.classOf(exprType.Object)
+		if class == nil {
+			return
+		}
+		_, self := exprType.Object.(grammar.SelfReferenceExpression)
+		if self && class.Member(fmt.Sprintf("%v", exprType.Name.Lexeme)) == nil {
+			indexer.member(class, exprType.Name, FIELD)
+			return
+		}
+		indexer.pending = append(indexer.pending, pendingMember{class: class, name: exprType.Name})
+	case grammar.BaseClassCallExpression:
+		if indexer.class != nil && indexer.class.Super != nil {
+			indexer.pending = append(indexer.pending, pendingMember{class: indexer.class.Super, name: exprType.Method})
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Package lsp implements a Language Server Protocol server for Lox over
+// stdio. Documents are analysed with the lexer, parser, resolver and type
+// checker on every change and kept in memory until they are closed.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+const SERVER_NAME = "loxls"
+
+type handler func(server *Server, params json.RawMessage) (any, *ResponseError)
+
+var handlers = map[string]handler{
+	"initialize":                  (*Server).initialize,
+	"shutdown":                    (*Server).shutdown,
+	"textDocument/definition":     (*Server).definition,
+	"textDocument/references":     (*Server).references,
+	"textDocument/hover":          (*Server).hover,
+	"textDocument/documentSymbol": (*Server).documentSymbol,
+	"textDocument/rename":         (*Server).rename,
+	"textDocument/completion":     (*Server).completion,
+}
+
+type notificationHandler func(server *Server, params json.RawMessage) error
+
+var notificationHandlers = map[string]notificationHandler{
+	"initialized":            func(server *Server, params json.RawMessage) error { return nil },
+	"textDocument/didOpen":   (*Server).didOpen,
+	"textDocument/didChange": (*Server).didChange,
+	"textDocument/didClose":  (*Server).didClose,
+}
+
+type Server struct {
+	In           *bufio.Reader
+	Out          io.Writer
+	Log          io.Writer
+	documents    map[string]*Document
+	shuttingDown bool
+}
+
+// Serve handles messages until the client sends exit. It returns an error
+// when the connection breaks or the client exits without shutting down.
+func (server *Server) Serve() error {
+	server.documents = make(map[string]*Document)
+	for {
+		body, err := utils.ReadMessage(server.In)
+		if err != nil {
+			return err
+		}
+
+		var request request
+		if err := json.Unmarshal(body, &request); err != nil {
+			if err := server.reply(nil, nil, &ResponseError{Code: PARSE_ERROR, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if request.Method == "exit" {
+			if !server.shuttingDown {
+				return fmt.Errorf("exit without shutdown")
+			}
+			return nil
+		}
+
+		if request.ID == nil {
+			if handle, ok := notificationHandlers[request.Method]; ok {
+				if err := handle(server, request.Params); err != nil {
+					server.log("%s: %v", request.Method, err)
+				}
+			}
+			continue
+		}
+
+		handle, ok := handlers[request.Method]
+		if !ok {
+			err = server.reply(request.ID, nil, &ResponseError{Code: METHOD_NOT_FOUND, Message: fmt.Sprintf("Method '%s' is not supported.", request.Method)})
+		} else {
+			result, responseErr := handle(server, request.Params)
+			err = server.reply(request.ID, result, responseErr)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (server *Server) log(format string, args ...any) {
+	if server.Log != nil {
+		fmt.Fprintf(server.Log, format+"\n", args...)
+	}
+}
+
+func (server *Server) write(value any) error {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return utils.WriteMessage(server.Out, body)
+}
+
+func (server *Server) reply(id *json.RawMessage, result any, err *ResponseError) error {
+	if err != nil {
+		return server.write(errorResponse{JSONRPC: JSONRPC_VERSION, ID: id, Error: err})
+	}
+	return server.write(response{JSONRPC: JSONRPC_VERSION, ID: id, Result: result})
+}
+
+func (server *Server) notify(method string, params any) error {
+	return server.write(notification{JSONRPC: JSONRPC_VERSION, Method: method, Params: params})
+}
+
+func decode[T any](params json.RawMessage) (T, *ResponseError) {
+	var value T
+	if err := json.Unmarshal(params, &value); err != nil {
+		return value, &ResponseError{Code: INVALID_PARAMS, Message: err.Error()}
+	}
+	return value, nil
+}
+
+func (server *Server) document(uri string) (*Document, *ResponseError) {
+	document, ok := server.documents[uri]
+	if !ok {
+		return nil, &ResponseError{Code: INVALID_PARAMS, Message: fmt.Sprintf("Document '%s' is not open.", uri)}
+	}
+	return document, nil
+}
+
+func (server *Server) initialize(params json.RawMessage) (any, *ResponseError) {
+	return map[string]any{
+		"capabilities": map[string]any{
+			"textDocumentSync":       TEXT_DOCUMENT_SYNC_FULL,
+			"definitionProvider":     true,
+			"referencesProvider":     true,
+			"hoverProvider":          true,
+			"documentSymbolProvider": true,
+			"renameProvider":         true,
+			"completionProvider":     map[string]any{"triggerCharacters": []string{"."}},
+		},
+		"serverInfo": map[string]any{"name": SERVER_NAME},
+	}, nil
+}
+
+func (server *Server) shutdown(params json.RawMessage) (any, *ResponseError) {
+	server.shuttingDown = true
+	return nil, nil
+}
+
+func (server *Server) didOpen(params json.RawMessage) error {
+	open, err := decode[DidOpenParams](params)
+	if err != nil {
+		return fmt.Errorf("%s", err.Message)
+	}
+	document := &Document{URI: open.TextDocument.URI}
+	server.documents[document.URI] = document
+	document.Update(open.TextDocument.Text, open.TextDocument.Version)
+	return server.publishDiagnostics(document)
+}
+
+func (server *Server) didChange(params json.RawMessage) error {
+	change, err := decode[DidChangeParams](params)
+	if err != nil {
+		return fmt.Errorf("%s", err.Message)
+	}
+	document, err := server.document(change.TextDocument.URI)
+	if err != nil {
+		return fmt.Errorf("%s", err.Message)
+	}
+	if len(change.ContentChanges) == 0 {
+		return nil
+	}
+	document.Update(change.ContentChanges[len(change.ContentChanges)-1].Text, change.TextDocument.Version)
+	return server.publishDiagnostics(document)
+}
+
+func (server *Server) didClose(params json.RawMessage) error {
+	closed, err := decode[DidCloseParams](params)
+	if err != nil {
+		return fmt.Errorf("%s", err.Message)
+	}
+	delete(server.documents, closed.TextDocument.URI)
+	return server.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: closed.TextDocument.URI, Diagnostics: []Diagnostic{}})
+}
+
+func (server *Server) publishDiagnostics(document *Document) error {
+	return server.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: document.URI, Version: document.Version, Diagnostics: document.Diagnostics})
+}
+
+func (server *Server) definition(params json.RawMessage) (any, *ResponseError) {
+	request, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+	document, err := server.document(request.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if location := document.definition(request.Position); location != nil {
+		return location, nil
+	}
+	return nil, nil
+}
+
+func (server *Server) references(params json.RawMessage) (any, *ResponseError) {
+	request, err := decode[ReferenceParams](params)
+	if err != nil {
+		return nil, err
+	}
+	document, err := server.document(request.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return document.findReferences(request.Position, request.Context.IncludeDeclaration), nil
+}
+
+func (server *Server) hover(params json.RawMessage) (any, *ResponseError) {
+	request, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+	document, err := server.document(request.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	if hover := document.hover(request.Position); hover != nil {
+		return hover, nil
+	}
+	return nil, nil
+}
+
+func (server *Server) documentSymbol(params json.RawMessage) (any, *ResponseError) {
+	request, err := decode[DocumentSymbolParams](params)
+	if err != nil {
+		return nil, err
+	}
+	document, err := server.document(request.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return document.documentSymbols(), nil
+}
+
+func (server *Server) rename(params json.RawMessage) (any, *ResponseError) {
+	request, err := decode[RenameParams](params)
+	if err != nil {
+		return nil, err
+	}
+	document, err := server.document(request.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return document.rename(request.Position, request.NewName)
+}
+
+func (server *Server) completion(params json.RawMessage) (any, *ResponseError) {
+	request, err := decode[TextDocumentPositionParams](params)
+	if err != nil {
+		return nil, err
+	}
+	document, err := server.document(request.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+	return document.completion(request.Position), nil
+}
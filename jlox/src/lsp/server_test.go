@@ -0,0 +1,109 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+const SAMPLE = `class Animal {
+  constructor(name) {
+    this.name = name;
+  }
+  speak() {
+    return this.name;
+  }
+}
+class Dog < Animal {
+  bark(times) {
+    return this.speak();
+  }
+}
+var rex = Dog("rex");
+func greet(animal) {
+  print animal;
+}
+greet(rex);
+print rex.bark(2);
+`
+
+func TestSession(t *testing.T) {
+	var input bytes.Buffer
+	send := func(id int, method string, params any) {
+		message := map[string]any{"jsonrpc": JSONRPC_VERSION, "method": method, "params": params}
+		if id > 0 {
+			message["id"] = id
+		}
+		body, _ := json.Marshal(message)
+		utils.WriteMessage(&input, body)
+	}
+	document := map[string]any{"uri": "file:///a.lox"}
+	send(1, "initialize", map[string]any{})
+	send(0, "initialized", map[string]any{})
+	send(0, "textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": "file:///a.lox", "version": 1, "text": "var a = ;"}})
+	send(0, "textDocument/didChange", map[string]any{"textDocument": map[string]any{"uri": "file:///a.lox", "version": 2}, "contentChanges": []any{map[string]any{"text": SAMPLE}}})
+	send(2, "textDocument/definition", map[string]any{"textDocument": document, "position": Position{Line: 17, Character: 7}})
+	send(3, "textDocument/unknown", map[string]any{})
+	send(4, "shutdown", nil)
+	send(0, "exit", nil)
+
+	var output bytes.Buffer
+	server := Server{In: bufio.NewReader(&input), Out: &output}
+	if err := server.Serve(); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := make([]map[string]any, 0)
+	reader := bufio.NewReader(&output)
+	for {
+		body, err := utils.ReadMessage(reader)
+		if err != nil {
+			break
+		}
+		var message map[string]any
+		json.Unmarshal(body, &message)
+		messages = append(messages, message)
+	}
+	if len(messages) != 6 {
+		t.Fatalf("got %d messages: %v", len(messages), messages)
+	}
+
+	capabilities := messages[0]["result"].(map[string]any)["capabilities"].(map[string]any)
+	for _, capability := range []string{"definitionProvider", "referencesProvider", "hoverProvider", "documentSymbolProvider", "renameProvider", "completionProvider"} {
+		if capabilities[capability] == nil {
+			t.Errorf("missing capability %s", capability)
+		}
+	}
+
+	diagnostics := messages[1]["params"].(map[string]any)["diagnostics"].([]any)
+	if len(diagnostics) != 1 || !strings.Contains(diagnostics[0].(map[string]any)["message"].(string), "Expect expression") {
+		t.Errorf("got diagnostics %v", diagnostics)
+	}
+	if diagnostics := messages[2]["params"].(map[string]any)["diagnostics"].([]any); len(diagnostics) != 0 {
+		t.Errorf("got diagnostics %v after fixing the document", diagnostics)
+	}
+
+	definition, _ := json.Marshal(messages[3]["result"])
+	if string(definition) != `{"range":{"end":{"character":7,"line":13},"start":{"character":4,"line":13}},"uri":"file:///a.lox"}` {
+		t.Errorf("got definition %s", definition)
+	}
+	if code := messages[4]["error"].(map[string]any)["code"].(float64); code != METHOD_NOT_FOUND {
+		t.Errorf("got error code %v", code)
+	}
+	if result, ok := messages[5]["result"]; !ok || result != nil {
+		t.Errorf("got shutdown response %v", messages[5])
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	var input bytes.Buffer
+	utils.WriteMessage(&input, []byte(`{"jsonrpc":"2.0","method":"exit"}`))
+	server := Server{In: bufio.NewReader(&input), Out: &bytes.Buffer{}}
+	if err := server.Serve(); err == nil {
+		t.Error("expected an error")
+	}
+}
@@ -0,0 +1,188 @@
+package modules
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/parser"
+	"github.com/DrEmbryo/jlox/src/resolver"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/stdlib"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+const SEARCH_PATH_ENV = "LOX_PATH"
+
+type Loader struct {
+	SearchPaths []string
+	Root        string
+	Libraries   []stdlib.Library
+	Limits      runtime.Limits
+	Stdout      io.Writer
+	Stdin       io.Reader
+	FS          runtime.FileSystem
+	cache       map[string]runtime.LoxModule
+	loading     []string
+}
+
+func ParseSearchPaths(paths string) []string {
+	searchPaths := make([]string, 0)
+	for _, path := range filepath.SplitList(paths) {
+		if path != "" {
+			searchPaths = append(searchPaths, path)
+		}
+	}
+	return searchPaths
+}
+
+func (loader *Loader) Load(path grammar.Token, execution *runtime.Execution) (runtime.LoxModule, grammar.LoxError) {
+	if loader.cache == nil {
+		loader.cache = make(map[string]runtime.LoxModule)
+	}
+
+	name := fmt.Sprintf("%s", path.Lexeme)
+	file, statErr := loader.resolvePath(name)
+	if errors.Is(statErr, fs.ErrNotExist) {
+		return runtime.LoxModule{}, resolver.ResolverError{Token: path, Message: fmt.Sprintf("Unable to find module '%s'.", name)}
+	}
+	if statErr != nil {
+		return runtime.LoxModule{}, resolver.ResolverError{Token: path, Message: fmt.Sprintf("Unable to read module '%s': %v", name, statErr)}
+	}
+
+	key := identity(file)
+	if module, ok := loader.cache[key]; ok {
+		return module, nil
+	}
+
+	chain := loader.importChain()
+	for i, loading := range chain {
+		if identity(loading) == key {
+			cycle := make([]string, 0, len(chain)-i+1)
+			for _, link := range append(chain[i:], file) {
+				cycle = append(cycle, identity(link))
+			}
+			return runtime.LoxModule{}, resolver.ResolverError{Token: path, Message: fmt.Sprintf("Import cycle detected: %s.", strings.Join(cycle, " -> "))}
+		}
+	}
+
+	loader.loading = append(loader.loading, file)
+	defer func() {
+		loader.loading = loader.loading[:len(loader.loading)-1]
+	}()
+
+	source, readErr := loader.fileSystem().ReadFile(file)
+	if readErr != nil {
+		return runtime.LoxModule{}, resolver.ResolverError{Token: path, Message: fmt.Sprintf("Unable to read module '%s': %v", name, readErr)}
+	}
+
+	exports, err := loader.evaluate(string(source), execution)
+	if err != nil {
+		return runtime.LoxModule{}, err
+	}
+
+	module := runtime.LoxModule{Name: name, Exports: exports}
+	loader.cache[key] = module
+	return module, nil
+}
+
+// importChain lists the files being loaded as they were found, starting
+// with the root script. They stay relative so that file systems rooted
+// elsewhere than the process, like ReadOnlyFileSystem, can open them.
+func (loader *Loader) importChain() []string {
+	chain := make([]string, 0)
+	if loader.Root != "" {
+		chain = append(chain, loader.Root)
+	}
+	return append(chain, loader.loading...)
+}
+
+// identity names a file for the cache and cycle detection.
+func identity(path string) string {
+	if file, err := filepath.Abs(path); err == nil {
+		return file
+	}
+	return filepath.Clean(path)
+}
+
+func (loader *Loader) fileSystem() runtime.FileSystem {
+	if loader.FS == nil {
+		return runtime.OSFileSystem{}
+	}
+	return loader.FS
+}
+
+// resolvePath finds an import in the importing file's directory, then in
+// the search paths. It fails with fs.ErrNotExist when no candidate exists,
+// or with the first error that is not about a missing file, so that a
+// denied lookup is not reported as a missing module.
+func (loader *Loader) resolvePath(path string) (string, error) {
+	candidates := make([]string, 0)
+	if filepath.IsAbs(path) {
+		candidates = append(candidates, path)
+	} else {
+		baseDir := "."
+		if chain := loader.importChain(); len(chain) > 0 {
+			baseDir = filepath.Dir(chain[len(chain)-1])
+		}
+		candidates = append(candidates, filepath.Join(baseDir, path))
+		for _, searchPath := range loader.SearchPaths {
+			candidates = append(candidates, filepath.Join(searchPath, path))
+		}
+	}
+
+	var failure error
+	for _, candidate := range candidates {
+		info, err := loader.fileSystem().Stat(candidate)
+		if err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+		if err != nil && failure == nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, fs.ErrInvalid) {
+			failure = err
+		}
+	}
+	if failure != nil {
+		return "", failure
+	}
+	return "", fs.ErrNotExist
+}
+
+func (loader *Loader) evaluate(source string, execution *runtime.Execution) (map[string]any, grammar.LoxError) {
+	lexer := lexer.Lexer{Source: []rune(source)}
+	tokens, lexErrs := lexer.Tokenize()
+	if len(lexErrs) > 0 {
+		return nil, lexErrs[0]
+	}
+
+	parser := parser.Parser{Tokens: tokens}
+	stmts, err := parser.Parse()
+	if err != nil {
+		return nil, err
+	}
+
+	env := runtime.Environment{Values: make(map[string]any), Parent: nil}
+	stdlib.Register(&env, loader.Libraries...)
+	interpreter := runtime.Interpreter{
+		Env:      env,
+		LocalEnv: make(map[any]int),
+		Modules:  loader,
+		Limits:   loader.Limits,
+		Stdout:   loader.Stdout,
+		Stdin:    loader.Stdin,
+		FS:       loader.FS,
+	}
+	interpreter.Join(execution)
+	resolver := resolver.Resolver{Interpreter: interpreter, Scopes: utils.Stack[map[string]bool]{}, Error: make([]grammar.LoxError, 0)}
+	if errs := resolver.Resolve(stmts); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	if errs := interpreter.Interpret(stmts); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return interpreter.Exports(), nil
+}
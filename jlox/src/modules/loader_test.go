@@ -0,0 +1,118 @@
+package modules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+func write(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, source := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func path(name string) grammar.Token {
+	return grammar.Token{TokenType: grammar.STRING, Lexeme: name, Line: 1}
+}
+
+func TestLoadCycle(t *testing.T) {
+	dir := t.TempDir()
+	write(t, dir, map[string]string{
+		"a.lox": "import \"b.lox\" as b;",
+		"b.lox": "import \"a.lox\" as a;",
+	})
+
+	loader := &Loader{Root: filepath.Join(dir, "a.lox")}
+	_, err := loader.Load(path("b.lox"), nil)
+	a, b := filepath.Join(dir, "a.lox"), filepath.Join(dir, "b.lox")
+	expect := "Import cycle detected: " + a + " -> " + b + " -> " + a + "."
+	if err == nil || !strings.Contains(err.Error(), expect) {
+		t.Errorf("got %v, want %q", err, expect)
+	}
+}
+
+func TestLoadCache(t *testing.T) {
+	dir := t.TempDir()
+	write(t, dir, map[string]string{
+		"lib.lox":  "print \"loading\"; export var n = 1;",
+		"left.lox": "import \"lib.lox\" as lib; export var n = lib.n;",
+		"main.lox": "",
+	})
+
+	var stdout strings.Builder
+	loader := &Loader{Root: filepath.Join(dir, "main.lox"), Stdout: &stdout}
+	for _, name := range []string{"lib.lox", "./lib.lox", "left.lox", filepath.Join(dir, "lib.lox")} {
+		module, err := loader.Load(path(name), nil)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if module.Exports["n"] != 1.0 {
+			t.Errorf("%s: got exports %v, want n = 1", name, module.Exports)
+		}
+	}
+	if got := stdout.String(); got != "loading\n" {
+		t.Errorf("got output %q, want lib.lox evaluated once", got)
+	}
+}
+
+func TestLoadSearchPaths(t *testing.T) {
+	dir := t.TempDir()
+	write(t, dir, map[string]string{
+		"main/main.lox":    "",
+		"main/local.lox":   "export var from = \"main\";",
+		"first/lib.lox":    "export var from = \"first\";",
+		"first/local.lox":  "export var from = \"first\";",
+		"second/lib.lox":   "export var from = \"second\";",
+		"second/only.lox":  "export var from = \"second\";",
+		"second/sub/x.lox": "export var from = \"second\";",
+	})
+
+	var tests = []struct {
+		name   string
+		expect string
+	}{
+		{"local.lox", "main"},
+		{"lib.lox", "first"},
+		{"only.lox", "second"},
+		{"sub/x.lox", "second"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			loader := &Loader{
+				Root:        filepath.Join(dir, "main", "main.lox"),
+				SearchPaths: []string{filepath.Join(dir, "first"), filepath.Join(dir, "second")},
+			}
+			module, err := loader.Load(path(tc.name), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if module.Exports["from"] != tc.expect {
+				t.Errorf("got module from %v, want %v", module.Exports["from"], tc.expect)
+			}
+		})
+	}
+
+	loader := &Loader{Root: filepath.Join(dir, "main", "main.lox")}
+	if _, err := loader.Load(path("lib.lox"), nil); err == nil || !strings.Contains(err.Error(), "Unable to find module 'lib.lox'.") {
+		t.Errorf("got %v, want missing module error", err)
+	}
+}
+
+func TestParseSearchPaths(t *testing.T) {
+	paths := ParseSearchPaths(strings.Join([]string{"a", "", "b/c"}, string(os.PathListSeparator)))
+	if len(paths) != 2 || paths[0] != "a" || paths[1] != "b/c" {
+		t.Errorf("got %q, want [a b/c]", paths)
+	}
+}
@@ -24,6 +24,13 @@ func (parser *Parser) lookahead() grammar.Token {
 	return token
 }
 
+func (parser *Parser) lookaheadNext() grammar.Token {
+	if parser.current+1 > len(parser.Tokens)-1 {
+		return parser.lookahead()
+	}
+	return parser.Tokens[parser.current+1]
+}
+
 func (parser *Parser) lookbehind() grammar.Token {
 	token := parser.Tokens[parser.current-1]
 	return token
@@ -90,7 +97,7 @@ func (parser *Parser) statement() (grammar.Statement, grammar.LoxError) {
 }
 
 func (parser *Parser) returnStatement() (grammar.Statement, grammar.LoxError) {
-	var value any
+	var value grammar.Expression
 	var err grammar.LoxError
 
 	keyword := parser.lookbehind()
@@ -109,8 +116,10 @@ func (parser *Parser) conditionalStatement() (grammar.Statement, grammar.LoxErro
 	var condition grammar.Expression
 	var thenBranch grammar.Statement
 	var elseBranch grammar.Statement
+	var elseKeyword grammar.Token
 	var err grammar.LoxError
 
+	keyword := parser.lookbehind()
 	err = parser.expect(grammar.LEFT_PAREN, "Expect '(' before condition inside 'if' statement")
 	if err != nil {
 		return nil, err
@@ -131,16 +140,18 @@ func (parser *Parser) conditionalStatement() (grammar.Statement, grammar.LoxErro
 	}
 
 	if parser.matchToken(grammar.ELSE) {
+		elseKeyword = parser.lookbehind()
 		elseBranch, err = parser.statement()
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return grammar.ConditionalStatement{Condition: condition, ThenBranch: thenBranch, ElseBranch: elseBranch}, nil
+	return grammar.ConditionalStatement{Keyword: keyword, Condition: condition, ThenBranch: thenBranch, Else: elseKeyword, ElseBranch: elseBranch}, nil
 }
 
 func (parser *Parser) blockStatement() (grammar.Statement, grammar.LoxError) {
+	brace := parser.lookbehind()
 	statements := make([]grammar.Statement, 0)
 
 	for !parser.compareTypes(grammar.RIGHT_BRACE) {
@@ -151,19 +162,21 @@ func (parser *Parser) blockStatement() (grammar.Statement, grammar.LoxError) {
 		statements = append(statements, stmt)
 	}
 
-	return grammar.BlockScopeStatement{Statements: statements}, parser.expect(grammar.RIGHT_BRACE, "Expect '}' after value")
+	return grammar.BlockScopeStatement{Brace: brace, Statements: statements}, parser.expect(grammar.RIGHT_BRACE, "Expect '}' after value")
 }
 
 func (parser *Parser) PrintStatement() (grammar.Statement, grammar.LoxError) {
+	keyword := parser.lookbehind()
 	value, err := parser.expression()
 	if err != nil {
 		return nil, err
 	}
 
-	return grammar.PrintStatement{Value: value}, parser.expect(grammar.SEMICOLON, "Expect ';' after value")
+	return grammar.PrintStatement{Keyword: keyword, Value: value}, parser.expect(grammar.SEMICOLON, "Expect ';' after value")
 }
 
 func (parser *Parser) whileStatement() (grammar.Statement, grammar.LoxError) {
+	keyword := parser.lookbehind()
 	err := parser.expect(grammar.LEFT_PAREN, "Expect '(' after 'while' keyword")
 	if err != nil {
 		return nil, err
@@ -181,10 +194,11 @@ func (parser *Parser) whileStatement() (grammar.Statement, grammar.LoxError) {
 
 	body, err := parser.statement()
 
-	return grammar.WhileLoopStatement{Condition: condition, Body: body}, err
+	return grammar.WhileLoopStatement{Keyword: keyword, Condition: condition, Body: body}, err
 }
 
 func (parser *Parser) forStatement() (grammar.Statement, grammar.LoxError) {
+	keyword := parser.lookbehind()
 	parser.expect(grammar.LEFT_PAREN, "Expect '(' after 'for' keyword")
 
 	var initializer grammar.Statement
@@ -234,7 +248,7 @@ func (parser *Parser) forStatement() (grammar.Statement, grammar.LoxError) {
 		condition = grammar.LiteralExpression{Literal: true}
 	}
 
-	body = grammar.WhileLoopStatement{Condition: condition, Body: body}
+	body = grammar.WhileLoopStatement{Keyword: keyword, Condition: condition, Body: body}
 
 	if initializer != nil {
 		stmts := make([]grammar.Statement, 2)
@@ -292,8 +306,10 @@ func (parser *Parser) logicOr() (grammar.Expression, grammar.LoxError) {
 	for parser.matchToken(grammar.OR) {
 		operator := parser.lookbehind()
 		rightExpr, err := parser.logicAnd()
+		if err != nil {
+			return nil, err
+		}
 		leftExpr = grammar.LogicExpression{Left: leftExpr, Right: rightExpr, Operator: operator}
-		return leftExpr, err
 	}
 
 	return leftExpr, err
@@ -308,8 +324,10 @@ func (parser *Parser) logicAnd() (grammar.Expression, grammar.LoxError) {
 	for parser.matchToken(grammar.AND) {
 		operator := parser.lookbehind()
 		rightExpr, err := parser.equality()
+		if err != nil {
+			return nil, err
+		}
 		leftExpr = grammar.LogicExpression{Left: leftExpr, Right: rightExpr, Operator: operator}
-		return leftExpr, err
 	}
 
 	return leftExpr, err
@@ -323,11 +341,56 @@ func (parser *Parser) declaration() (grammar.Statement, grammar.LoxError) {
 		return parser.classDeclaration()
 	case parser.matchToken(grammar.VAR):
 		return parser.variableDeclaration()
+	case parser.matchToken(grammar.IMPORT):
+		return parser.importDeclaration()
+	case parser.matchToken(grammar.EXPORT):
+		return parser.exportDeclaration()
 	default:
 		return parser.statement()
 	}
 }
 
+func (parser *Parser) importDeclaration() (grammar.Statement, grammar.LoxError) {
+	keyword := parser.lookbehind()
+	err := parser.expect(grammar.STRING, "Expect module path after 'import'.")
+	if err != nil {
+		return nil, err
+	}
+	path := parser.lookbehind()
+
+	err = parser.expect(grammar.AS, "Expect 'as' after module path.")
+	if err != nil {
+		return nil, err
+	}
+	err = parser.expect(grammar.IDENTIFIER, "Expect module name after 'as'.")
+	if err != nil {
+		return nil, err
+	}
+	alias := parser.lookbehind()
+
+	return grammar.ImportStatement{Keyword: keyword, Path: path, Alias: alias}, parser.expect(grammar.SEMICOLON, "Expect ';' after import.")
+}
+
+func (parser *Parser) exportDeclaration() (grammar.Statement, grammar.LoxError) {
+	keyword := parser.lookbehind()
+	var declaration grammar.Statement
+	var err grammar.LoxError
+	switch {
+	case parser.matchToken(grammar.FUNC):
+		declaration, err = parser.functionDeclaration("function")
+	case parser.matchToken(grammar.CLASS):
+		declaration, err = parser.classDeclaration()
+	case parser.matchToken(grammar.VAR):
+		declaration, err = parser.variableDeclaration()
+	default:
+		return nil, ParserError{Token: parser.lookahead(), Message: "Expect declaration after 'export'.", Position: parser.current}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return grammar.ExportStatement{Keyword: keyword, Declaration: declaration}, nil
+}
+
 func (parser *Parser) functionDeclaration(kind string) (grammar.Statement, grammar.LoxError) {
 	err := parser.expect(grammar.IDENTIFIER, fmt.Sprintf("Expect %v name.", kind))
 	if err != nil {
@@ -336,6 +399,7 @@ func (parser *Parser) functionDeclaration(kind string) (grammar.Statement, gramm
 
 	name := parser.lookbehind()
 	parameters := make([]grammar.Token, 0)
+	paramTypes := make([]*grammar.TypeAnnotation, 0)
 
 	err = parser.expect(grammar.LEFT_PAREN, fmt.Sprintf("Expect '(' after %v name.", kind))
 	if err != nil {
@@ -353,6 +417,12 @@ func (parser *Parser) functionDeclaration(kind string) (grammar.Statement, gramm
 			}
 
 			parameters = append(parameters, parser.lookbehind())
+
+			paramType, err := parser.optionalTypeAnnotation()
+			if err != nil {
+				return nil, err
+			}
+			paramTypes = append(paramTypes, paramType)
 		}
 	}
 
@@ -361,6 +431,11 @@ func (parser *Parser) functionDeclaration(kind string) (grammar.Statement, gramm
 		return nil, err
 	}
 
+	returnType, err := parser.optionalTypeAnnotation()
+	if err != nil {
+		return nil, err
+	}
+
 	err = parser.expect(grammar.LEFT_BRACE, fmt.Sprintf("Expect '{' before %v body.", kind))
 	if err != nil {
 		return nil, err
@@ -376,11 +451,11 @@ func (parser *Parser) functionDeclaration(kind string) (grammar.Statement, gramm
 		return nil, ParserError{Message: fmt.Sprintf("Unidentified parser type cast of block statement %T", body)}
 	}
 
-	return grammar.FunctionDeclarationStatement{Name: name, Params: parameters, Body: body}, err
+	return grammar.FunctionDeclarationStatement{Name: name, Params: parameters, ParamTypes: paramTypes, ReturnType: returnType, Body: body}, err
 }
 
 func (parser *Parser) classDeclaration() (grammar.Statement, grammar.LoxError) {
-	var superclass any = nil
+	var superclass grammar.Expression
 	err := parser.expect(grammar.IDENTIFIER, "Expect class name.")
 	if err != nil {
 		return nil, err
@@ -403,15 +478,43 @@ func (parser *Parser) classDeclaration() (grammar.Statement, grammar.LoxError) {
 		return nil, err
 	}
 
+	fields := make([]grammar.VariableDeclarationStatement, 0)
 	methods := make([]grammar.FunctionDeclarationStatement, 0)
 	for !parser.compareTypes(grammar.RIGHT_BRACE) {
+		if parser.compareTypes(grammar.IDENTIFIER) && parser.lookaheadNext().TokenType == grammar.COLON {
+			field, err := parser.fieldDeclaration()
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field)
+			continue
+		}
 		method, err := parser.functionDeclaration("method")
 		if err != nil {
 			return nil, err
 		}
 		methods = append(methods, method.(grammar.FunctionDeclarationStatement))
 	}
-	return grammar.ClassDeclarationStatement{Name: name, Methods: methods, Super: superclass}, parser.expect(grammar.RIGHT_BRACE, "Expect '}' after class body.")
+	return grammar.ClassDeclarationStatement{Name: name, Fields: fields, Methods: methods, Super: superclass}, parser.expect(grammar.RIGHT_BRACE, "Expect '}' after class body.")
+}
+
+func (parser *Parser) fieldDeclaration() (grammar.VariableDeclarationStatement, grammar.LoxError) {
+	name := parser.consume()
+	fieldType, err := parser.optionalTypeAnnotation()
+	if err != nil {
+		return grammar.VariableDeclarationStatement{}, err
+	}
+	return grammar.VariableDeclarationStatement{Name: name, Type: fieldType}, parser.expect(grammar.SEMICOLON, "Expect ';' after field declaration.")
+}
+
+func (parser *Parser) optionalTypeAnnotation() (*grammar.TypeAnnotation, grammar.LoxError) {
+	if !parser.matchToken(grammar.COLON) {
+		return nil, nil
+	}
+	if parser.matchToken(grammar.IDENTIFIER, grammar.NULL) {
+		return &grammar.TypeAnnotation{Name: parser.lookbehind()}, nil
+	}
+	return nil, ParserError{Token: parser.lookahead(), Message: "Expect type name after ':'.", Position: parser.current}
 }
 
 func (parser *Parser) variableDeclaration() (grammar.Statement, grammar.LoxError) {
@@ -423,6 +526,11 @@ func (parser *Parser) variableDeclaration() (grammar.Statement, grammar.LoxError
 	}
 	name := parser.lookbehind()
 
+	varType, err := parser.optionalTypeAnnotation()
+	if err != nil {
+		return nil, err
+	}
+
 	if parser.matchToken(grammar.EQUAL) {
 		initializer, err = parser.expression()
 		if err != nil {
@@ -435,7 +543,7 @@ func (parser *Parser) variableDeclaration() (grammar.Statement, grammar.LoxError
 		return nil, err
 	}
 
-	return grammar.VariableDeclarationStatement{Name: name, Initializer: initializer}, err
+	return grammar.VariableDeclarationStatement{Name: name, Type: varType, Initializer: initializer}, err
 }
 
 func (parser *Parser) equality() (grammar.Expression, grammar.LoxError) {
@@ -444,7 +552,7 @@ func (parser *Parser) equality() (grammar.Expression, grammar.LoxError) {
 		return nil, err
 	}
 
-	for parser.matchToken(grammar.BANG, grammar.EQUAL_EQUAL) {
+	for parser.matchToken(grammar.BANG_EQUAL, grammar.EQUAL_EQUAL) {
 		operator := parser.lookbehind()
 		rightExpr, err := parser.comparison()
 		if err != nil {
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/generator"
+	"github.com/DrEmbryo/jlox/src/lexer"
+)
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+	}{
+		{"not equal", "print 1 != 2;"},
+		{"chained and", "print a and b and c;"},
+		{"chained or", "print a or b or c;"},
+		{"property of number", "print 44.value;"},
+		{"comment before statement", "/* note */ print 1;"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			lexer := lexer.Lexer{Source: []rune(tc.source)}
+			tokens, errs := lexer.Tokenize()
+			if len(errs) > 0 {
+				t.Fatalf("got lexer errors %v", errs)
+			}
+			parser := Parser{Tokens: tokens}
+			if _, err := parser.Parse(); err != nil {
+				t.Errorf("got %v, want no error", err)
+			}
+		})
+	}
+}
+
+func TestGeneratedPrograms(t *testing.T) {
+	for seed := int64(0); seed < 200; seed++ {
+		source := generator.Program(seed, 8)
+		lexer := lexer.Lexer{Source: []rune(source)}
+		tokens, errs := lexer.Tokenize()
+		if len(errs) > 0 {
+			t.Fatalf("seed %v: got lexer errors %v for\n%s", seed, errs, source)
+		}
+		parser := Parser{Tokens: tokens}
+		if _, err := parser.Parse(); err != nil {
+			t.Fatalf("seed %v: got parser error %v for\n%s", seed, err, source)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{"class A < B {}", "a.b = c;", "func f(a: number): number { return a; }", "import \"m\" as m;", "for (;;) {}"} {
+		f.Add(seed)
+	}
+	for seed := int64(0); seed < 10; seed++ {
+		f.Add(generator.Program(seed, 5))
+	}
+
+	f.Fuzz(func(t *testing.T, source string) {
+		lexer := lexer.Lexer{Source: []rune(source)}
+		tokens, errs := lexer.Tokenize()
+		if len(errs) > 0 {
+			return
+		}
+		parser := Parser{Tokens: tokens}
+		parser.Parse()
+	})
+}
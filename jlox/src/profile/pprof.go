@@ -0,0 +1,182 @@
+package profile
+
+import (
+	"compress/gzip"
+	"io"
+	"sort"
+)
+
+// Field numbers from profile.proto in github.com/google/pprof.
+const (
+	PROFILE_SAMPLE_TYPE    = 1
+	PROFILE_SAMPLE         = 2
+	PROFILE_LOCATION       = 4
+	PROFILE_FUNCTION       = 5
+	PROFILE_STRING_TABLE   = 6
+	PROFILE_TIME_NANOS     = 9
+	PROFILE_DURATION_NANOS = 10
+	PROFILE_PERIOD_TYPE    = 11
+	PROFILE_PERIOD         = 12
+
+	VALUE_TYPE_TYPE = 1
+	VALUE_TYPE_UNIT = 2
+
+	SAMPLE_LOCATION_ID = 1
+	SAMPLE_VALUE       = 2
+
+	LOCATION_ID   = 1
+	LOCATION_LINE = 4
+
+	LINE_FUNCTION_ID = 1
+	LINE_LINE        = 2
+
+	FUNCTION_ID          = 1
+	FUNCTION_NAME        = 2
+	FUNCTION_SYSTEM_NAME = 3
+	FUNCTION_FILENAME    = 4
+	FUNCTION_START_LINE  = 5
+)
+
+const (
+	WIRE_VARINT = 0
+	WIRE_BYTES  = 2
+)
+
+// protobuf is a minimal encoder for the subset of the wire format needed by
+// profile.proto, which keeps the module free of dependencies.
+type protobuf struct {
+	data []byte
+}
+
+func (buffer *protobuf) varint(value uint64) {
+	for value >= 0x80 {
+		buffer.data = append(buffer.data, byte(value)|0x80)
+		value >>= 7
+	}
+	buffer.data = append(buffer.data, byte(value))
+}
+
+func (buffer *protobuf) tag(field int, wire int) {
+	buffer.varint(uint64(field)<<3 | uint64(wire))
+}
+
+func (buffer *protobuf) int(field int, value int64) {
+	if value == 0 {
+		return
+	}
+	buffer.tag(field, WIRE_VARINT)
+	buffer.varint(uint64(value))
+}
+
+func (buffer *protobuf) bytes(field int, value []byte) {
+	buffer.tag(field, WIRE_BYTES)
+	buffer.varint(uint64(len(value)))
+	buffer.data = append(buffer.data, value...)
+}
+
+func (buffer *protobuf) message(field int, encode func(message *protobuf)) {
+	message := &protobuf{}
+	encode(message)
+	buffer.bytes(field, message.data)
+}
+
+func (buffer *protobuf) packed(field int, values []int64) {
+	packed := &protobuf{}
+	for _, value := range values {
+		packed.varint(uint64(value))
+	}
+	buffer.bytes(field, packed.data)
+}
+
+type stringTable struct {
+	table []string
+	index map[string]int64
+}
+
+func (table *stringTable) id(value string) int64 {
+	if id, ok := table.index[value]; ok {
+		return id
+	}
+	table.index[value] = int64(len(table.table))
+	table.table = append(table.table, value)
+	return table.index[value]
+}
+
+// WritePprof writes a gzipped profile.proto with one sample per distinct
+// Lox call stack, valued by the number of calls and their self time, so
+// `go tool pprof` can render flame graphs of Lox frames.
+func (profiler *Profiler) WritePprof(writer io.Writer) error {
+	names := &stringTable{table: []string{""}, index: map[string]int64{"": 0}}
+	buffer := &protobuf{}
+
+	for _, sampleType := range [][2]string{{"calls", "count"}, {"time", "nanoseconds"}} {
+		buffer.message(PROFILE_SAMPLE_TYPE, func(message *protobuf) {
+			message.int(VALUE_TYPE_TYPE, names.id(sampleType[0]))
+			message.int(VALUE_TYPE_UNIT, names.id(sampleType[1]))
+		})
+	}
+
+	keys := make([]string, 0, len(profiler.stacks))
+	for key := range profiler.stacks {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	ids := make(map[Function]int64)
+	functions := make([]Function, 0)
+	for _, key := range keys {
+		sample := profiler.stacks[key]
+		locations := make([]int64, 0, len(sample.functions))
+		for _, function := range sample.functions {
+			if _, ok := ids[function]; !ok {
+				functions = append(functions, function)
+				ids[function] = int64(len(functions))
+			}
+			locations = append(locations, ids[function])
+		}
+		buffer.message(PROFILE_SAMPLE, func(message *protobuf) {
+			message.packed(SAMPLE_LOCATION_ID, locations)
+			message.packed(SAMPLE_VALUE, []int64{int64(sample.calls), int64(sample.self)})
+		})
+	}
+
+	for _, function := range functions {
+		buffer.message(PROFILE_LOCATION, func(message *protobuf) {
+			message.int(LOCATION_ID, ids[function])
+			message.message(LOCATION_LINE, func(line *protobuf) {
+				line.int(LINE_FUNCTION_ID, ids[function])
+				line.int(LINE_LINE, int64(function.Line))
+			})
+		})
+	}
+	for _, function := range functions {
+		file := function.File
+		if function.Native {
+			file = "<native>"
+		}
+		buffer.message(PROFILE_FUNCTION, func(message *protobuf) {
+			message.int(FUNCTION_ID, ids[function])
+			message.int(FUNCTION_NAME, names.id(function.Name))
+			message.int(FUNCTION_SYSTEM_NAME, names.id(function.Name))
+			message.int(FUNCTION_FILENAME, names.id(file))
+			message.int(FUNCTION_START_LINE, int64(function.Line))
+		})
+	}
+
+	buffer.int(PROFILE_TIME_NANOS, profiler.started.UnixNano())
+	buffer.int(PROFILE_DURATION_NANOS, int64(profiler.duration))
+	buffer.message(PROFILE_PERIOD_TYPE, func(message *protobuf) {
+		message.int(VALUE_TYPE_TYPE, names.id("time"))
+		message.int(VALUE_TYPE_UNIT, names.id("nanoseconds"))
+	})
+	buffer.int(PROFILE_PERIOD, 1)
+	for _, name := range names.table {
+		buffer.bytes(PROFILE_STRING_TABLE, []byte(name))
+	}
+
+	compressed := gzip.NewWriter(writer)
+	if _, err := compressed.Write(buffer.data); err != nil {
+		return err
+	}
+	return compressed.Close()
+}
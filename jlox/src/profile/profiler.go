@@ -0,0 +1,181 @@
+// Package profile measures where Lox programs spend their time. A Profiler
+// is installed as the interpreter's runtime.Hook and times every call to a
+// Lox function or native, attributing time to Lox-level frames so reports
+// and pprof profiles show the script's own call stacks.
+package profile
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+const MAIN = "main"
+
+type Function struct {
+	Name   string
+	File   string
+	Line   int
+	Native bool
+}
+
+func (function Function) String() string {
+	switch {
+	case function.Native:
+		return function.Name + " (native)"
+	case function.Line > 0:
+		return fmt.Sprintf("%s (%s:%d)", function.Name, function.File, function.Line)
+	}
+	return function.Name
+}
+
+type Stats struct {
+	Function Function
+	Calls    int
+	Self     time.Duration
+	Total    time.Duration
+}
+
+type Edge struct {
+	Caller Function
+	Callee Function
+	Calls  int
+	Time   time.Duration
+}
+
+type stack struct {
+	functions []Function
+	calls     int
+	self      time.Duration
+}
+
+type frame struct {
+	function Function
+	start    time.Time
+	children time.Duration
+}
+
+type Profiler struct {
+	// File names the script in reports; Clock defaults to time.Now.
+	File     string
+	Clock    func() time.Time
+	frames   []*frame
+	active   map[Function]int
+	stats    map[Function]*Stats
+	edges    map[[2]Function]*Edge
+	stacks   map[string]*stack
+	started  time.Time
+	duration time.Duration
+}
+
+func (profiler *Profiler) now() time.Time {
+	if profiler.Clock != nil {
+		return profiler.Clock()
+	}
+	return time.Now()
+}
+
+// Start begins timing the top-level code of the program as the main frame.
+func (profiler *Profiler) Start() {
+	profiler.active = make(map[Function]int)
+	profiler.stats = make(map[Function]*Stats)
+	profiler.edges = make(map[[2]Function]*Edge)
+	profiler.stacks = make(map[string]*stack)
+	profiler.started = profiler.now()
+	profiler.push(Function{Name: MAIN})
+}
+
+// Stop closes the frames still open, including main.
+func (profiler *Profiler) Stop() {
+	for len(profiler.frames) > 0 {
+		profiler.pop()
+	}
+	profiler.duration = profiler.now().Sub(profiler.started)
+}
+
+func (profiler *Profiler) Statement(interpreter *runtime.Interpreter, stmt grammar.Statement) grammar.LoxError {
+	return nil
+}
+
+func (profiler *Profiler) Branch(interpreter *runtime.Interpreter, node any, taken bool) {}
+
+func (profiler *Profiler) EnterFunction(interpreter *runtime.Interpreter, callee runtime.LoxCallable) {
+	if profiler.stats == nil {
+		profiler.Start()
+	}
+	switch callee := callee.(type) {
+	case *runtime.LoxFunction:
+		name := callee.Declaration.Name
+		profiler.push(Function{Name: fmt.Sprintf("%v", name.Lexeme), File: profiler.File, Line: name.Line})
+	case *runtime.NativeCall:
+		profiler.push(Function{Name: callee.Name, Native: true})
+	}
+}
+
+func (profiler *Profiler) ExitFunction(interpreter *runtime.Interpreter, callee runtime.LoxCallable) {
+	switch callee.(type) {
+	case *runtime.LoxFunction, *runtime.NativeCall:
+		if len(profiler.frames) > 1 {
+			profiler.pop()
+		}
+	}
+}
+
+func (profiler *Profiler) push(function Function) {
+	profiler.frames = append(profiler.frames, &frame{function: function, start: profiler.now()})
+	profiler.active[function]++
+}
+
+// pop records a finished call. Inclusive time, for the function and for the
+// edge from its caller, is only counted for the outermost active call of a
+// function, so recursion is not counted twice.
+func (profiler *Profiler) pop() {
+	top := profiler.frames[len(profiler.frames)-1]
+	elapsed := profiler.now().Sub(top.start)
+	self := elapsed - top.children
+
+	stats, ok := profiler.stats[top.function]
+	if !ok {
+		stats = &Stats{Function: top.function}
+		profiler.stats[top.function] = stats
+	}
+	stats.Calls++
+	stats.Self += self
+	profiler.active[top.function]--
+	if profiler.active[top.function] == 0 {
+		stats.Total += elapsed
+	}
+
+	functions := make([]Function, 0, len(profiler.frames))
+	names := make([]string, 0, len(profiler.frames))
+	for i := len(profiler.frames) - 1; i >= 0; i-- {
+		functions = append(functions, profiler.frames[i].function)
+		names = append(names, profiler.frames[i].function.String())
+	}
+	key := strings.Join(names, "\x00")
+	sample, ok := profiler.stacks[key]
+	if !ok {
+		sample = &stack{functions: functions}
+		profiler.stacks[key] = sample
+	}
+	sample.calls++
+	sample.self += self
+
+	profiler.frames = profiler.frames[:len(profiler.frames)-1]
+	if len(profiler.frames) > 0 {
+		caller := profiler.frames[len(profiler.frames)-1]
+		caller.children += elapsed
+		edge, ok := profiler.edges[[2]Function{caller.function, top.function}]
+		if !ok {
+			edge = &Edge{Caller: caller.function, Callee: top.function}
+			profiler.edges[[2]Function{caller.function, top.function}] = edge
+		}
+		edge.Calls++
+		if profiler.active[top.function] == 0 {
+			edge.Time += elapsed
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package profile
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DrEmbryo/jlox/src/lox"
+)
+
+const PROGRAM = `func leaf() {
+  return 1;
+}
+func outer() {
+  leaf();
+  return leaf();
+}
+outer();
+`
+
+// profileProgram runs PROGRAM with a clock that advances one millisecond
+// every time it is read.
+func profileProgram(t *testing.T) *Profiler {
+	now := time.Unix(0, 0)
+	profiler := &Profiler{File: "test.lox", Clock: func() time.Time {
+		now = now.Add(time.Millisecond)
+		return now
+	}}
+	profiler.Start()
+	if _, err := lox.New(lox.Options{Hook: profiler}).Eval(PROGRAM); err != nil {
+		t.Fatal(err)
+	}
+	profiler.Stop()
+	return profiler
+}
+
+func TestStats(t *testing.T) {
+	profiler := profileProgram(t)
+
+	leaf := Function{Name: "leaf", File: "test.lox", Line: 1}
+	outer := Function{Name: "outer", File: "test.lox", Line: 4}
+	main := Function{Name: MAIN}
+	expected := []Stats{
+		{Function: outer, Calls: 1, Self: 3 * time.Millisecond, Total: 5 * time.Millisecond},
+		{Function: leaf, Calls: 2, Self: 2 * time.Millisecond, Total: 2 * time.Millisecond},
+		{Function: main, Calls: 1, Self: 2 * time.Millisecond, Total: 7 * time.Millisecond},
+	}
+	if stats := profiler.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected %+v, got %+v", expected, stats)
+	}
+
+	edges := []Edge{
+		{Caller: outer, Callee: leaf, Calls: 2, Time: 2 * time.Millisecond},
+		{Caller: main, Callee: outer, Calls: 1, Time: 5 * time.Millisecond},
+	}
+	if got := profiler.Edges(); !reflect.DeepEqual(got, edges) {
+		t.Errorf("expected %+v, got %+v", edges, got)
+	}
+}
+
+func TestReports(t *testing.T) {
+	profiler := profileProgram(t)
+
+	var report bytes.Buffer
+	profiler.WriteFlat(&report)
+	profiler.WriteCallGraph(&report)
+	expected := `Flat profile (total 9ms):
+  self%  self  total  calls  function
+  33.3%   3ms    5ms      1  outer (test.lox:4)
+  22.2%   2ms    2ms      2  leaf (test.lox:1)
+  22.2%   2ms    7ms      1  main
+Call graph:
+outer (test.lox:4)
+  -> leaf (test.lox:1)  2 calls  2ms
+main
+  -> outer (test.lox:4)  1 calls  5ms
+`
+	if report.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, report.String())
+	}
+
+	var pprof bytes.Buffer
+	if err := profiler.WritePprof(&pprof); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := gzip.NewReader(&pprof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"leaf", "outer", "main", "test.lox", "nanoseconds"} {
+		if !bytes.Contains(data, []byte(name)) {
+			t.Errorf("expected the profile to mention %q", name)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package profile
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Stats returns the per-function statistics, most self time first.
+func (profiler *Profiler) Stats() []Stats {
+	stats := make([]Stats, 0, len(profiler.stats))
+	for _, stat := range profiler.stats {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Self != stats[j].Self {
+			return stats[i].Self > stats[j].Self
+		}
+		return stats[i].Function.String() < stats[j].Function.String()
+	})
+	return stats
+}
+
+// Edges returns the caller/callee pairs, grouped by caller in the order of
+// Stats and by time within a caller.
+func (profiler *Profiler) Edges() []Edge {
+	order := make(map[Function]int)
+	for i, stat := range profiler.Stats() {
+		order[stat.Function] = i
+	}
+	edges := make([]Edge, 0, len(profiler.edges))
+	for _, edge := range profiler.edges {
+		edges = append(edges, *edge)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if order[edges[i].Caller] != order[edges[j].Caller] {
+			return order[edges[i].Caller] < order[edges[j].Caller]
+		}
+		if edges[i].Time != edges[j].Time {
+			return edges[i].Time > edges[j].Time
+		}
+		return edges[i].Callee.String() < edges[j].Callee.String()
+	})
+	return edges
+}
+
+func (profiler *Profiler) WriteFlat(writer io.Writer) error {
+	fmt.Fprintf(writer, "Flat profile (total %v):\n", profiler.duration)
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "self%\tself\ttotal\tcalls\t  function")
+	for _, stat := range profiler.Stats() {
+		fmt.Fprintf(table, "%s\t%v\t%v\t%d\t  %s\n", percent(stat.Self, profiler.duration), stat.Self, stat.Total, stat.Calls, stat.Function)
+	}
+	return table.Flush()
+}
+
+func (profiler *Profiler) WriteCallGraph(writer io.Writer) error {
+	fmt.Fprintln(writer, "Call graph:")
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	var caller *Function
+	for _, edge := range profiler.Edges() {
+		if caller == nil || *caller != edge.Caller {
+			caller = &edge.Caller
+			fmt.Fprintf(table, "%s\n", edge.Caller)
+		}
+		fmt.Fprintf(table, "  -> %s\t%d calls\t%v\n", edge.Callee, edge.Calls, edge.Time)
+	}
+	return table.Flush()
+}
+
+func percent(part time.Duration, total time.Duration) string {
+	if total <= 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(part)*100/float64(total))
+}
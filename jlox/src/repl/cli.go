@@ -0,0 +1,420 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/ast"
+	"github.com/DrEmbryo/jlox/src/conformance"
+	"github.com/DrEmbryo/jlox/src/format"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+// Exit codes follow clox and sysexits.h.
+const (
+	EXIT_FAILURE       = 1
+	EXIT_USAGE         = 64
+	EXIT_COMPILE_ERROR = 65
+	EXIT_RUNTIME_ERROR = 70
+	EXIT_IO_ERROR      = 74
+)
+
+const STDIN = "-"
+
+const USAGE = `Usage: lox <command> [flags] [arguments]
+
+Commands:
+  run <file|->        run a script
+  repl                start the interactive prompt (the default)
+  check <file|->...   lex, parse and resolve without running
+  test [path]...      run scripts annotated with // expect: comments
+  fmt [path|-]...     format source files
+  ast <file|->        print the syntax tree as JSON or S-expressions
+  debug <file>        debug a script
+  disasm <file|->     show the bytecode of a script (clox only)
+  help                show this help
+
+"lox <file>" is short for "lox run <file>". Run "lox <command> -h" for the
+flags of a command.`
+
+var commands = map[string]func(args []string) int{
+	"run":    runMain,
+	"repl":   replCommand,
+	"check":  checkMain,
+	"test":   testMain,
+	"fmt":    fmtMain,
+	"ast":    astMain,
+	"debug":  debugMain,
+	"disasm": disasmMain,
+	"help":   helpMain,
+}
+
+// runCommand dispatches to a subcommand and returns the exit code. Without
+// one, a file argument runs the file and flags alone start the REPL, as
+// before subcommands existed.
+func runCommand(args []string) int {
+	if len(args) == 0 {
+		return replCommand(args)
+	}
+	if command, ok := commands[args[0]]; ok {
+		return command(args[1:])
+	}
+	switch {
+	case args[0] == "-h" || args[0] == "-help" || args[0] == "--help":
+		return helpMain(nil)
+	case args[0] != STDIN && strings.HasPrefix(args[0], "-"):
+		return replCommand(args)
+	}
+	return runMain(args)
+}
+
+func helpMain(args []string) int {
+	fmt.Println(USAGE)
+	return 0
+}
+
+// parseArgs parses flags placed before, between or after the positional
+// arguments, so both `lox run -timeout 1s a.lox` and `lox a.lox -timeout 1s`
+// work.
+func parseArgs(options *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := options.Parse(args); err != nil {
+			return nil, err
+		}
+		args = options.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// flagExit is the exit code for a flag parsing error; asking for help is not
+// a failure.
+func flagExit(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	return EXIT_USAGE
+}
+
+func usage(options *flag.FlagSet, line string) {
+	options.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: "+line)
+		options.PrintDefaults()
+	}
+}
+
+// readSource reads a script, or all of stdin for "-".
+func readSource(path string) (string, bool) {
+	var source []byte
+	var err error
+	if path == STDIN {
+		source, err = io.ReadAll(os.Stdin)
+	} else {
+		source, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read file \"%s\": %v\n", path, err)
+		return "", false
+	}
+	return string(source), true
+}
+
+func runMain(args []string) int {
+	options := newFlags("run")
+	options.Bool("profile", false, "Print flat and call-graph profiles of the script to stderr")
+	options.String("pprof", "", "Write a gzipped pprof profile of the script to the given file")
+	options.String("cover", "", "Record line and branch coverage of the script, merged into the given profile file")
+	usage(options, "lox run [flags] <file|->")
+	paths, err := parseArgs(options, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(paths) != 1 {
+		options.Usage()
+		return EXIT_USAGE
+	}
+
+	source, ok := readSource(paths[0])
+	if !ok {
+		return EXIT_IO_ERROR
+	}
+	loader := newLoader(options)
+	if paths[0] != STDIN {
+		loader.Root = paths[0]
+	}
+	return eval(source, options, loader)
+}
+
+func replCommand(args []string) int {
+	options := newFlags("repl")
+	usage(options, "lox repl [flags]")
+	rest, err := parseArgs(options, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(rest) > 0 {
+		options.Usage()
+		return EXIT_USAGE
+	}
+	replMain(newLoader(options))
+	return 0
+}
+
+// checkMain reports lexer, parser and resolver errors without running
+// anything.
+func checkMain(args []string) int {
+	options := flag.NewFlagSet("check", flag.ContinueOnError)
+	usage(options, "lox check <file|->...")
+	paths, err := parseArgs(options, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(paths) == 0 {
+		options.Usage()
+		return EXIT_USAGE
+	}
+
+	status := 0
+	for _, path := range paths {
+		source, ok := readSource(path)
+		if !ok {
+			status = max(status, EXIT_IO_ERROR)
+			continue
+		}
+		interpreter := runtime.Interpreter{LocalEnv: make(map[any]int)}
+		if _, errs := analyze(source, &interpreter); len(errs) > 0 {
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, strings.TrimSpace(e.Error()))
+			}
+			status = max(status, EXIT_COMPILE_ERROR)
+		}
+	}
+	return status
+}
+
+// testMain runs every .lox file under the given paths through the
+// conformance runner. Files without expectation comments are skipped.
+func testMain(args []string) int {
+	options := flag.NewFlagSet("test", flag.ContinueOnError)
+	verbose := options.Bool("v", false, "List passing files too")
+	usage(options, "lox test [-v] [path]...")
+	paths, err := parseArgs(options, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	passed, failed, skipped := 0, 0, 0
+	status := 0
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || filepath.Ext(path) != ".lox" {
+				return err
+			}
+			source, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			expected := conformance.Expected(string(source))
+			if len(expected.Output) == 0 && len(expected.Errors) == 0 {
+				skipped++
+				return nil
+			}
+			mismatches := conformance.Diff(expected, conformance.Run(path))
+			if expected.KnownFailure != "" {
+				if len(mismatches) > 0 {
+					skipped++
+					if *verbose {
+						fmt.Printf("skip %s: known failure: %s\n", path, expected.KnownFailure)
+					}
+					return nil
+				}
+				mismatches = []string{"passes, remove its known failure comment"}
+			}
+			if len(mismatches) == 0 {
+				passed++
+				if *verbose {
+					fmt.Printf("ok   %s\n", path)
+				}
+				return nil
+			}
+			failed++
+			fmt.Printf("FAIL %s\n", path)
+			for _, mismatch := range mismatches {
+				fmt.Printf("     %s\n", mismatch)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = EXIT_IO_ERROR
+		}
+	}
+	fmt.Printf("%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	if failed > 0 {
+		status = max(status, EXIT_FAILURE)
+	}
+	return status
+}
+
+// fmtMain formats files like loxfmt: to stdout by default, in place with -w,
+// or only reporting changes with -check and -diff.
+func fmtMain(args []string) int {
+	options := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	check := options.Bool("check", false, "List files whose formatting differs and exit with status 1")
+	diff := options.Bool("diff", false, "Print a diff of the formatting changes and exit with status 1 if there are any")
+	write := options.Bool("w", false, "Write the result back to the source file instead of stdout")
+	usage(options, "lox fmt [-check] [-diff] [-w] [path|-]...")
+	paths, err := parseArgs(options, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(paths) == 0 {
+		paths = []string{STDIN}
+	}
+
+	status := 0
+	formatFile := func(path string) {
+		source, ok := readSource(path)
+		if !ok {
+			status = max(status, EXIT_IO_ERROR)
+			return
+		}
+		formatted, errs := format.Format(source)
+		if len(errs) > 0 {
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", path, strings.TrimSpace(e.Error()))
+			}
+			status = max(status, EXIT_COMPILE_ERROR)
+			return
+		}
+		changed := formatted != source
+		switch {
+		case *check || *diff:
+			if *check && changed {
+				fmt.Println(path)
+			}
+			if *diff {
+				fmt.Print(format.Diff(path, source, formatted))
+			}
+			if changed {
+				status = max(status, EXIT_FAILURE)
+			}
+		case *write && path != STDIN:
+			if changed {
+				if err := os.WriteFile(path, []byte(formatted), 0644); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					status = max(status, EXIT_IO_ERROR)
+				}
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+	for _, path := range paths {
+		if path == STDIN {
+			formatFile(path)
+			continue
+		}
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || (filepath.Ext(file) != ".lox" && file != path) {
+				return err
+			}
+			formatFile(file)
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			status = max(status, EXIT_IO_ERROR)
+		}
+	}
+	return status
+}
+
+// astMain prints the parsed and resolved program in a form ast.ParseJSON,
+// ast.ParseSExpr or the parser reads back.
+func astMain(args []string) int {
+	options := flag.NewFlagSet("ast", flag.ContinueOnError)
+	format := options.String("format", "json", "Output format: json, sexpr, or source to print the tree back as Lox")
+	usage(options, "lox ast [-format json|sexpr|source] <file|->")
+	paths, err := parseArgs(options, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(paths) != 1 || (*format != "json" && *format != "sexpr" && *format != "source") {
+		options.Usage()
+		return EXIT_USAGE
+	}
+	source, ok := readSource(paths[0])
+	if !ok {
+		return EXIT_IO_ERROR
+	}
+	interpreter := runtime.Interpreter{LocalEnv: make(map[any]int)}
+	stmts, errs := analyze(source, &interpreter)
+	if len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", paths[0], strings.TrimSpace(e.Error()))
+		}
+		return EXIT_COMPILE_ERROR
+	}
+
+	var text string
+	switch *format {
+	case "json":
+		var data []byte
+		data, err = ast.JSON(stmts)
+		text = string(data) + "\n"
+	case "sexpr":
+		text, err = ast.SExpr(stmts)
+	case "source":
+		printer := utils.SourcePrinter{}
+		text = printer.Source(stmts)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return EXIT_FAILURE
+	}
+	fmt.Print(text)
+	return 0
+}
+
+// disasmMain checks the script so the usual errors are reported, then
+// explains that only clox compiles to bytecode.
+func disasmMain(args []string) int {
+	options := flag.NewFlagSet("disasm", flag.ContinueOnError)
+	usage(options, "lox disasm <file|->")
+	paths, err := parseArgs(options, args)
+	if err != nil {
+		return flagExit(err)
+	}
+	if len(paths) != 1 {
+		options.Usage()
+		return EXIT_USAGE
+	}
+	source, ok := readSource(paths[0])
+	if !ok {
+		return EXIT_IO_ERROR
+	}
+	interpreter := runtime.Interpreter{LocalEnv: make(map[any]int)}
+	if _, errs := analyze(source, &interpreter); len(errs) > 0 {
+		for _, e := range errs {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", paths[0], strings.TrimSpace(e.Error()))
+		}
+		return EXIT_COMPILE_ERROR
+	}
+	fmt.Fprintln(os.Stderr, "lox disasm: jlox walks the syntax tree and has no bytecode; disassembly is only available on clox.")
+	return EXIT_USAGE
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/modules"
+)
+
+func TestRunCommandExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ok.lox":      "print 1 + 2; // expect: 3\n",
+		"compile.lox": "var = ;\n",
+		"runtime.lox": "var x = -\"a\";\n",
+		"failing.lox": "print 2; // expect: 3\n",
+		"known.lox":   "// known failure: prints the wrong number.\nprint 2; // expect: 3\n",
+		"fixed.lox":   "// known failure: prints the wrong number.\nprint 3; // expect: 3\n",
+	}
+	for name, source := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{path("ok.lox")}, 0},
+		{[]string{"run", "-max-steps", "100", path("ok.lox")}, 0},
+		{[]string{path("ok.lox"), "-max-steps", "100"}, 0},
+		{[]string{"run", path("compile.lox")}, EXIT_COMPILE_ERROR},
+		{[]string{"run", path("runtime.lox")}, EXIT_RUNTIME_ERROR},
+		{[]string{"run", path("missing.lox")}, EXIT_IO_ERROR},
+		{[]string{"run"}, EXIT_USAGE},
+		{[]string{"run", "-unknown", path("ok.lox")}, EXIT_USAGE},
+		{[]string{"check", path("ok.lox"), path("runtime.lox")}, 0},
+		{[]string{"check", path("ok.lox"), path("compile.lox")}, EXIT_COMPILE_ERROR},
+		{[]string{"test", path("ok.lox")}, 0},
+		{[]string{"test", path("known.lox")}, 0},
+		{[]string{"test", path("fixed.lox")}, EXIT_FAILURE},
+		{[]string{"test", dir}, EXIT_FAILURE},
+		{[]string{"fmt", "-check", path("ok.lox")}, 0},
+		{[]string{"disasm", path("compile.lox")}, EXIT_COMPILE_ERROR},
+		{[]string{"help"}, 0},
+	}
+	for _, test := range tests {
+		if code := runCommand(test.args); code != test.code {
+			t.Errorf("lox %v: expected exit code %d, got %d", test.args, test.code, code)
+		}
+	}
+}
+
+func TestSearchPaths(t *testing.T) {
+	dir := t.TempDir()
+	for name, source := range map[string]string{
+		"flag/lib.lox": "export var from = \"flag\";",
+		"env/lib.lox":  "export var other = \"env\";",
+		"env/only.lox": "export var from = \"env\";",
+		"main.lox":     "import \"lib.lox\" as lib; import \"only.lox\" as only; print lib.from + \" \" + only.from;\n",
+	} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(source), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv(modules.SEARCH_PATH_ENV, filepath.Join(dir, "env"))
+
+	options := newFlags("run")
+	if err := options.Parse([]string{"-path", filepath.Join(dir, "flag")}); err != nil {
+		t.Fatal(err)
+	}
+	paths := searchPaths(options)
+	if len(paths) != 2 || paths[0] != filepath.Join(dir, "flag") || paths[1] != filepath.Join(dir, "env") {
+		t.Errorf("got search paths %q, want -path before %s", paths, modules.SEARCH_PATH_ENV)
+	}
+
+	if code := runCommand([]string{"run", "-path", filepath.Join(dir, "flag"), filepath.Join(dir, "main.lox")}); code != 0 {
+		t.Errorf("run: expected exit code 0, got %d", code)
+	}
+	if code := runCommand([]string{"run", filepath.Join(dir, "main.lox")}); code != EXIT_RUNTIME_ERROR {
+		t.Errorf("run without -path: expected exit code %d, got %d", EXIT_RUNTIME_ERROR, code)
+	}
+}
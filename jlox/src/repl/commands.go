@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DrEmbryo/jlox/src/debug"
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/parser"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+const REPL_HELP = `:tokens source   show the tokens of source
+:ast source      show the syntax tree of source
+:env             list the variables of every environment
+:type expr       evaluate expr and show the type of its value
+:load file.lox   run a file in this session
+:reset           forget every definition
+:time source     run source and show how long it took
+:disasm source   show the bytecode of source (clox only)
+:help            show this help
+:exit, :quit     leave the REPL`
+
+// command runs one meta-command and reports whether the session goes on.
+func (session *replSession) command(line string) bool {
+	name, argument, _ := strings.Cut(line, " ")
+	argument = strings.TrimSpace(argument)
+	switch name {
+	case ":exit", ":quit":
+		return false
+	case ":help":
+		fmt.Fprintln(session.output, REPL_HELP)
+	case ":tokens":
+		session.tokens(argument)
+	case ":ast":
+		session.ast(argument)
+	case ":env":
+		session.env()
+	case ":type":
+		session.typeOf(argument)
+	case ":load":
+		session.load(argument)
+	case ":reset":
+		session.interpreter = newInterpreter(session.loader)
+		fmt.Fprintln(session.output, "Session reset.")
+	case ":time":
+		start := time.Now()
+		session.eval(argument)
+		fmt.Fprintf(session.output, "Time: %v\n", time.Since(start).Round(time.Microsecond))
+	case ":disasm":
+		fmt.Fprintln(session.output, ":disasm is only available on clox; jlox walks the syntax tree and has no bytecode.")
+	default:
+		fmt.Fprintf(session.output, "Unknown command '%s'; type :help for a list.\n", name)
+	}
+	return true
+}
+
+func (session *replSession) tokens(source string) {
+	tokens, errs := lexer.Lexer{Source: []rune(source)}.Tokenize()
+	for _, e := range errs {
+		grammar.LoxError.Print(e)
+	}
+	printer := utils.TokenPrinter{Output: session.output}
+	printer.Print(tokens)
+}
+
+func (session *replSession) ast(source string) {
+	tokens, errs := lexer.Lexer{Source: []rune(terminate(source))}.Tokenize()
+	if len(errs) > 0 {
+		for _, e := range errs {
+			grammar.LoxError.Print(e)
+		}
+		return
+	}
+	stmts, err := parser.Parser{Tokens: tokens}.Parse()
+	if err != nil {
+		grammar.LoxError.Print(err)
+		return
+	}
+	printer := utils.AstPrinter{Output: session.output}
+	printer.Print(stmts)
+}
+
+// env lists every environment from the innermost out; natives from the
+// standard library are only counted.
+func (session *replSession) env() {
+	depth := 0
+	for env := &session.interpreter.Env; env != nil; env = env.Parent {
+		if env.Parent == nil {
+			fmt.Fprintln(session.output, "globals:")
+		} else {
+			fmt.Fprintf(session.output, "scope %d:\n", depth)
+		}
+		names := make([]string, 0, len(env.Values))
+		natives := 0
+		for name, value := range env.Values {
+			if _, ok := value.(runtime.NativeCall); ok {
+				natives++
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(session.output, "  %s = %s\n", name, debug.Format(env.Values[name]))
+		}
+		if natives > 0 {
+			fmt.Fprintf(session.output, "  (%d natives)\n", natives)
+		}
+		depth++
+	}
+}
+
+func (session *replSession) typeOf(source string) {
+	stmts, ok := compile(terminate(source), &session.interpreter)
+	if !ok {
+		return
+	}
+	stmt, isExpression := grammar.ExpressionStatement{}, false
+	if len(stmts) == 1 {
+		stmt, isExpression = stmts[0].(grammar.ExpressionStatement)
+	}
+	if !isExpression {
+		fmt.Fprintln(session.output, ":type expects a single expression.")
+		return
+	}
+	value, err := session.interpreter.Evaluate(stmt.Expression)
+	if err != nil {
+		grammar.LoxError.Print(err)
+		return
+	}
+	fmt.Fprintln(session.output, debug.TypeName(value))
+}
+
+// load runs a file as if its source had been typed in, resolving its
+// imports relative to the file.
+func (session *replSession) load(path string) {
+	if path == "" {
+		fmt.Fprintln(session.output, ":load expects a file name.")
+		return
+	}
+	source, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(session.output, err)
+		return
+	}
+	root := session.loader.Root
+	session.loader.Root = path
+	defer func() {
+		session.loader.Root = root
+	}()
+	session.eval(string(source))
+}
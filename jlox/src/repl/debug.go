@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/debug"
+	"github.com/DrEmbryo/jlox/src/lox"
+)
+
+const DEBUG_PROMPT = "(lox) "
+
+const DEBUG_HELP = `break [file:]line [if expr]  stop at a line, optionally only when expr is true
+watch expr                    stop when the value of expr changes
+run                           start the program
+continue                      run until the next breakpoint
+next                          run to the next statement, stepping over calls
+step                          run to the next statement, stepping into calls
+finish                        run until the current function returns
+print expr                    evaluate expr in the current frame
+locals                        list the variables of the current frame
+backtrace                     show the call stack
+quit                          stop debugging`
+
+type debugSession struct {
+	path     string
+	lines    []string
+	debugger *debug.Debugger
+	input    *bufio.Reader
+	output   io.Writer
+	options  lox.Options
+	running  bool
+	quit     bool
+}
+
+func debugMain(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: lox debug <file>")
+		return EXIT_USAGE
+	}
+	input := bufio.NewReader(os.Stdin)
+	session, err := newDebugSession(args[0], input, os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return EXIT_IO_ERROR
+	}
+	session.options.Stdin = input
+	session.run()
+	return 0
+}
+
+func newDebugSession(path string, input *bufio.Reader, output io.Writer) (*debugSession, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	session := &debugSession{
+		path:     path,
+		lines:    strings.Split(string(source), "\n"),
+		debugger: &debug.Debugger{},
+		input:    input,
+		output:   output,
+		options:  lox.Options{SearchPaths: []string{filepath.Dir(path)}, Stdout: output},
+	}
+	session.debugger.Stopped = session.stopped
+	session.options.Hook = session.debugger
+	return session, nil
+}
+
+// run reads commands until the program is started with run, then hands the
+// prompt over to stopped, which is called every time the program pauses.
+func (session *debugSession) run() {
+	for !session.running && !session.quit {
+		line, ok := session.readCommand()
+		if !ok {
+			return
+		}
+		session.execute(line)
+	}
+	if session.quit {
+		return
+	}
+
+	_, err := lox.New(session.options).RunFile(session.path)
+	if err != nil && !session.quit {
+		if loxErr, ok := err.(lox.Error); ok {
+			for _, e := range loxErr.Errors {
+				fmt.Fprintf(session.output, "[line %d] %s\n", lox.Line(e), lox.Message(e))
+			}
+		} else {
+			fmt.Fprintln(session.output, err)
+		}
+	}
+	if !session.quit {
+		fmt.Fprintln(session.output, "Program exited.")
+	}
+}
+
+func (session *debugSession) readCommand() (string, bool) {
+	fmt.Fprint(session.output, DEBUG_PROMPT)
+	line, err := session.input.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(session.output)
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
+
+func (session *debugSession) stopped(reason string) {
+	frame := session.debugger.Frames()[0]
+	switch reason {
+	case debug.STOP_BREAKPOINT:
+		fmt.Fprintf(session.output, "Breakpoint, %s at %s:%d\n", frame.Name, filepath.Base(session.path), frame.Line)
+	case debug.STOP_WATCH:
+		for _, watch := range session.debugger.Triggered() {
+			fmt.Fprintf(session.output, "Watch %d: %s\nOld value = %s\nNew value = %s\n", watch.ID, watch.Expression, watch.Old, watch.New)
+		}
+	}
+	session.printLine(frame.Line)
+
+	for {
+		line, ok := session.readCommand()
+		if !ok {
+			session.quit = true
+			session.debugger.Terminate()
+			return
+		}
+		if session.execute(line) {
+			return
+		}
+	}
+}
+
+func (session *debugSession) printLine(line int) {
+	if line >= 1 && line <= len(session.lines) {
+		fmt.Fprintf(session.output, "%d\t%s\n", line, session.lines[line-1])
+	}
+}
+
+// execute runs one command and reports whether it resumed the program.
+func (session *debugSession) execute(line string) bool {
+	command, argument, _ := strings.Cut(line, " ")
+	argument = strings.TrimSpace(argument)
+	switch command {
+	case "":
+		return false
+	case "help", "h":
+		fmt.Fprintln(session.output, DEBUG_HELP)
+	case "break", "b":
+		session.setBreakpoint(argument)
+	case "watch":
+		watch, err := session.debugger.Watch(argument)
+		if err != nil {
+			fmt.Fprintln(session.output, err)
+			return false
+		}
+		fmt.Fprintf(session.output, "Watch %d: %s\n", watch.ID, watch.Expression)
+	case "run", "r":
+		if session.running {
+			fmt.Fprintln(session.output, "The program is already running.")
+			return false
+		}
+		session.running = true
+		return true
+	case "quit", "q":
+		session.quit = true
+		if session.running {
+			session.debugger.Terminate()
+		}
+		return true
+	case "continue", "c", "next", "n", "step", "s", "finish", "print", "p", "locals", "backtrace", "bt":
+		if !session.running {
+			fmt.Fprintln(session.output, "The program is not being run.")
+			return false
+		}
+		return session.executeStopped(command, argument)
+	default:
+		fmt.Fprintf(session.output, "Undefined command: '%s'. Try 'help'.\n", command)
+	}
+	return false
+}
+
+func (session *debugSession) executeStopped(command string, argument string) bool {
+	switch command {
+	case "continue", "c":
+		session.debugger.Continue()
+		return true
+	case "next", "n":
+		session.debugger.StepOver()
+		return true
+	case "step", "s":
+		session.debugger.StepIn()
+		return true
+	case "finish":
+		session.debugger.StepOut()
+		return true
+	case "print", "p":
+		frame := session.debugger.Frames()[0]
+		value, err := session.debugger.Evaluate(frame.ID, argument)
+		if err != nil {
+			fmt.Fprintln(session.output, err)
+			return false
+		}
+		fmt.Fprintf(session.output, "%s = %s\n", argument, value.Value)
+	case "locals":
+		session.printLocals()
+	case "backtrace", "bt":
+		for i, frame := range session.debugger.Frames() {
+			fmt.Fprintf(session.output, "#%d  %s at %s:%d\n", i, frame.Name, filepath.Base(session.path), frame.Line)
+		}
+	}
+	return false
+}
+
+// printLocals lists the innermost scope of the current frame, which is the
+// globals when the program is paused at the top level.
+func (session *debugSession) printLocals() {
+	frame := session.debugger.Frames()[0]
+	scopes, err := session.debugger.Scopes(frame.ID)
+	if err != nil || len(scopes) == 0 {
+		fmt.Fprintln(session.output, "No locals.")
+		return
+	}
+	variables, _ := session.debugger.Variables(scopes[0].Reference)
+	if len(variables) == 0 {
+		fmt.Fprintln(session.output, "No locals.")
+	}
+	for _, variable := range variables {
+		fmt.Fprintf(session.output, "%s = %s\n", variable.Name, variable.Value)
+	}
+}
+
+func (session *debugSession) setBreakpoint(argument string) {
+	location, condition, _ := strings.Cut(argument, " if ")
+	location = strings.TrimSpace(location)
+	if file, line, ok := strings.Cut(location, ":"); ok {
+		if filepath.Base(file) != filepath.Base(session.path) {
+			fmt.Fprintf(session.output, "No source file named %s.\n", file)
+			return
+		}
+		location = line
+	}
+	line, err := strconv.Atoi(location)
+	if err != nil || line < 1 || line > len(session.lines) {
+		fmt.Fprintf(session.output, "Invalid line '%s'.\n", location)
+		return
+	}
+
+	requested := make([]debug.Breakpoint, 0)
+	for _, breakpoint := range session.debugger.Breakpoints() {
+		if breakpoint.Line != line {
+			requested = append(requested, debug.Breakpoint{Line: breakpoint.Line, Condition: breakpoint.Condition})
+		}
+	}
+	requested = append(requested, debug.Breakpoint{Line: line, Condition: strings.TrimSpace(condition)})
+	breakpoints := session.debugger.SetBreakpoints(requested)
+	breakpoint := breakpoints[len(breakpoints)-1]
+	if !breakpoint.Verified {
+		fmt.Fprintln(session.output, breakpoint.Message)
+		return
+	}
+	fmt.Fprintf(session.output, "Breakpoint %d at %s:%d\n", breakpoint.ID, filepath.Base(session.path), line)
+}
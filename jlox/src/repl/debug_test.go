@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const DEBUGGED = `func square(n) {
+  var result = n * n;
+  return result;
+}
+var total = square(2);
+total = total + square(3);
+print total;
+`
+
+func TestDebugSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "square.lox")
+	if err := os.WriteFile(path, []byte(DEBUGGED), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	commands := []string{
+		"print total",
+		"break square.lox:2 if n == 3",
+		"break other.lox:1",
+		"watch total",
+		"run",
+		"backtrace",
+		"print n * 10",
+		"finish",
+		"next",
+	}
+	var output bytes.Buffer
+	session, err := newDebugSession(path, bufio.NewReader(strings.NewReader(strings.Join(commands, "\n")+"\n")), &output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.run()
+
+	expected := `(lox) The program is not being run.
+(lox) Breakpoint 1 at square.lox:2
+(lox) No source file named other.lox.
+(lox) Watch 2: total
+(lox) Breakpoint, square at square.lox:2
+2	  var result = n * n;
+(lox) #0  square at square.lox:2
+#1  main at square.lox:6
+(lox) n * 10 = 30
+(lox) Watch 2: total
+Old value = 4
+New value = 13
+7	print total;
+(lox) 13
+Program exited.
+`
+	if output.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, output.String())
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	KEY_CTRL_A    = 1
+	KEY_CTRL_B    = 2
+	KEY_CTRL_C    = 3
+	KEY_CTRL_D    = 4
+	KEY_CTRL_E    = 5
+	KEY_CTRL_F    = 6
+	KEY_CTRL_H    = 8
+	KEY_TAB       = 9
+	KEY_CTRL_K    = 11
+	KEY_CTRL_L    = 12
+	KEY_CTRL_N    = 14
+	KEY_CTRL_P    = 16
+	KEY_CTRL_U    = 21
+	KEY_CTRL_W    = 23
+	KEY_ESCAPE    = 27
+	KEY_BACKSPACE = 127
+)
+
+var errInterrupted = errors.New("interrupted")
+
+// lineEditor reads one line from a terminal in raw mode, with cursor
+// movement, history navigation and tab completion. Putting the terminal in
+// raw mode is left to the caller.
+type lineEditor struct {
+	input   *bufio.Reader
+	output  io.Writer
+	history []string
+	// complete returns the identifier being typed before the cursor and the
+	// words that could replace it.
+	complete func(line []rune) (string, []string)
+
+	prompt  string
+	buffer  []rune
+	cursor  int
+	recall  int
+	pending []rune
+}
+
+func (editor *lineEditor) AddHistory(line string) {
+	if line == "" || (len(editor.history) > 0 && editor.history[len(editor.history)-1] == line) {
+		return
+	}
+	editor.history = append(editor.history, line)
+}
+
+// ReadLine returns the entered line, io.EOF for Ctrl-D on an empty line
+// and errInterrupted for Ctrl-C.
+func (editor *lineEditor) ReadLine(prompt string) (string, error) {
+	editor.prompt = prompt
+	editor.buffer = make([]rune, 0)
+	editor.cursor = 0
+	editor.recall = len(editor.history)
+	editor.render()
+
+	for {
+		key, _, err := editor.input.ReadRune()
+		if err != nil {
+			if len(editor.buffer) > 0 && err == io.EOF {
+				fmt.Fprint(editor.output, "\r\n")
+				return string(editor.buffer), nil
+			}
+			return "", err
+		}
+
+		switch key {
+		case '\r', '\n':
+			fmt.Fprint(editor.output, "\r\n")
+			return string(editor.buffer), nil
+		case KEY_CTRL_C:
+			fmt.Fprint(editor.output, "^C\r\n")
+			return "", errInterrupted
+		case KEY_CTRL_D:
+			if len(editor.buffer) == 0 {
+				fmt.Fprint(editor.output, "\r\n")
+				return "", io.EOF
+			}
+			editor.delete()
+		case KEY_CTRL_A:
+			editor.cursor = 0
+		case KEY_CTRL_E:
+			editor.cursor = len(editor.buffer)
+		case KEY_CTRL_B:
+			editor.left()
+		case KEY_CTRL_F:
+			editor.right()
+		case KEY_CTRL_H, KEY_BACKSPACE:
+			if editor.cursor > 0 {
+				editor.cursor--
+				editor.delete()
+			}
+		case KEY_CTRL_K:
+			editor.buffer = editor.buffer[:editor.cursor]
+		case KEY_CTRL_U:
+			editor.buffer = editor.buffer[editor.cursor:]
+			editor.cursor = 0
+		case KEY_CTRL_W:
+			editor.deleteWord()
+		case KEY_CTRL_L:
+			fmt.Fprint(editor.output, "\x1b[H\x1b[2J")
+		case KEY_CTRL_P:
+			editor.previous()
+		case KEY_CTRL_N:
+			editor.next()
+		case KEY_TAB:
+			editor.completeWord()
+		case KEY_ESCAPE:
+			editor.escape()
+		default:
+			if unicode.IsPrint(key) {
+				editor.insert([]rune{key})
+			}
+		}
+		editor.render()
+	}
+}
+
+func (editor *lineEditor) render() {
+	fmt.Fprintf(editor.output, "\r%s%s\x1b[K", editor.prompt, string(editor.buffer))
+	if back := len(editor.buffer) - editor.cursor; back > 0 {
+		fmt.Fprintf(editor.output, "\x1b[%dD", back)
+	}
+}
+
+func (editor *lineEditor) insert(text []rune) {
+	buffer := make([]rune, 0, len(editor.buffer)+len(text))
+	buffer = append(buffer, editor.buffer[:editor.cursor]...)
+	buffer = append(buffer, text...)
+	editor.buffer = append(buffer, editor.buffer[editor.cursor:]...)
+	editor.cursor += len(text)
+}
+
+func (editor *lineEditor) delete() {
+	if editor.cursor < len(editor.buffer) {
+		editor.buffer = append(editor.buffer[:editor.cursor], editor.buffer[editor.cursor+1:]...)
+	}
+}
+
+func (editor *lineEditor) deleteWord() {
+	start := editor.cursor
+	for start > 0 && unicode.IsSpace(editor.buffer[start-1]) {
+		start--
+	}
+	for start > 0 && !unicode.IsSpace(editor.buffer[start-1]) {
+		start--
+	}
+	editor.buffer = append(editor.buffer[:start], editor.buffer[editor.cursor:]...)
+	editor.cursor = start
+}
+
+func (editor *lineEditor) left() {
+	if editor.cursor > 0 {
+		editor.cursor--
+	}
+}
+
+func (editor *lineEditor) right() {
+	if editor.cursor < len(editor.buffer) {
+		editor.cursor++
+	}
+}
+
+// previous and next walk the history; the line being typed is kept in
+// pending so coming back past the newest entry restores it.
+func (editor *lineEditor) previous() {
+	if editor.recall == 0 {
+		return
+	}
+	if editor.recall == len(editor.history) {
+		editor.pending = editor.buffer
+	}
+	editor.recall--
+	editor.show([]rune(editor.history[editor.recall]))
+}
+
+func (editor *lineEditor) next() {
+	if editor.recall >= len(editor.history) {
+		return
+	}
+	editor.recall++
+	if editor.recall == len(editor.history) {
+		editor.show(editor.pending)
+		return
+	}
+	editor.show([]rune(editor.history[editor.recall]))
+}
+
+func (editor *lineEditor) show(line []rune) {
+	editor.buffer = append(make([]rune, 0, len(line)), line...)
+	editor.cursor = len(editor.buffer)
+}
+
+// escape handles the CSI and SS3 sequences sent by arrow, home, end and
+// delete keys.
+func (editor *lineEditor) escape() {
+	kind, _, err := editor.input.ReadRune()
+	if err != nil || (kind != '[' && kind != 'O') {
+		return
+	}
+	parameter := make([]rune, 0)
+	for {
+		key, _, err := editor.input.ReadRune()
+		if err != nil {
+			return
+		}
+		if key >= 0x40 && key <= 0x7e {
+			editor.sequence(key, string(parameter))
+			return
+		}
+		parameter = append(parameter, key)
+	}
+}
+
+func (editor *lineEditor) sequence(final rune, parameter string) {
+	switch final {
+	case 'A':
+		editor.previous()
+	case 'B':
+		editor.next()
+	case 'C':
+		editor.right()
+	case 'D':
+		editor.left()
+	case 'H':
+		editor.cursor = 0
+	case 'F':
+		editor.cursor = len(editor.buffer)
+	case '~':
+		switch parameter {
+		case "1", "7":
+			editor.cursor = 0
+		case "4", "8":
+			editor.cursor = len(editor.buffer)
+		case "3":
+			editor.delete()
+		}
+	}
+}
+
+// completeWord inserts the only candidate, or the longest prefix shared by
+// all candidates; when that adds nothing the candidates are listed.
+func (editor *lineEditor) completeWord() {
+	if editor.complete == nil {
+		return
+	}
+	word, candidates := editor.complete(editor.buffer[:editor.cursor])
+	if len(candidates) == 0 {
+		return
+	}
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(prefix) > len(word) {
+		editor.insert([]rune(prefix[len(word):]))
+		return
+	}
+	sort.Strings(candidates)
+	fmt.Fprintf(editor.output, "\r\n%s\r\n", strings.Join(candidates, "  "))
+}
@@ -1,97 +1,182 @@
 package main
 
 import (
-	"bufio"
 	"flag"
 	"fmt"
-	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/DrEmbryo/jlox/src/checker"
+	"github.com/DrEmbryo/jlox/src/coverage"
 	"github.com/DrEmbryo/jlox/src/grammar"
 	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/modules"
 	"github.com/DrEmbryo/jlox/src/parser"
+	"github.com/DrEmbryo/jlox/src/profile"
 	"github.com/DrEmbryo/jlox/src/resolver"
 	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/stdlib"
 	"github.com/DrEmbryo/jlox/src/utils"
 )
 
 func main() {
-	options := flag.NewFlagSet("options", flag.ContinueOnError)
-	options.Bool("debug", false, "Run REPL in debug mode")
+	os.Exit(runCommand(os.Args[1:]))
+}
 
-	var source string
-	if len(os.Args) < 2 || strings.Contains(os.Args[1], "-") {
-		options.Parse(os.Args[1:])
-		fmt.Println("Lox REPL 0.4: ")
-		for {
-			reader := bufio.NewReader(os.Stdin)
-			fmt.Print("> ")
-			source, _ = reader.ReadString('\n')
-			eval(source, options)
-		}
+// newFlags declares the flags shared by every command that runs Lox code.
+func newFlags(name string) *flag.FlagSet {
+	options := flag.NewFlagSet(name, flag.ContinueOnError)
+	options.String("stdlib", "math,strings,types,conversion,io", "Standard library groups to load separated by ','")
+	options.Int("max-steps", 0, "Maximum number of executed statements (0 is unlimited)")
+	options.Int("max-depth", runtime.DEFAULT_MAX_CALL_DEPTH, "Maximum call depth")
+	options.Int64("max-memory", 0, "Maximum number of bytes a script may allocate (0 is unlimited)")
+	options.Duration("timeout", 0, "Maximum execution time, e.g. 5s (0 is unlimited)")
+	options.String("path", "", fmt.Sprintf("Module search paths separated by '%c' (also read from %s)", os.PathListSeparator, modules.SEARCH_PATH_ENV))
+	return options
+}
 
-	} else {
-		options.Parse(os.Args[2:])
-		sourceRaw, err := os.ReadFile(os.Args[1])
-		if err != nil {
-			fmt.Println(err)
-			panic("Unable to read source")
-		}
-		source = string(sourceRaw)
-		eval(source, options)
+func newLoader(options *flag.FlagSet) *modules.Loader {
+	return &modules.Loader{
+		SearchPaths: searchPaths(options),
+		Libraries:   libraries(options),
+		Limits:      limits(options),
 	}
 }
 
-func eval(source string, options *flag.FlagSet) {
-	debugOption, parseErr := strconv.ParseBool(options.Lookup("debug").Value.String())
-	if parseErr != nil {
-		log.Fatal(parseErr)
-	}
+func searchPaths(options *flag.FlagSet) []string {
+	paths := modules.ParseSearchPaths(options.Lookup("path").Value.String())
+	return append(paths, modules.ParseSearchPaths(os.Getenv(modules.SEARCH_PATH_ENV))...)
+}
 
+func libraries(options *flag.FlagSet) []stdlib.Library {
+	return stdlib.Lookup(strings.Split(options.Lookup("stdlib").Value.String(), ",")...)
+}
+
+func limits(options *flag.FlagSet) runtime.Limits {
+	maxSteps, _ := strconv.Atoi(options.Lookup("max-steps").Value.String())
+	maxDepth, _ := strconv.Atoi(options.Lookup("max-depth").Value.String())
+	maxMemory, _ := strconv.ParseInt(options.Lookup("max-memory").Value.String(), 10, 64)
+	timeout, _ := time.ParseDuration(options.Lookup("timeout").Value.String())
+	return runtime.Limits{MaxSteps: maxSteps, MaxCallDepth: maxDepth, MaxMemory: maxMemory, Timeout: timeout}
+}
+
+// analyze lexes, parses and resolves source for interpreter and returns the
+// first stage's errors.
+func analyze(source string, interpreter *runtime.Interpreter) ([]grammar.Statement, []grammar.LoxError) {
 	lexer := &lexer.Lexer{Source: []rune(source)}
 	loxTokens, lexErrs := lexer.Tokenize()
 	if len(lexErrs) > 0 {
+		errs := make([]grammar.LoxError, 0, len(lexErrs))
 		for _, e := range lexErrs {
-			grammar.LoxError.Print(e)
+			errs = append(errs, e)
 		}
-		if !debugOption {
-			return
-		}
-	}
-	if debugOption {
-		printer := utils.TokenPrinter{}
-		printer.Print(loxTokens)
+		return nil, errs
 	}
 	parser := parser.Parser{Tokens: loxTokens}
 	stmts, err := parser.Parse()
 	if err != nil {
-		grammar.LoxError.Print(err)
-		if !debugOption {
-			return
-		}
+		return nil, []grammar.LoxError{err}
 	}
-	if debugOption {
-		printer := utils.AstPrinter{}
-		printer.Print(stmts)
+	resolver := resolver.Resolver{Interpreter: *interpreter, Scopes: utils.Stack[map[string]bool]{}, Error: make([]grammar.LoxError, 0)}
+	if errs := resolver.Resolve(stmts); len(errs) > 0 {
+		return nil, errs
 	}
-	env := runtime.Environment{Values: make(map[string]any), Parent: nil}
-	interpreter := runtime.Interpreter{Env: env, LocalEnv: make(map[any]int)}
-	resolver := resolver.Resolver{Interpreter: interpreter, Scopes: utils.Stack[map[string]bool]{}, Error: make([]grammar.LoxError, 0)}
-	errs := resolver.Resolve(stmts)
+	return stmts, nil
+}
+
+// compile is analyze followed by the type checker, printing every error and
+// warning; ok is false when the program should not run.
+func compile(source string, interpreter *runtime.Interpreter) ([]grammar.Statement, bool) {
+	stmts, errs := analyze(source, interpreter)
 	if len(errs) > 0 {
 		for _, e := range errs {
 			grammar.LoxError.Print(e)
 		}
-		if !debugOption {
-			return
-		}
+		return nil, false
+	}
+	checker := checker.TypeChecker{}
+	for _, e := range checker.Check(stmts) {
+		grammar.LoxError.Print(e)
+	}
+	return stmts, true
+}
+
+func newInterpreter(loader *modules.Loader) runtime.Interpreter {
+	env := runtime.Environment{Values: make(map[string]any), Parent: nil}
+	stdlib.Register(&env, loader.Libraries...)
+	return runtime.Interpreter{Env: env, LocalEnv: make(map[any]int), Modules: loader, Limits: loader.Limits, Stdin: loader.Stdin}
+}
+
+// eval runs a whole script and returns the process exit code.
+func eval(source string, options *flag.FlagSet, loader *modules.Loader) int {
+	interpreter := newInterpreter(loader)
+	stmts, ok := compile(source, &interpreter)
+	if !ok {
+		return EXIT_COMPILE_ERROR
+	}
+	hooks := runtime.Hooks{}
+	var profiler *profile.Profiler
+	if loader.Root != "" && (options.Lookup("profile").Value.String() == "true" || options.Lookup("pprof").Value.String() != "") {
+		profiler = &profile.Profiler{File: filepath.Base(loader.Root)}
+		hooks = append(hooks, profiler)
+		profiler.Start()
+	}
+	var collector *coverage.Collector
+	if loader.Root != "" && options.Lookup("cover").Value.String() != "" {
+		collector = &coverage.Collector{Path: loader.Root}
+		collector.Instrument(stmts)
+		hooks = append(hooks, collector)
+	}
+	if len(hooks) > 0 {
+		interpreter.Hook = hooks
+	}
+	errs := interpreter.Interpret(stmts)
+	for _, e := range errs {
+		grammar.LoxError.Print(e)
+	}
+	if profiler != nil {
+		profiler.Stop()
+		writeProfile(profiler, options)
+	}
+	if collector != nil {
+		writeCoverage(collector, options.Lookup("cover").Value.String())
 	}
-	errs = interpreter.Interpret(stmts)
 	if len(errs) > 0 {
-		for _, e := range errs {
-			grammar.LoxError.Print(e)
+		return EXIT_RUNTIME_ERROR
+	}
+	return 0
+}
+
+func writeCoverage(collector *coverage.Collector, path string) {
+	merged, err := coverage.LoadOrEmpty(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+	merged.Merge(collector.Profile())
+	if err := merged.Save(path); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+}
+
+func writeProfile(profiler *profile.Profiler, options *flag.FlagSet) {
+	if options.Lookup("profile").Value.String() == "true" {
+		profiler.WriteFlat(os.Stderr)
+		fmt.Fprintln(os.Stderr)
+		profiler.WriteCallGraph(os.Stderr)
+	}
+	if path := options.Lookup("pprof").Value.String(); path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return
+		}
+		defer file.Close()
+		if err := profiler.WritePprof(file); err != nil {
+			fmt.Fprintln(os.Stderr, err)
 		}
 	}
 }
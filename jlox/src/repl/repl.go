@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/debug"
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/modules"
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+const (
+	PROMPT              = "> "
+	CONTINUATION_PROMPT = "... "
+	HISTORY_FILE        = ".lox_history"
+	HISTORY_LIMIT       = 1000
+)
+
+// replSession keeps one interpreter for the whole session, so declarations
+// from earlier inputs stay visible.
+type replSession struct {
+	loader      *modules.Loader
+	interpreter runtime.Interpreter
+	input       *bufio.Reader
+	output      io.Writer
+	// editor is nil when stdin is not a terminal; lines are then read as is.
+	editor      *lineEditor
+	historyPath string
+}
+
+func replMain(loader *modules.Loader) {
+	input := bufio.NewReader(os.Stdin)
+	loader.Stdin = input
+	session := newReplSession(loader, input, os.Stdout)
+	if isTerminal(os.Stdin.Fd()) {
+		session.editor = &lineEditor{input: input, output: os.Stdout, complete: session.complete}
+		if home, err := os.UserHomeDir(); err == nil {
+			session.historyPath = filepath.Join(home, HISTORY_FILE)
+			session.loadHistory()
+		}
+	}
+	session.run()
+}
+
+func newReplSession(loader *modules.Loader, input *bufio.Reader, output io.Writer) *replSession {
+	return &replSession{
+		loader:      loader,
+		interpreter: newInterpreter(loader),
+		input:       input,
+		output:      output,
+	}
+}
+
+func (session *replSession) run() {
+	fmt.Fprintln(session.output, "Lox REPL 0.5: type :help for commands, :exit or Ctrl-D to quit")
+	for {
+		source, err := session.read()
+		if errors.Is(err, errInterrupted) {
+			continue
+		}
+		if source == "" && err != nil {
+			return
+		}
+		trimmed := strings.TrimSpace(source)
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, ":"):
+			if !session.command(trimmed) {
+				return
+			}
+		default:
+			session.eval(source)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// read returns one complete input, prompting for more lines while braces
+// or parentheses are left open.
+func (session *replSession) read() (string, error) {
+	lines := make([]string, 0)
+	prompt := PROMPT
+	for {
+		line, err := session.readLine(prompt)
+		if err != nil && (line == "" || errors.Is(err, errInterrupted)) {
+			return strings.Join(lines, "\n"), err
+		}
+		lines = append(lines, line)
+		source := strings.Join(lines, "\n")
+		if err != nil || !incomplete(source) {
+			return source, err
+		}
+		prompt = CONTINUATION_PROMPT
+	}
+}
+
+func (session *replSession) readLine(prompt string) (string, error) {
+	if session.editor == nil {
+		fmt.Fprint(session.output, prompt)
+		line, err := session.input.ReadString('\n')
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	state, err := makeRaw(os.Stdin.Fd())
+	if err != nil {
+		return "", err
+	}
+	line, err := session.editor.ReadLine(prompt)
+	restoreTerminal(os.Stdin.Fd(), state)
+	if err == nil {
+		session.addHistory(line)
+	}
+	return line, err
+}
+
+// incomplete reports whether source ends inside a string or with more
+// opening than closing braces or parentheses.
+func incomplete(source string) bool {
+	tokens, errs := lexer.Lexer{Source: []rune(source)}.Tokenize()
+	for _, err := range errs {
+		if err.Message == "Unterminated string" {
+			return true
+		}
+	}
+	depth := 0
+	for _, token := range tokens {
+		switch token.TokenType {
+		case grammar.LEFT_BRACE, grammar.LEFT_PAREN:
+			depth++
+		case grammar.RIGHT_BRACE, grammar.RIGHT_PAREN:
+			depth--
+		}
+	}
+	return depth > 0
+}
+
+// eval runs one input; when it ends with an expression statement the value
+// is printed the way the debugger shows values.
+func (session *replSession) eval(source string) {
+	stmts, ok := compile(terminate(source), &session.interpreter)
+	if !ok {
+		return
+	}
+
+	last, hasValue := grammar.ExpressionStatement{}, false
+	if len(stmts) > 0 {
+		last, hasValue = stmts[len(stmts)-1].(grammar.ExpressionStatement)
+		if hasValue {
+			stmts = stmts[:len(stmts)-1]
+		}
+	}
+	if errs := session.interpreter.Interpret(stmts); len(errs) > 0 {
+		for _, e := range errs {
+			grammar.LoxError.Print(e)
+		}
+		return
+	}
+	if !hasValue {
+		return
+	}
+	value, err := session.interpreter.Evaluate(last.Expression)
+	if err != nil {
+		grammar.LoxError.Print(err)
+		return
+	}
+	if value != nil {
+		fmt.Fprintln(session.output, debug.Format(value))
+	}
+}
+
+// terminate adds the semicolon that may be left out after a bare
+// expression such as `x + 1`.
+func terminate(source string) string {
+	trimmed := strings.TrimSpace(source)
+	if trimmed == "" || strings.HasSuffix(trimmed, ";") || strings.HasSuffix(trimmed, "}") {
+		return source
+	}
+	return trimmed + ";"
+}
+
+func (session *replSession) loadHistory() {
+	data, err := os.ReadFile(session.historyPath)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		session.editor.AddHistory(line)
+	}
+	if len(session.editor.history) > HISTORY_LIMIT {
+		session.editor.history = session.editor.history[len(session.editor.history)-HISTORY_LIMIT:]
+		os.WriteFile(session.historyPath, []byte(strings.Join(session.editor.history, "\n")+"\n"), 0o600)
+	}
+}
+
+func (session *replSession) addHistory(line string) {
+	count := len(session.editor.history)
+	session.editor.AddHistory(line)
+	if session.historyPath == "" || len(session.editor.history) == count {
+		return
+	}
+	file, err := os.OpenFile(session.historyPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+	fmt.Fprintln(file, line)
+}
+
+// complete offers globals and keywords for a bare identifier, and the
+// fields, methods or exports of the value named by a dotted path such as
+// `point.` or `math.sq`. Paths are only looked up, never evaluated, so
+// completion has no side effects.
+func (session *replSession) complete(line []rune) (string, []string) {
+	start := len(line)
+	for start > 0 && isIdentifierRune(line[start-1]) {
+		start--
+	}
+	word := string(line[start:])
+
+	names := make([]string, 0)
+	if start > 0 && line[start-1] == '.' {
+		pathStart := start - 1
+		for pathStart > 0 && (isIdentifierRune(line[pathStart-1]) || line[pathStart-1] == '.') {
+			pathStart--
+		}
+		path := strings.Split(string(line[pathStart:start-1]), ".")
+		value, ok := session.interpreter.Env.Get(path[0])
+		for _, name := range path[1:] {
+			if !ok {
+				break
+			}
+			value, ok = member(value, name)
+		}
+		if ok {
+			names = members(value)
+		}
+	} else {
+		for name := range session.interpreter.Env.Values {
+			names = append(names, name)
+		}
+		for keyword := range grammar.KEYWORDS {
+			names = append(names, keyword)
+		}
+	}
+
+	candidates := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if strings.HasPrefix(name, word) && !seen[name] {
+			seen[name] = true
+			candidates = append(candidates, name)
+		}
+	}
+	sort.Strings(candidates)
+	return word, candidates
+}
+
+func isIdentifierRune(char rune) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
+
+func member(value any, name string) (any, bool) {
+	switch value := value.(type) {
+	case runtime.LoxClassInstance:
+		field, ok := value.Class.Fields[name]
+		return field, ok
+	case runtime.LoxModule:
+		export, ok := value.Exports[name]
+		return export, ok
+	}
+	return nil, false
+}
+
+func members(value any) []string {
+	names := make([]string, 0)
+	switch value := value.(type) {
+	case runtime.LoxClassInstance:
+		for name := range value.Class.Fields {
+			names = append(names, fmt.Sprint(name))
+		}
+		class := value.Class
+		for class != nil {
+			for name := range class.Methods {
+				names = append(names, name)
+			}
+			super, ok := class.Super.(runtime.LoxClass)
+			if !ok {
+				break
+			}
+			class = &super
+		}
+	case runtime.LoxModule:
+		for name := range value.Exports {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/modules"
+	"github.com/DrEmbryo/jlox/src/stdlib"
+)
+
+func newTestSession(input string, output io.Writer) *replSession {
+	return newReplSession(&modules.Loader{}, bufio.NewReader(strings.NewReader(input)), output)
+}
+
+func TestReplSession(t *testing.T) {
+	input := `var x = 20;
+func double(n) {
+  return n * 2;
+}
+double(x) + 2
+"done"
+:exit
+x
+`
+	var output bytes.Buffer
+	newTestSession(input, &output).run()
+
+	expected := "Lox REPL 0.5: type :help for commands, :exit or Ctrl-D to quit\n> > ... ... > 42\n> \"done\"\n> "
+	if output.String() != expected {
+		t.Errorf("expected %q, got %q", expected, output.String())
+	}
+}
+
+func TestReplCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "greet.lox")
+	if err := os.WriteFile(path, []byte("var greeting = \"hi\";\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		command  string
+		expected []string
+	}{
+		{":tokens 1 + x", []string{"Tokens generated from source:", "lexeme [+]", "lexeme [x]"}},
+		{":ast count = 1", []string{"Ast generated from tokens:", "AssignmentExpression"}},
+		{":type count", []string{"number\n"}},
+		{":type var y = 1;", []string{":type expects a single expression.\n"}},
+		{":env", []string{"globals:\n", "  count = 0\n", "natives)\n"}},
+		{":load " + path + "\ngreeting", []string{"\"hi\"\n"}},
+		{":time count + 1", []string{"1\nTime: "}},
+		{":reset\n:env", []string{"Session reset.\n> globals:\n  (7 natives)\n"}},
+		{":disasm 1", []string{"only available on clox"}},
+		{":nope", []string{"Unknown command ':nope'"}},
+		{":help", []string{":load file.lox"}},
+	}
+	for _, test := range tests {
+		var output bytes.Buffer
+		session := newTestSession(test.command+"\n", &output)
+		session.loader.Libraries = stdlib.Lookup("math")
+		session.interpreter = newInterpreter(session.loader)
+		session.eval("var count = 0;")
+		session.run()
+		for _, expected := range test.expected {
+			if !strings.Contains(output.String(), expected) {
+				t.Errorf("%s: expected output to contain %q, got:\n%s", test.command, expected, output.String())
+			}
+		}
+	}
+}
+
+func TestIncomplete(t *testing.T) {
+	tests := []struct {
+		source     string
+		incomplete bool
+	}{
+		{"var x = 1;", false},
+		{"func f() {", true},
+		{"func f() {\n  return (1 +", true},
+		{"func f() {\n  return 1;\n}", false},
+		{"print \"unterminated", true},
+		{"}", false},
+	}
+	for _, test := range tests {
+		if got := incomplete(test.source); got != test.incomplete {
+			t.Errorf("%q: expected %v, got %v", test.source, test.incomplete, got)
+		}
+	}
+}
+
+func TestComplete(t *testing.T) {
+	session := newTestSession("", io.Discard)
+	session.eval(`class Point { norm() { return 0; } }
+var point = Point();
+point.x = 1;`)
+
+	tests := []struct {
+		line       string
+		word       string
+		candidates []string
+	}{
+		{"Po", "Po", []string{"Point"}},
+		{"va", "va", []string{"var"}},
+		{"1 + point.", "", []string{"norm", "x"}},
+		{"point.n", "n", []string{"norm"}},
+		{"missing.", "", []string{}},
+	}
+	for _, test := range tests {
+		word, candidates := session.complete([]rune(test.line))
+		if word != test.word || !reflect.DeepEqual(candidates, test.candidates) {
+			t.Errorf("%q: expected %q %v, got %q %v", test.line, test.word, test.candidates, word, candidates)
+		}
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	tests := []struct {
+		keys string
+		line string
+		err  error
+	}{
+		{"abc\r", "abc", nil},
+		{"ab\x1b[D\x7fc\r", "cb", nil},
+		{"abc\x01\x1b[3~\r", "bc", nil},
+		{"abc\x1b[D\x0b\r", "ab", nil},
+		{"hello world\x17\r", "hello ", nil},
+		{"\x1b[A\x1b[A\r", "first", nil},
+		{"x\x1b[A\x1b[B\r", "x", nil},
+		{"dou\t(1)\r", "double(1)", nil},
+		{"abc\x03", "", errInterrupted},
+		{"\x04", "", io.EOF},
+	}
+	for _, test := range tests {
+		editor := &lineEditor{
+			input:   bufio.NewReader(strings.NewReader(test.keys)),
+			output:  io.Discard,
+			history: []string{"first", "second"},
+			complete: func(line []rune) (string, []string) {
+				return "dou", []string{"double"}
+			},
+		}
+		line, err := editor.ReadLine(PROMPT)
+		if line != test.line || !errors.Is(err, test.err) {
+			t.Errorf("%q: expected %q (%v), got %q (%v)", test.keys, test.line, test.err, line, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+type terminalState struct {
+	termios syscall.Termios
+}
+
+func getTermios(fd uintptr) (syscall.Termios, error) {
+	var termios syscall.Termios
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, IOCTL_GET_TERMIOS, uintptr(unsafe.Pointer(&termios))); errno != 0 {
+		return termios, errno
+	}
+	return termios, nil
+}
+
+func setTermios(fd uintptr, termios syscall.Termios) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, IOCTL_SET_TERMIOS, uintptr(unsafe.Pointer(&termios))); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func isTerminal(fd uintptr) bool {
+	_, err := getTermios(fd)
+	return err == nil
+}
+
+// makeRaw switches the terminal to byte-at-a-time input without echo or
+// signal keys, keeping output post-processing so "\n" still moves to the
+// start of the next line.
+func makeRaw(fd uintptr) (*terminalState, error) {
+	termios, err := getTermios(fd)
+	if err != nil {
+		return nil, err
+	}
+	state := &terminalState{termios: termios}
+	termios.Iflag &^= syscall.BRKINT | syscall.ICRNL | syscall.INPCK | syscall.ISTRIP | syscall.IXON
+	termios.Cflag |= syscall.CS8
+	termios.Lflag &^= syscall.ECHO | syscall.ICANON | syscall.IEXTEN | syscall.ISIG
+	termios.Cc[syscall.VMIN] = 1
+	termios.Cc[syscall.VTIME] = 0
+	if err := setTermios(fd, termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+func restoreTerminal(fd uintptr, state *terminalState) error {
+	return setTermios(fd, state.termios)
+}
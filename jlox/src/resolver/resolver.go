@@ -64,6 +64,38 @@ func (resolver *Resolver) Resolve(statements []grammar.Statement) []grammar.LoxE
 	return resolver.Error
 }
 
+// ResolveExpression resolves an expression evaluated while env is the
+// current environment, such as a debugger watch, taking the names each
+// environment below the globals holds as declared.
+func (resolver *Resolver) ResolveExpression(env *runtime.Environment, expr grammar.Expression) []grammar.LoxError {
+	scopes := make([]map[string]bool, 0)
+	for ; env != nil && env.Parent != nil; env = env.Parent {
+		scope := make(map[string]bool)
+		for name := range env.Values {
+			scope[name] = true
+			if name == "this" && resolver.CurrentClass == NONE {
+				resolver.CurrentClass = CLASS
+			}
+			if name == "super" {
+				resolver.CurrentClass = SUBCLASS
+			}
+		}
+		scopes = append(scopes, scope)
+	}
+
+	resolver.beginScope()
+	for i := len(scopes) - 1; i >= 0; i-- {
+		resolver.Scopes.Push(scopes[i])
+	}
+	if err := resolver.resolveExpr(expr); err != nil {
+		resolver.Error = append(resolver.Error, err)
+	}
+	for i := 0; i <= len(scopes); i++ {
+		resolver.endScope()
+	}
+	return resolver.Error
+}
+
 func (resolver *Resolver) resolveStmts(statements []grammar.Statement) {
 	for _, stmt := range statements {
 		err := resolver.resolveStmt(stmt)
@@ -73,58 +105,59 @@ func (resolver *Resolver) resolveStmts(statements []grammar.Statement) {
 	}
 }
 
+// resolveStmt and resolveExpr skip the nil nodes the parser leaves in for
+// loops and in optional fields.
 func (resolver *Resolver) resolveStmt(stmt grammar.Statement) grammar.LoxError {
-	switch stmtType := stmt.(type) {
-	case grammar.BlockScopeStatement:
-		return resolver.blockStmt(stmtType)
-	case grammar.VariableDeclarationStatement:
-		return resolver.vararStmt(stmtType)
-	case grammar.FunctionDeclarationStatement:
-		return resolver.functionStmt(stmtType)
-	case grammar.ClassDeclarationStatement:
-		return resolver.classStmt(stmtType)
-	case grammar.ExpressionStatement:
-		return resolver.expressionStmt(stmtType)
-	case grammar.ConditionalStatement:
-		return resolver.conditionalStmt(stmtType)
-	case grammar.PrintStatement:
-		return resolver.printStmt(stmtType)
-	case grammar.ReturnStatement:
-		return resolver.returnStmt(stmtType)
-	case grammar.WhileLoopStatement:
-		return resolver.whileStmt(stmtType)
-	default:
+	if stmt == nil {
+		return nil
+	}
+	_, err := stmt.Accept(resolver)
+	return err
+}
+
+func (resolver *Resolver) resolveExpr(expr grammar.Expression) grammar.LoxError {
+	if expr == nil {
 		return nil
 	}
+	_, err := expr.Accept(resolver)
+	return err
+}
+
+func (resolver *Resolver) VisitImportStatement(stmt grammar.ImportStatement) (any, grammar.LoxError) {
+	err := resolver.declare(stmt.Alias)
+	resolver.define(stmt.Alias)
+	return nil, err
 }
 
-func (resolver *Resolver) blockStmt(stmt grammar.BlockScopeStatement) grammar.LoxError {
+func (resolver *Resolver) VisitExportStatement(stmt grammar.ExportStatement) (any, grammar.LoxError) {
+	if resolver.Scopes.Len() > 1 || resolver.CurrentFunction != NONE || resolver.CurrentClass != NONE {
+		return nil, ResolverError{Token: stmt.Keyword, Message: "Can only export from top-level code."}
+	}
+	return nil, resolver.resolveStmt(stmt.Declaration)
+}
+
+func (resolver *Resolver) VisitBlockScopeStatement(stmt grammar.BlockScopeStatement) (any, grammar.LoxError) {
 	resolver.beginScope()
 	resolver.resolveStmts(stmt.Statements)
 	resolver.endScope()
-	return nil
+	return nil, nil
 }
 
-func (resolver *Resolver) vararStmt(stmt grammar.VariableDeclarationStatement) grammar.LoxError {
+func (resolver *Resolver) VisitVariableDeclarationStatement(stmt grammar.VariableDeclarationStatement) (any, grammar.LoxError) {
 	err := resolver.declare(stmt.Name)
 	if err != nil {
-		return err
-	}
-	if stmt.Initializer != nil {
-		err := resolver.resolveStmt(stmt.Initializer)
-		if err != nil {
-			return err
-		}
+		return nil, err
 	}
+	err = resolver.resolveExpr(stmt.Initializer)
 	resolver.define(stmt.Name)
-	return err
+	return nil, err
 }
 
-func (resolver *Resolver) functionStmt(stmt grammar.FunctionDeclarationStatement) grammar.LoxError {
+func (resolver *Resolver) VisitFunctionDeclarationStatement(stmt grammar.FunctionDeclarationStatement) (any, grammar.LoxError) {
 	err := resolver.declare(stmt.Name)
 	resolver.define(stmt.Name)
 	resolver.resolveFunction(stmt, FUNCTION)
-	return err
+	return nil, err
 }
 
 func (resolver *Resolver) resolveFunction(function grammar.FunctionDeclarationStatement, functionType int) grammar.LoxError {
@@ -138,13 +171,13 @@ func (resolver *Resolver) resolveFunction(function grammar.FunctionDeclarationSt
 		}
 		resolver.define(param)
 	}
-	resolver.resolveStmt(function.Body)
+	resolver.resolveStmts(function.Body.Statements)
 	resolver.endScope()
 	resolver.CurrentFunction = enclosingFunction
 	return nil
 }
 
-func (resolver *Resolver) classStmt(class grammar.ClassDeclarationStatement) grammar.LoxError {
+func (resolver *Resolver) VisitClassDeclarationStatement(class grammar.ClassDeclarationStatement) (any, grammar.LoxError) {
 	enclosingClass := resolver.CurrentClass
 	resolver.CurrentClass = CLASS
 	resolver.declare(class.Name)
@@ -152,7 +185,7 @@ func (resolver *Resolver) classStmt(class grammar.ClassDeclarationStatement) gra
 	super, ok := class.Super.(grammar.VariableDeclaration)
 	if ok {
 		if class.Name.Lexeme == super.Name.Lexeme {
-			return ResolverError{Token: super.Name, Message: "A class can't inherit from itself."}
+			return nil, ResolverError{Token: super.Name, Message: "A class can't inherit from itself."}
 		}
 		resolver.CurrentClass = SUBCLASS
 		resolver.resolveExpr(super)
@@ -161,14 +194,14 @@ func (resolver *Resolver) classStmt(class grammar.ClassDeclarationStatement) gra
 		resolver.beginScope()
 		superScope, stackErr := resolver.Scopes.Peek()
 		if stackErr != nil {
-			return ResolverError{Token: class.Name, Message: fmt.Sprint(stackErr)}
+			return nil, ResolverError{Token: class.Name, Message: fmt.Sprint(stackErr)}
 		}
 		superScope["super"] = true
 	}
 	resolver.beginScope()
 	scope, stackErr := resolver.Scopes.Peek()
 	if stackErr != nil {
-		return ResolverError{Token: class.Name, Message: fmt.Sprint(stackErr)}
+		return nil, ResolverError{Token: class.Name, Message: fmt.Sprint(stackErr)}
 	}
 	scope["this"] = true
 	for _, method := range class.Methods {
@@ -183,127 +216,97 @@ func (resolver *Resolver) classStmt(class grammar.ClassDeclarationStatement) gra
 	}
 	resolver.endScope()
 	resolver.CurrentClass = enclosingClass
-	return nil
+	return nil, nil
 }
 
-func (resolver *Resolver) expressionStmt(expr grammar.ExpressionStatement) grammar.LoxError {
-	return resolver.resolveExpr(expr)
+func (resolver *Resolver) VisitExpressionStatement(stmt grammar.ExpressionStatement) (any, grammar.LoxError) {
+	return nil, resolver.resolveExpr(stmt.Expression)
 }
 
-func (resolver *Resolver) conditionalStmt(stmt grammar.ConditionalStatement) grammar.LoxError {
+func (resolver *Resolver) VisitConditionalStatement(stmt grammar.ConditionalStatement) (any, grammar.LoxError) {
 	err := resolver.resolveExpr(stmt.Condition)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	err = resolver.resolveStmt(stmt.ThenBranch)
 	if err != nil {
-		return err
-	}
-	if stmt.ElseBranch != nil {
-		err = resolver.resolveStmt(stmt.ElseBranch)
+		return nil, err
 	}
-	return err
+	return nil, resolver.resolveStmt(stmt.ElseBranch)
 }
 
-func (resolver *Resolver) printStmt(stmt grammar.PrintStatement) grammar.LoxError {
-	return resolver.resolveExpr(stmt.Value)
+func (resolver *Resolver) VisitPrintStatement(stmt grammar.PrintStatement) (any, grammar.LoxError) {
+	return nil, resolver.resolveExpr(stmt.Value)
 }
 
-func (resolver *Resolver) returnStmt(stmt grammar.ReturnStatement) grammar.LoxError {
+func (resolver *Resolver) VisitReturnStatement(stmt grammar.ReturnStatement) (any, grammar.LoxError) {
 	if resolver.CurrentFunction == NONE {
-		return ResolverError{Token: stmt.Keyword, Message: "Can't return from top-level code."}
+		return nil, ResolverError{Token: stmt.Keyword, Message: "Can't return from top-level code."}
 	}
 
 	if stmt.Expression != nil {
 		if resolver.CurrentFunction == INITIALIZER {
-			return ResolverError{Token: stmt.Keyword, Message: "Can't return a value from constructor"}
+			return nil, ResolverError{Token: stmt.Keyword, Message: "Can't return a value from constructor"}
 		}
-		return resolver.resolveExpr(stmt.Expression)
+		return nil, resolver.resolveExpr(stmt.Expression)
 	}
-	return nil
+	return nil, nil
 }
 
-func (resolver *Resolver) whileStmt(stmt grammar.WhileLoopStatement) grammar.LoxError {
+func (resolver *Resolver) VisitWhileLoopStatement(stmt grammar.WhileLoopStatement) (any, grammar.LoxError) {
 	err := resolver.resolveExpr(stmt.Condition)
 	if err != nil {
-		return err
-	}
-	return resolver.resolveStmt(stmt.Body)
-}
-
-func (resolver *Resolver) resolveExpr(expr grammar.Expression) grammar.LoxError {
-	switch exprType := expr.(type) {
-	case grammar.VariableDeclaration:
-		return resolver.varExpr(exprType)
-	case grammar.AssignmentExpression:
-		return resolver.assignmentExpr(exprType)
-	case grammar.BinaryExpression:
-		return resolver.binaryExpr(exprType)
-	case grammar.CallExpression:
-		return resolver.callExpr(exprType)
-	case grammar.PropertyAccessExpression:
-		return resolver.propAccessExpr(exprType)
-	case grammar.PropertyAssignmentExpression:
-		return resolver.propAssignmentExpr(exprType)
-	case grammar.SelfReferenceExpression:
-		return resolver.selfReferenceExpr(exprType)
-	case grammar.BaseClassCallExpression:
-		return resolver.baseClassCallExpr(exprType)
-	case grammar.GroupingExpression:
-		return resolver.groupExpr(exprType)
-	case grammar.LiteralExpression:
-		return resolver.literalExpr()
-	case grammar.UnaryExpression:
-		return resolver.unaryExpr(exprType)
-	default:
-		return nil
+		return nil, err
 	}
+	return nil, resolver.resolveStmt(stmt.Body)
 }
 
-func (resolver *Resolver) baseClassCallExpr(expr grammar.BaseClassCallExpression) grammar.LoxError {
+func (resolver *Resolver) VisitBaseClassCallExpression(expr grammar.BaseClassCallExpression) (any, grammar.LoxError) {
 	if resolver.CurrentClass == NONE {
-		return ResolverError{Token: expr.Keyword, Message: "Can't use 'super' outside of a class."}
+		return nil, ResolverError{Token: expr.Keyword, Message: "Can't use 'super' outside of a class."}
 	} else if resolver.CurrentClass != SUBCLASS {
-		return ResolverError{Token: expr.Keyword, Message: "Can't use 'super' in a class with no superclass."}
+		return nil, ResolverError{Token: expr.Keyword, Message: "Can't use 'super' in a class with no superclass."}
 	}
-	return resolver.resolveLocal(expr, expr.Keyword)
+	return nil, resolver.resolveLocal(expr, expr.Keyword)
 }
 
-func (resolver *Resolver) selfReferenceExpr(expr grammar.SelfReferenceExpression) grammar.LoxError {
+func (resolver *Resolver) VisitSelfReferenceExpression(expr grammar.SelfReferenceExpression) (any, grammar.LoxError) {
 	if resolver.CurrentClass == NONE {
-		return runtime.RuntimeError{Token: expr.Keyword, Message: "Can't use 'this' outside of a class"}
+		return nil, runtime.RuntimeError{Token: expr.Keyword, Message: "Can't use 'this' outside of a class"}
 	}
-	return resolver.resolveLocal(expr, expr.Keyword)
+	return nil, resolver.resolveLocal(expr, expr.Keyword)
 }
 
-func (resolver *Resolver) propAssignmentExpr(expr grammar.PropertyAssignmentExpression) grammar.LoxError {
+func (resolver *Resolver) VisitPropertyAssignmentExpression(expr grammar.PropertyAssignmentExpression) (any, grammar.LoxError) {
 	err := resolver.resolveExpr(expr.Value)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = resolver.resolveExpr(expr.Object)
-	return err
+	return nil, resolver.resolveExpr(expr.Object)
 }
 
-func (resolver *Resolver) propAccessExpr(expr grammar.PropertyAccessExpression) grammar.LoxError {
-	return resolver.resolveExpr(expr.Object)
+func (resolver *Resolver) VisitPropertyAccessExpression(expr grammar.PropertyAccessExpression) (any, grammar.LoxError) {
+	return nil, resolver.resolveExpr(expr.Object)
 }
 
-func (resolver *Resolver) varExpr(expr grammar.VariableDeclaration) grammar.LoxError {
+func (resolver *Resolver) VisitVariableDeclaration(expr grammar.VariableDeclaration) (any, grammar.LoxError) {
 	scope, err := resolver.Scopes.Peek()
 	if err != nil {
-		return ResolverError{Token: expr.Name, Message: fmt.Sprint(err)}
+		return nil, ResolverError{Token: expr.Name, Message: fmt.Sprint(err)}
 	}
 	lookup := fmt.Sprintf("%s", expr.Name.Lexeme)
 	if val, ok := scope[lookup]; !resolver.Scopes.IsEmpty() && ok && !val {
-		return ResolverError{Token: expr.Name, Message: "Can't read local variable in its own initializer."}
+		return nil, ResolverError{Token: expr.Name, Message: "Can't read local variable in its own initializer."}
 	}
 	resolver.resolveLocal(expr, expr.Name)
-	return nil
+	return nil, nil
 }
 
+// resolveLocal records how many scopes out a name was declared. Names found
+// only in the outermost scope, the script's own, are left to the interpreter
+// to look up among the globals.
 func (resolver *Resolver) resolveLocal(expr grammar.Expression, name grammar.Token) grammar.LoxError {
-	for i := resolver.Scopes.Len() - 1; i >= 0; i-- {
+	for i := resolver.Scopes.Len() - 1; i >= 1; i-- {
 		scope, err := resolver.Scopes.Get(i)
 		if err != nil {
 			return ResolverError{Token: name, Message: fmt.Sprint(err)}
@@ -314,43 +317,51 @@ func (resolver *Resolver) resolveLocal(expr grammar.Expression, name grammar.Tok
 			return nil
 		}
 	}
+	resolver.Interpreter.Forget(expr)
 	return nil
 }
 
-func (resolver *Resolver) assignmentExpr(expr grammar.AssignmentExpression) grammar.LoxError {
+func (resolver *Resolver) VisitAssignmentExpression(expr grammar.AssignmentExpression) (any, grammar.LoxError) {
 	err := resolver.resolveExpr(expr.Value)
 	resolver.resolveLocal(expr, expr.Name)
-	return err
+	return nil, err
 }
 
-func (resolver *Resolver) binaryExpr(expr grammar.BinaryExpression) grammar.LoxError {
+func (resolver *Resolver) VisitBinaryExpression(expr grammar.BinaryExpression) (any, grammar.LoxError) {
 	err := resolver.resolveExpr(expr.Left)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	err = resolver.resolveExpr(expr.Right)
-	return err
+	return nil, resolver.resolveExpr(expr.Right)
+}
+
+func (resolver *Resolver) VisitLogicExpression(expr grammar.LogicExpression) (any, grammar.LoxError) {
+	err := resolver.resolveExpr(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	return nil, resolver.resolveExpr(expr.Right)
 }
 
-func (resolver *Resolver) callExpr(expr grammar.CallExpression) grammar.LoxError {
+func (resolver *Resolver) VisitCallExpression(expr grammar.CallExpression) (any, grammar.LoxError) {
 	err := resolver.resolveExpr(expr.Callee)
 	for _, argument := range expr.Arguments {
 		err := resolver.resolveExpr(argument)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return err
+	return nil, err
 }
 
-func (resolver *Resolver) groupExpr(expr grammar.GroupingExpression) grammar.LoxError {
-	return resolver.resolveExpr(expr.Expression)
+func (resolver *Resolver) VisitGroupingExpression(expr grammar.GroupingExpression) (any, grammar.LoxError) {
+	return nil, resolver.resolveExpr(expr.Expression)
 }
 
-func (resolver *Resolver) literalExpr() grammar.LoxError {
-	return nil
+func (resolver *Resolver) VisitLiteralExpression(expr grammar.LiteralExpression) (any, grammar.LoxError) {
+	return nil, nil
 }
 
-func (resolver *Resolver) unaryExpr(expr grammar.UnaryExpression) grammar.LoxError {
-	return resolver.resolveExpr(expr.Right)
+func (resolver *Resolver) VisitUnaryExpression(expr grammar.UnaryExpression) (any, grammar.LoxError) {
+	return nil, resolver.resolveExpr(expr.Right)
 }
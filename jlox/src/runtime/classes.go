@@ -16,11 +16,16 @@ type LoxClass struct {
 }
 
 func (class *LoxClass) Call(interpreter Interpreter, args []any) (any, grammar.LoxError) {
+	if err := interpreter.allocate(class.Name, INSTANCE_SIZE); err != nil {
+		return nil, err
+	}
 	instance := LoxClassInstance{Class: class}
 	initMethod := instance.Class.FindMethod(CONSTRUCTOR)
 	if init, ok := initMethod.(LoxFunction); ok {
-		init.Bind(instance)
-		init.Call(interpreter, args)
+		bound := init.Bind(instance)
+		if _, err := bound.Call(interpreter, args); err != nil {
+			return nil, err
+		}
 	}
 	return instance, nil
 }
@@ -11,6 +11,18 @@ type Environment struct {
 	Parent *Environment
 }
 
+func (env *Environment) Define(name string, value any) {
+	if env.Values == nil {
+		env.Values = make(map[string]any)
+	}
+	env.Values[name] = value
+}
+
+func (env *Environment) Get(name string) (any, bool) {
+	value, ok := env.Values[name]
+	return value, ok
+}
+
 func (env *Environment) defineEnvValue(name grammar.Token, value any) {
 	field := fmt.Sprintf("%s", name.Lexeme)
 	env.Values[field] = value
@@ -45,13 +57,12 @@ func (env *Environment) getEnvValueAt(distance int, name grammar.Token) (any, gr
 }
 
 func (env *Environment) assignEnvValueAt(distance int, name grammar.Token, value any) {
-	varName := fmt.Sprintf("%s", name.Lexeme)
-	env.getAncestor(distance).Values[varName] = value
+	env.getAncestor(distance).Define(fmt.Sprintf("%s", name.Lexeme), value)
 }
 
 func (env *Environment) getAncestor(distance int) *Environment {
 	environment := env
-	for i := 0; i < distance; i++ {
+	for i := 0; i < distance && environment.Parent != nil; i++ {
 		environment = environment.Parent
 	}
 	return environment
@@ -18,3 +18,29 @@ func (e RuntimeError) Print() {
 func (e RuntimeError) Error() string {
 	return fmt.Sprintf("[%v]: Runtime error: %s", e.Token, e.Message)
 }
+
+type NativeError struct {
+	Message string
+}
+
+func (e NativeError) Print() {
+	fmt.Printf("Native error: %s", e.Message)
+}
+
+func (e NativeError) Error() string {
+	return fmt.Sprintf("Native error: %s", e.Message)
+}
+
+// Return unwinds the statements of a function body like an error would,
+// carrying the returned value up to LoxFunction.Call.
+type Return struct {
+	Value any
+}
+
+func (r Return) Print() {
+	fmt.Print(r.Error())
+}
+
+func (r Return) Error() string {
+	return "Return outside of a function."
+}
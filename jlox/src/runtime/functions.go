@@ -13,27 +13,46 @@ type LoxFunction struct {
 }
 
 func (function *LoxFunction) Call(interpreter Interpreter, arguments []any) (any, grammar.LoxError) {
-	env := Environment{Parent: function.Closure, Values: function.Closure.Values}
+	if interpreter.Hook != nil {
+		interpreter.Hook.EnterFunction(&interpreter, function)
+		defer interpreter.Hook.ExitFunction(&interpreter, function)
+	}
+
+	if err := interpreter.allocate(function.Declaration.Name, ENVIRONMENT_SIZE); err != nil {
+		return nil, err
+	}
+	defer interpreter.release(ENVIRONMENT_SIZE)
+	env := Environment{Parent: function.Closure, Values: make(map[string]any)}
 	for i := 0; i < len(function.Declaration.Params); i++ {
 		env.defineEnvValue(function.Declaration.Params[i], arguments[i])
 	}
 
-	val, err := interpreter.executeBlock(function.Declaration.Body.Statements, env)
+	var value any
+	_, err := interpreter.executeBlock(function.Declaration.Body.Statements, env)
+	if ret, ok := err.(Return); ok {
+		value, err = ret.Value, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
 	if function.Initializer {
 		return env.Parent.getEnvValueAt(0, grammar.Token{TokenType: grammar.THIS, Lexeme: "this"})
 	}
 
-	return val, err
+	return value, nil
 }
 
 func (function *LoxFunction) GetAirity() int {
 	return len(function.Declaration.Params)
 }
 
+// Bind returns the method with this defined in a scope of its own between
+// the method and the class it was declared in.
 func (function *LoxFunction) Bind(instance LoxClassInstance) LoxFunction {
-	function.Closure.defineEnvValue(grammar.Token{TokenType: grammar.THIS, Lexeme: "this"}, instance)
-	return *function
+	env := Environment{Values: make(map[string]any), Parent: function.Closure}
+	env.defineEnvValue(grammar.Token{TokenType: grammar.THIS, Lexeme: "this"}, instance)
+	return LoxFunction{Declaration: function.Declaration, Closure: &env, Initializer: function.Initializer}
 }
 
 func (function *LoxFunction) ToString() string {
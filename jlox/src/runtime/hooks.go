@@ -0,0 +1,65 @@
+package runtime
+
+import "github.com/DrEmbryo/jlox/src/grammar"
+
+// Hook observes the interpreter while it runs. Hooks are called on the
+// goroutine running the program, so a debugger can pause execution by
+// blocking inside them. Returning an error from Statement stops the program.
+// EnterFunction and ExitFunction see every *LoxFunction and *NativeCall.
+// Branch reports the outcome of every if and loop condition and whether the
+// right operand of a logical operator was evaluated.
+type Hook interface {
+	Statement(interpreter *Interpreter, stmt grammar.Statement) grammar.LoxError
+	EnterFunction(interpreter *Interpreter, function LoxCallable)
+	ExitFunction(interpreter *Interpreter, function LoxCallable)
+	Branch(interpreter *Interpreter, node any, taken bool)
+}
+
+// Hooks runs several hooks in order, stopping at the first statement error.
+type Hooks []Hook
+
+func (hooks Hooks) Statement(interpreter *Interpreter, stmt grammar.Statement) grammar.LoxError {
+	for _, hook := range hooks {
+		if err := hook.Statement(interpreter, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (hooks Hooks) EnterFunction(interpreter *Interpreter, function LoxCallable) {
+	for _, hook := range hooks {
+		hook.EnterFunction(interpreter, function)
+	}
+}
+
+func (hooks Hooks) ExitFunction(interpreter *Interpreter, function LoxCallable) {
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hooks[i].ExitFunction(interpreter, function)
+	}
+}
+
+func (hooks Hooks) Branch(interpreter *Interpreter, node any, taken bool) {
+	for _, hook := range hooks {
+		hook.Branch(interpreter, node, taken)
+	}
+}
+
+func (interpreter *Interpreter) Globals() *Environment {
+	if interpreter.globalEnv == nil {
+		return &interpreter.Env
+	}
+	return interpreter.globalEnv
+}
+
+func (interpreter *Interpreter) branch(node any, taken bool) {
+	if interpreter.Hook != nil {
+		interpreter.Hook.Branch(interpreter, node, taken)
+	}
+}
+
+func (interpreter *Interpreter) halt() {
+	if interpreter.execution != nil {
+		interpreter.execution.halted = true
+	}
+}
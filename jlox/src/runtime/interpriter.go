@@ -1,7 +1,9 @@
 package runtime
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/DrEmbryo/jlox/src/grammar"
@@ -11,17 +13,26 @@ type Interpreter struct {
 	Env       Environment
 	globalEnv *Environment
 	LocalEnv  map[any]int
-}
-
-func (interpreter *Interpreter) literalExpr(expr grammar.LiteralExpression) (any, grammar.LoxError) {
+	Modules   ModuleLoader
+	Limits    Limits
+	Stdout    io.Writer
+	Stdin     io.Reader
+	FS        FileSystem
+	exports   []grammar.Token
+	Hook      Hook
+	execution *Execution
+	usage     Usage
+}
+
+func (interpreter *Interpreter) VisitLiteralExpression(expr grammar.LiteralExpression) (any, grammar.LoxError) {
 	return expr.Literal, nil
 }
 
-func (interpreter *Interpreter) groupingExpr(expr grammar.GroupingExpression) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitGroupingExpression(expr grammar.GroupingExpression) (any, grammar.LoxError) {
 	return interpreter.evaluate(expr.Expression)
 }
 
-func (interpreter *Interpreter) unaryExpr(expr grammar.UnaryExpression) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitUnaryExpression(expr grammar.UnaryExpression) (any, grammar.LoxError) {
 	right, err := interpreter.evaluate(expr.Right)
 	if err != nil {
 		return nil, err
@@ -40,7 +51,7 @@ func (interpreter *Interpreter) unaryExpr(expr grammar.UnaryExpression) (any, gr
 	return nil, err
 }
 
-func (interpreter *Interpreter) binaryExpr(expr grammar.BinaryExpression) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitBinaryExpression(expr grammar.BinaryExpression) (any, grammar.LoxError) {
 	left, err := interpreter.evaluate(expr.Left)
 	if err != nil {
 		return nil, err
@@ -62,7 +73,11 @@ func (interpreter *Interpreter) binaryExpr(expr grammar.BinaryExpression) (any,
 		if checkTypeEquality(left, right) {
 			switch leftType := left.(type) {
 			case string:
-				return fmt.Sprintf("%s%s", left, right), nil
+				value := fmt.Sprintf("%s%s", left, right)
+				if err := interpreter.allocate(expr.Operator, STRING_HEADER_SIZE+len(value)); err != nil {
+					return nil, err
+				}
+				return value, nil
 			case float64:
 				return leftType + right.(float64), nil
 			}
@@ -119,42 +134,34 @@ func (interpreter *Interpreter) binaryExpr(expr grammar.BinaryExpression) (any,
 	return nil, err
 }
 
-func (interpreter *Interpreter) logicalExpr(expr grammar.LogicExpression) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitLogicExpression(expr grammar.LogicExpression) (any, grammar.LoxError) {
 	left, err := interpreter.evaluate(expr.Left)
 	if err != nil {
 		return nil, err
 	}
 
-	if expr.Operator.TokenType == grammar.OR {
-		if castToBool(left) {
-			return left, nil
-		} else if !castToBool(left) {
-			return left, nil
-		}
+	if castToBool(left) == (expr.Operator.TokenType == grammar.OR) {
+		interpreter.branch(expr, false)
+		return left, nil
 	}
+	interpreter.branch(expr, true)
 	return interpreter.evaluate(expr.Right)
 }
 
-func (interpreter *Interpreter) callExpr(expr grammar.CallExpression) (any, grammar.LoxError) {
-	var function LoxCallable
+func (interpreter *Interpreter) VisitCallExpression(expr grammar.CallExpression) (any, grammar.LoxError) {
 	callee, err := interpreter.evaluate(expr.Callee)
 	if err != nil {
 		return nil, err
 	}
 
-	switch calleeType := callee.(type) {
-	case LoxFunction:
-		function = &calleeType
-	case NativeCall:
-		function = &calleeType
-	case LoxClass:
-		function = &calleeType
-	default:
+	function, ok := toCallable(callee)
+	if !ok {
 		return nil, RuntimeError{Token: expr.Paren, Message: "Calls available only for functions and classes"}
 	}
 
-	if len(expr.Arguments) != function.GetAirity() {
-		return nil, RuntimeError{Token: expr.Paren, Message: fmt.Sprintf("Expect %v arguments but got %v.", function.GetAirity(), len(expr.Arguments))}
+	err = checkAirity(function, expr.Paren, len(expr.Arguments))
+	if err != nil {
+		return nil, err
 	}
 
 	arguments := make([]any, 0)
@@ -166,10 +173,45 @@ func (interpreter *Interpreter) callExpr(expr grammar.CallExpression) (any, gram
 		arguments = append(arguments, arg)
 	}
 
-	return function.Call(*interpreter, arguments)
+	return interpreter.invoke(function, expr.Paren, arguments)
+}
+
+func (interpreter *Interpreter) invoke(function LoxCallable, paren grammar.Token, arguments []any) (any, grammar.LoxError) {
+	err := interpreter.enterCall(paren)
+	if err != nil {
+		return nil, err
+	}
+	defer interpreter.exitCall()
+
+	value, err := function.Call(*interpreter, arguments)
+	if nativeErr, ok := err.(NativeError); ok {
+		return nil, RuntimeError{Token: paren, Message: nativeErr.Message}
+	}
+	return value, err
+}
+
+func (interpreter *Interpreter) CallContext(ctx context.Context, callee any, arguments []any) (any, grammar.LoxError) {
+	end := interpreter.Begin(ctx)
+	defer end()
+	return interpreter.Call(callee, arguments)
 }
 
-func (interpreter *Interpreter) propAccessExpr(expr grammar.PropertyAccessExpression) (any, grammar.LoxError) {
+func (interpreter *Interpreter) Call(callee any, arguments []any) (any, grammar.LoxError) {
+	token := grammar.Token{TokenType: grammar.IDENTIFIER, Lexeme: Stringify(callee)}
+	function, ok := toCallable(callee)
+	if !ok {
+		return nil, RuntimeError{Token: token, Message: "Calls available only for functions and classes"}
+	}
+
+	err := checkAirity(function, token, len(arguments))
+	if err != nil {
+		return nil, err
+	}
+
+	return interpreter.invoke(function, token, arguments)
+}
+
+func (interpreter *Interpreter) VisitPropertyAccessExpression(expr grammar.PropertyAccessExpression) (any, grammar.LoxError) {
 	object, err := interpreter.evaluate(expr.Object)
 	if err != nil {
 		return nil, err
@@ -179,15 +221,31 @@ func (interpreter *Interpreter) propAccessExpr(expr grammar.PropertyAccessExpres
 		return classInstance.GetProperty(expr.Name)
 	}
 
+	if module, ok := object.(LoxModule); ok {
+		return module.GetProperty(expr.Name)
+	}
+
+	if loxObject, ok := object.(LoxObject); ok {
+		return loxObject.GetProperty(expr.Name)
+	}
+
 	return nil, RuntimeError{Token: expr.Name, Message: "Only instances have prooperties."}
 }
 
-func (interpreter *Interpreter) propAssignmentExpr(expr grammar.PropertyAssignmentExpression) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitPropertyAssignmentExpression(expr grammar.PropertyAssignmentExpression) (any, grammar.LoxError) {
 	object, err := interpreter.evaluate(expr.Object)
 	if err != nil {
 		return nil, err
 	}
 
+	if loxObject, ok := object.(LoxObject); ok {
+		value, err := interpreter.evaluate(expr.Value)
+		if err != nil {
+			return nil, err
+		}
+		return value, loxObject.SetProperty(expr.Name, value)
+	}
+
 	if _, ok := object.(LoxClassInstance); !ok {
 		return nil, RuntimeError{Token: expr.Name, Message: "Only instances have fiellds."}
 	}
@@ -203,12 +261,12 @@ func (interpreter *Interpreter) propAssignmentExpr(expr grammar.PropertyAssignme
 	return value, err
 }
 
-func (interpreter *Interpreter) selfReferenceExpr(expr grammar.SelfReferenceExpression) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitSelfReferenceExpression(expr grammar.SelfReferenceExpression) (any, grammar.LoxError) {
 	return interpreter.lookUpVariable(expr.Keyword, expr)
 }
 
-func (interpreter *Interpreter) baseClassCallExpr(expr grammar.BaseClassCallExpression) (any, grammar.LoxError) {
-	distance := interpreter.LocalEnv[expr]
+func (interpreter *Interpreter) VisitBaseClassCallExpression(expr grammar.BaseClassCallExpression) (any, grammar.LoxError) {
+	distance := interpreter.LocalEnv[localKey(expr)]
 	superclass, err := interpreter.Env.getEnvValueAt(distance, grammar.Token{TokenType: grammar.SUPER, Lexeme: "super"})
 	if err != nil {
 		return nil, err
@@ -217,121 +275,145 @@ func (interpreter *Interpreter) baseClassCallExpr(expr grammar.BaseClassCallExpr
 	if err != nil {
 		return nil, err
 	}
-	super := superclass.(LoxClass)
+	super, ok := superclass.(LoxClass)
+	if !ok {
+		return nil, RuntimeError{Token: expr.Keyword, Message: "Superclass must be a class."}
+	}
+	this, ok := instance.(LoxClassInstance)
+	if !ok {
+		return nil, RuntimeError{Token: expr.Keyword, Message: "Can't use 'super' outside of a method."}
+	}
 	method, ok := super.FindMethod(fmt.Sprintf("%v", expr.Method.Lexeme)).(LoxFunction)
 	if !ok {
 		return nil, RuntimeError{Token: expr.Keyword, Message: fmt.Sprintf("Undefined property '%v'.", expr.Method.Lexeme)}
 	}
-	return method.Bind(instance.(LoxClassInstance)), nil
+	return method.Bind(this), nil
 }
 
 func (interpreter *Interpreter) evaluate(expr grammar.Expression) (any, grammar.LoxError) {
-	switch exprType := expr.(type) {
-	case grammar.GroupingExpression:
-		return interpreter.groupingExpr(exprType)
-	case grammar.UnaryExpression:
-		return interpreter.unaryExpr(exprType)
-	case grammar.BinaryExpression:
-		return interpreter.binaryExpr(exprType)
-	case grammar.VariableDeclaration:
-		return interpreter.varExpr(exprType)
-	case grammar.AssignmentExpression:
-		return interpreter.assignmentExpr(exprType)
-	case grammar.LogicExpression:
-		return interpreter.logicalExpr(exprType)
-	case grammar.CallExpression:
-		return interpreter.callExpr(exprType)
-	case grammar.PropertyAccessExpression:
-		return interpreter.propAccessExpr(exprType)
-	case grammar.PropertyAssignmentExpression:
-		return interpreter.propAssignmentExpr(exprType)
-	case grammar.SelfReferenceExpression:
-		return interpreter.selfReferenceExpr(exprType)
-	case grammar.BaseClassCallExpression:
-		return interpreter.baseClassCallExpr(exprType)
-	case grammar.LiteralExpression:
-		return interpreter.literalExpr(exprType)
-	default:
-		fmt.Printf("%T", exprType)
+	if expr == nil {
 		return nil, nil
 	}
+	return expr.Accept(interpreter)
 }
 
-func (interpreter *Interpreter) printStmt(stmt grammar.PrintStatement) grammar.LoxError {
+func (interpreter *Interpreter) VisitPrintStatement(stmt grammar.PrintStatement) (any, grammar.LoxError) {
 	value, err := interpreter.evaluate(stmt.Value)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	fmt.Fprintln(interpreter.output(), Stringify(value))
+	return nil, err
+}
+
+func Stringify(value any) string {
 	switch valueType := value.(type) {
 	case LoxClassInstance:
-		fmt.Println(valueType.ToString())
+		return valueType.ToString()
 	case LoxClass:
-		fmt.Println(valueType.ToString())
+		return valueType.ToString()
 	case LoxFunction:
-		fmt.Println(valueType.ToString())
+		return valueType.ToString()
+	case NativeCall:
+		return valueType.ToString()
+	case LoxModule:
+		return valueType.ToString()
+	case LoxList:
+		return valueType.ToString()
+	case LoxObject:
+		return valueType.ToString()
 	default:
-		fmt.Println(valueType)
+		return fmt.Sprint(valueType)
 	}
-
-	return err
 }
 
-func (interpreter *Interpreter) expressionStmt(stmt grammar.ExpressionStatement) grammar.LoxError {
+func (interpreter *Interpreter) VisitExpressionStatement(stmt grammar.ExpressionStatement) (any, grammar.LoxError) {
 	_, err := interpreter.evaluate(stmt.Expression)
-	return err
+	return nil, err
 }
 
-func (interpreter *Interpreter) whileStmt(stmt grammar.WhileLoopStatement) grammar.LoxError {
-	expr, err := interpreter.evaluate(stmt.Condition)
-	if err != nil {
-		return err
-	}
-
-	for castToBool(expr) {
-		expr, _ = interpreter.evaluate(stmt.Condition)
-		_, err = interpreter.execute(stmt.Body)
+func (interpreter *Interpreter) VisitWhileLoopStatement(stmt grammar.WhileLoopStatement) (any, grammar.LoxError) {
+	for {
+		condition, err := interpreter.evaluate(stmt.Condition)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		interpreter.branch(stmt, castToBool(condition))
+		if !castToBool(condition) {
+			return nil, nil
+		}
+		if _, err := interpreter.execute(stmt.Body); err != nil {
+			return nil, err
 		}
 	}
-	return err
 }
 
 func (interpreter *Interpreter) execute(stmt grammar.Statement) (any, grammar.LoxError) {
-	switch stmtType := stmt.(type) {
-	case grammar.PrintStatement:
-		return nil, interpreter.printStmt(stmtType)
-	case grammar.ExpressionStatement:
-		return nil, interpreter.expressionStmt(stmtType)
-	case grammar.VariableDeclarationStatement:
-		return nil, interpreter.varStmt(stmtType)
-	case grammar.BlockScopeStatement:
-		return interpreter.blockStmt(stmtType)
-	case grammar.ConditionalStatement:
-		return nil, interpreter.conditionalStmt(stmtType)
-	case grammar.WhileLoopStatement:
-		return nil, interpreter.whileStmt(stmtType)
+	if err := interpreter.tick(stmt); err != nil {
+		return nil, err
+	}
+	if interpreter.Hook != nil && stmt != nil {
+		if err := interpreter.Hook.Statement(interpreter, stmt); err != nil {
+			interpreter.halt()
+			return nil, err
+		}
+	}
+
+	if stmt == nil {
+		return nil, nil
+	}
+	return stmt.Accept(interpreter)
+}
+
+func (interpreter *Interpreter) VisitImportStatement(stmt grammar.ImportStatement) (any, grammar.LoxError) {
+	if interpreter.Modules == nil {
+		return nil, RuntimeError{Token: stmt.Keyword, Message: "Modules are not available in this environment."}
+	}
+	module, err := interpreter.Modules.Load(stmt.Path, interpreter.execution)
+	if err != nil {
+		return nil, err
+	}
+	interpreter.Env.defineEnvValue(stmt.Alias, module)
+	return nil, nil
+}
+
+func (interpreter *Interpreter) VisitExportStatement(stmt grammar.ExportStatement) (any, grammar.LoxError) {
+	value, err := interpreter.execute(stmt.Declaration)
+	if err != nil {
+		return nil, err
+	}
+	switch declaration := stmt.Declaration.(type) {
 	case grammar.FunctionDeclarationStatement:
-		return interpreter.functionDeclarationStmt(stmtType)
+		interpreter.exports = append(interpreter.exports, declaration.Name)
 	case grammar.ClassDeclarationStatement:
-		return interpreter.classDeclarationStmt(stmtType)
-	case grammar.ReturnStatement:
-		return interpreter.returnStmt(stmtType)
-	default:
-		return nil, nil
+		interpreter.exports = append(interpreter.exports, declaration.Name)
+	case grammar.VariableDeclarationStatement:
+		interpreter.exports = append(interpreter.exports, declaration.Name)
 	}
+	return value, nil
 }
 
-func (interpreter *Interpreter) functionDeclarationStmt(stmt grammar.FunctionDeclarationStatement) (any, grammar.LoxError) {
-	function := LoxFunction{Declaration: stmt, Closure: &interpreter.Env, Initializer: false}
+func (interpreter *Interpreter) Exports() map[string]any {
+	exports := make(map[string]any)
+	for _, name := range interpreter.exports {
+		value, err := interpreter.globalEnv.getEnvValue(name)
+		if err == nil {
+			exports[fmt.Sprintf("%s", name.Lexeme)] = value
+		}
+	}
+	return exports
+}
+
+func (interpreter *Interpreter) VisitFunctionDeclarationStatement(stmt grammar.FunctionDeclarationStatement) (any, grammar.LoxError) {
+	closure := interpreter.Env
+	function := LoxFunction{Declaration: stmt, Closure: &closure, Initializer: false}
 	interpreter.Env.defineEnvValue(stmt.Name, function)
 	return nil, nil
 }
 
-func (interpreter *Interpreter) classDeclarationStmt(stmt grammar.ClassDeclarationStatement) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitClassDeclarationStatement(stmt grammar.ClassDeclarationStatement) (any, grammar.LoxError) {
 	var superclass any = nil
-	var enclosingEnv Environment
 	super, ok := stmt.Super.(grammar.VariableDeclaration)
 	if ok {
 		evalSuper, err := interpreter.evaluate(super)
@@ -345,50 +427,60 @@ func (interpreter *Interpreter) classDeclarationStmt(stmt grammar.ClassDeclarati
 		superclass = evalSuper
 	}
 
+	interpreter.Env.defineEnvValue(stmt.Name, nil)
+	closure := interpreter.Env
 	if stmt.Super != nil {
-		enclosingEnv = Environment{Parent: interpreter.Env.Parent, Values: interpreter.Env.Values}
-		interpreter.Env.defineEnvValue(grammar.Token{TokenType: grammar.SUPER, Lexeme: "super"}, superclass)
+		enclosing := closure
+		closure = Environment{Values: make(map[string]any), Parent: &enclosing}
+		closure.defineEnvValue(grammar.Token{TokenType: grammar.SUPER, Lexeme: "super"}, superclass)
 	}
 
-	interpreter.Env.defineEnvValue(stmt.Name, nil)
 	methods := make(map[string]LoxFunction)
 	for _, method := range stmt.Methods {
 		lookup := fmt.Sprintf("%s", method.Name.Lexeme)
-		methods[lookup] = LoxFunction{Closure: &interpreter.Env, Declaration: method, Initializer: lookup == CONSTRUCTOR}
+		methods[lookup] = LoxFunction{Closure: &closure, Declaration: method, Initializer: lookup == CONSTRUCTOR}
 	}
 
 	interpreter.Env.defineEnvValue(stmt.Name, LoxClass{Name: stmt.Name, Methods: methods, Fields: make(map[any]any), Super: superclass})
-	if stmt.Super != nil {
-		interpreter.Env = enclosingEnv
-	}
 	return nil, nil
 }
 
-func (interpreter *Interpreter) returnStmt(stmt grammar.ReturnStatement) (any, grammar.LoxError) {
-	return interpreter.evaluate(stmt.Expression)
+func (interpreter *Interpreter) VisitReturnStatement(stmt grammar.ReturnStatement) (any, grammar.LoxError) {
+	value, err := interpreter.evaluate(stmt.Expression)
+	if err != nil {
+		return nil, err
+	}
+	return nil, Return{Value: value}
 }
 
-func (interpreter *Interpreter) conditionalStmt(stmt grammar.ConditionalStatement) grammar.LoxError {
+func (interpreter *Interpreter) VisitConditionalStatement(stmt grammar.ConditionalStatement) (any, grammar.LoxError) {
 	condition, err := interpreter.evaluate(stmt.Condition)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	interpreter.branch(stmt, castToBool(condition))
 
 	if castToBool(condition) {
 		_, err := interpreter.execute(stmt.ThenBranch)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	} else if stmt.ElseBranch != nil {
 		_, err := interpreter.execute(stmt.ElseBranch)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return err
+	return nil, err
 }
 
-func (interpreter *Interpreter) blockStmt(stmt grammar.BlockScopeStatement) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitBlockScopeStatement(stmt grammar.BlockScopeStatement) (any, grammar.LoxError) {
+	token, _ := grammar.FirstToken(stmt)
+	if err := interpreter.allocate(token, ENVIRONMENT_SIZE); err != nil {
+		return nil, err
+	}
+	defer interpreter.release(ENVIRONMENT_SIZE)
+
 	parentEnv := interpreter.Env
 	env := Environment{Values: make(map[string]any), Parent: &parentEnv}
 	return interpreter.executeBlock(stmt.Statements, env)
@@ -401,6 +493,9 @@ func (interpreter *Interpreter) executeBlock(stmts []grammar.Statement, env Envi
 	interpreter.Env = env
 	for _, stmt := range stmts {
 		value, err = interpreter.execute(stmt)
+		if err != nil {
+			break
+		}
 	}
 
 	interpreter.Env = parentEnv
@@ -408,8 +503,18 @@ func (interpreter *Interpreter) executeBlock(stmts []grammar.Statement, env Envi
 }
 
 func (interpreter *Interpreter) Interpret(statements []grammar.Statement) []grammar.LoxError {
-	interpreter.globalEnv = &interpreter.Env
-	interpreter.globalEnv.defineEnvValue(grammar.Token{Lexeme: "clock"}, NativeCall{Airity: 0, NativeCallFunc: func(a ...any) any { return time.Now() }})
+	return interpreter.InterpretContext(context.Background(), statements)
+}
+
+func (interpreter *Interpreter) InterpretContext(ctx context.Context, statements []grammar.Statement) []grammar.LoxError {
+	end := interpreter.Begin(ctx)
+	defer end()
+
+	globals := interpreter.Env
+	interpreter.globalEnv = &globals
+	interpreter.globalEnv.defineEnvValue(grammar.Token{Lexeme: "clock"}, NativeCall{Name: "clock", Airity: 0, NativeCallFunc: func(interpreter *Interpreter, arguments []any) (any, error) {
+		return float64(time.Now().UnixNano()) / float64(time.Second), nil
+	}})
 
 	errs := make([]grammar.LoxError, 0)
 	for _, stmt := range statements {
@@ -417,44 +522,54 @@ func (interpreter *Interpreter) Interpret(statements []grammar.Statement) []gram
 		if err != nil {
 			errs = append(errs, err)
 		}
+		if interpreter.halted() {
+			break
+		}
 	}
 	return errs
 }
 
-func (interpreter *Interpreter) varStmt(stmt grammar.VariableDeclarationStatement) grammar.LoxError {
+func (interpreter *Interpreter) Evaluate(expr grammar.Expression) (any, grammar.LoxError) {
+	return interpreter.EvaluateContext(context.Background(), expr)
+}
+
+func (interpreter *Interpreter) EvaluateContext(ctx context.Context, expr grammar.Expression) (any, grammar.LoxError) {
+	end := interpreter.Begin(ctx)
+	defer end()
+	return interpreter.evaluate(expr)
+}
+
+func (interpreter *Interpreter) VisitVariableDeclarationStatement(stmt grammar.VariableDeclarationStatement) (any, grammar.LoxError) {
 	var value any
 	var err grammar.LoxError
 	if stmt.Initializer != nil {
 		value, err = interpreter.evaluate(stmt.Initializer)
 	}
 	interpreter.Env.defineEnvValue(stmt.Name, value)
-	return err
+	return nil, err
 }
 
-func (interpreter *Interpreter) varExpr(expr grammar.VariableDeclaration) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitVariableDeclaration(expr grammar.VariableDeclaration) (any, grammar.LoxError) {
 	return interpreter.lookUpVariable(expr.Name, expr)
 }
 
 func (interpreter *Interpreter) lookUpVariable(name grammar.Token, expr grammar.Expression) (any, grammar.LoxError) {
-	distance, ok := interpreter.LocalEnv[expr]
-	if !ok {
+	if distance, ok := interpreter.LocalEnv[localKey(expr)]; ok {
 		return interpreter.Env.getEnvValueAt(distance, name)
 	}
-	return interpreter.globalEnv.getEnvValue(name)
+	return interpreter.Globals().getEnvValue(name)
 }
 
-func (interpreter *Interpreter) assignmentExpr(expr grammar.AssignmentExpression) (any, grammar.LoxError) {
+func (interpreter *Interpreter) VisitAssignmentExpression(expr grammar.AssignmentExpression) (any, grammar.LoxError) {
 	value, err := interpreter.evaluate(expr.Value)
 	if err != nil {
 		return nil, err
 	}
-	distance, ok := interpreter.LocalEnv[expr]
-	if !ok {
+	if distance, ok := interpreter.LocalEnv[localKey(expr)]; ok {
 		interpreter.Env.assignEnvValueAt(distance, expr.Name, value)
-	} else {
-		interpreter.globalEnv.assignEnvValue(expr.Name, value)
+		return value, nil
 	}
-	return value, err
+	return value, interpreter.Globals().assignEnvValue(expr.Name, value)
 }
 
 func checkTypeEquality(a, b any) bool {
@@ -494,5 +609,19 @@ func checkNumericOperands(operator grammar.Token, left any, right any) grammar.L
 }
 
 func (interpreter *Interpreter) Resolve(expr grammar.Expression, depth int) {
-	interpreter.LocalEnv[expr] = depth
+	interpreter.LocalEnv[localKey(expr)] = depth
+}
+
+// Forget drops a resolution made earlier, leaving expr to be looked up among
+// the globals.
+func (interpreter *Interpreter) Forget(expr grammar.Expression) {
+	delete(interpreter.LocalEnv, localKey(expr))
+}
+
+func localKey(expr grammar.Expression) any {
+	switch expr := expr.(type) {
+	case grammar.AssignmentExpression:
+		return expr.Name
+	}
+	return expr
 }
@@ -0,0 +1,116 @@
+package runtime
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+var ErrAccessDenied = errors.New("file system access denied")
+
+type FileSystem interface {
+	Stat(name string) (fs.FileInfo, error)
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte) error
+}
+
+type OSFileSystem struct{}
+
+func (OSFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFileSystem) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+func (OSFileSystem) WriteFile(name string, data []byte) error {
+	return os.WriteFile(name, data, 0644)
+}
+
+type ReadOnlyFileSystem struct {
+	FS fs.FS
+}
+
+func (fileSystem ReadOnlyFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(fileSystem.FS, name)
+}
+
+func (fileSystem ReadOnlyFileSystem) ReadFile(name string) ([]byte, error) {
+	return fs.ReadFile(fileSystem.FS, name)
+}
+
+func (fileSystem ReadOnlyFileSystem) WriteFile(name string, data []byte) error {
+	return &fs.PathError{Op: "write", Path: name, Err: ErrAccessDenied}
+}
+
+type DenyFileSystem struct{}
+
+func (DenyFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: ErrAccessDenied}
+}
+
+func (DenyFileSystem) ReadFile(name string) ([]byte, error) {
+	return nil, &fs.PathError{Op: "read", Path: name, Err: ErrAccessDenied}
+}
+
+func (DenyFileSystem) WriteFile(name string, data []byte) error {
+	return &fs.PathError{Op: "write", Path: name, Err: ErrAccessDenied}
+}
+
+func (interpreter *Interpreter) output() io.Writer {
+	if interpreter.Stdout == nil {
+		return os.Stdout
+	}
+	return interpreter.Stdout
+}
+
+func (interpreter *Interpreter) input() io.Reader {
+	if interpreter.Stdin == nil {
+		return os.Stdin
+	}
+	return interpreter.Stdin
+}
+
+func (interpreter *Interpreter) fileSystem() FileSystem {
+	if interpreter.FS == nil {
+		return OSFileSystem{}
+	}
+	return interpreter.FS
+}
+
+// ReadLine reads byte by byte so that no input is buffered away from the
+// reader the embedder handed in.
+func (interpreter *Interpreter) ReadLine() (string, error) {
+	var line strings.Builder
+	buffer := make([]byte, 1)
+	for {
+		n, err := interpreter.input().Read(buffer)
+		if n > 0 {
+			if buffer[0] == '\n' {
+				return strings.TrimSuffix(line.String(), "\r"), nil
+			}
+			line.WriteByte(buffer[0])
+		}
+		if err != nil {
+			if err == io.EOF && line.Len() > 0 {
+				return line.String(), nil
+			}
+			return "", err
+		}
+	}
+}
+
+func (interpreter *Interpreter) ReadFile(name string) (string, error) {
+	content, err := interpreter.fileSystem().ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func (interpreter *Interpreter) WriteFile(name string, content string) error {
+	return interpreter.fileSystem().WriteFile(name, []byte(content))
+}
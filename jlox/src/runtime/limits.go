@@ -0,0 +1,164 @@
+package runtime
+
+import (
+	"context"
+	"time"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+const DEFAULT_MAX_CALL_DEPTH = 2048
+
+const (
+	STRING_HEADER_SIZE = 16
+	ENVIRONMENT_SIZE   = 64
+	INSTANCE_SIZE      = 48
+	LIST_HEADER_SIZE   = 24
+	LIST_ELEMENT_SIZE  = 16
+)
+
+type Limits struct {
+	MaxSteps     int
+	MaxCallDepth int
+	MaxMemory    int64
+	Timeout      time.Duration
+}
+
+// Usage counts what a run has consumed. Memory is the number of bytes live
+// by the estimates above, which MaxMemory bounds: block and call
+// environments are given back when they exit, while strings, instances and
+// lists are kept until the run ends as nothing tracks when they die.
+// PeakMemory is the most Memory reached.
+type Usage struct {
+	Steps      int
+	Memory     int64
+	PeakMemory int64
+}
+
+// Execution is the budget of one run: its context, the limits it started
+// with and what it has used so far. Modules imported during the run are
+// evaluated within the importer's, so one budget covers the whole program.
+type Execution struct {
+	ctx    context.Context
+	done   <-chan struct{}
+	limits Limits
+	usage  Usage
+	depth  int
+	halted bool
+}
+
+func (interpreter *Interpreter) Begin(ctx context.Context) context.CancelFunc {
+	if interpreter.execution != nil {
+		return func() {}
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if interpreter.Limits.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, interpreter.Limits.Timeout)
+	}
+	interpreter.execution = &Execution{ctx: ctx, done: ctx.Done(), limits: interpreter.Limits}
+	return func() {
+		cancel()
+		interpreter.usage = interpreter.execution.usage
+		interpreter.execution = nil
+	}
+}
+
+// Join runs the interpreter within an execution begun by another one, which
+// Begin then keeps instead of starting its own.
+func (interpreter *Interpreter) Join(execution *Execution) {
+	interpreter.execution = execution
+}
+
+func (interpreter *Interpreter) tick(node any) grammar.LoxError {
+	if interpreter.execution == nil {
+		return nil
+	}
+
+	interpreter.execution.usage.Steps++
+	if interpreter.execution.limits.MaxSteps > 0 && interpreter.execution.usage.Steps > interpreter.execution.limits.MaxSteps {
+		return interpreter.limitError(node, "Execution step limit exceeded.")
+	}
+
+	select {
+	case <-interpreter.execution.done:
+		if interpreter.execution.ctx.Err() == context.DeadlineExceeded {
+			return interpreter.limitError(node, "Execution timed out.")
+		}
+		return interpreter.limitError(node, "Execution cancelled.")
+	default:
+		return nil
+	}
+}
+
+func (interpreter *Interpreter) enterCall(paren grammar.Token) grammar.LoxError {
+	if interpreter.execution == nil {
+		return nil
+	}
+
+	maxDepth := interpreter.execution.limits.MaxCallDepth
+	if maxDepth <= 0 {
+		maxDepth = DEFAULT_MAX_CALL_DEPTH
+	}
+	if interpreter.execution.depth >= maxDepth {
+		return RuntimeError{Token: paren, Message: "Stack overflow."}
+	}
+	interpreter.execution.depth++
+	return nil
+}
+
+func (interpreter *Interpreter) exitCall() {
+	if interpreter.execution != nil {
+		interpreter.execution.depth--
+	}
+}
+
+func (interpreter *Interpreter) allocate(token grammar.Token, size int) grammar.LoxError {
+	if err := interpreter.Allocate(size); err != nil {
+		return RuntimeError{Token: token, Message: err.(NativeError).Message}
+	}
+	return nil
+}
+
+func (interpreter *Interpreter) Allocate(size int) error {
+	if interpreter.execution == nil {
+		return nil
+	}
+
+	usage := &interpreter.execution.usage
+	if interpreter.execution.limits.MaxMemory > 0 && usage.Memory+int64(size) > interpreter.execution.limits.MaxMemory {
+		interpreter.execution.halted = true
+		return NativeError{Message: "Memory limit exceeded."}
+	}
+	usage.Memory += int64(size)
+	usage.PeakMemory = max(usage.PeakMemory, usage.Memory)
+	return nil
+}
+
+func (interpreter *Interpreter) release(size int) {
+	if interpreter.execution != nil {
+		interpreter.execution.usage.Memory -= int64(size)
+	}
+}
+
+// AllocateString accounts for a string a native function creates.
+func (interpreter *Interpreter) AllocateString(value string) error {
+	return interpreter.Allocate(STRING_HEADER_SIZE + len(value))
+}
+
+func (interpreter *Interpreter) Usage() Usage {
+	if interpreter.execution != nil {
+		return interpreter.execution.usage
+	}
+	return interpreter.usage
+}
+
+func (interpreter *Interpreter) halted() bool {
+	return interpreter.execution != nil && interpreter.execution.halted
+}
+
+func (interpreter *Interpreter) limitError(node any, message string) grammar.LoxError {
+	interpreter.execution.halted = true
+	token, _ := grammar.FirstToken(node)
+	return RuntimeError{Token: token, Message: message}
+}
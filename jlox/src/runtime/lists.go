@@ -0,0 +1,15 @@
+package runtime
+
+import "strings"
+
+type LoxList struct {
+	Elements []any
+}
+
+func (list *LoxList) ToString() string {
+	elements := make([]string, 0)
+	for _, element := range list.Elements {
+		elements = append(elements, Stringify(element))
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
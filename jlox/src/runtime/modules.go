@@ -0,0 +1,30 @@
+package runtime
+
+import (
+	"fmt"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+// ModuleLoader evaluates imported modules within the importer's execution,
+// which is nil outside of a run.
+type ModuleLoader interface {
+	Load(path grammar.Token, execution *Execution) (LoxModule, grammar.LoxError)
+}
+
+type LoxModule struct {
+	Name    string
+	Exports map[string]any
+}
+
+func (module *LoxModule) GetProperty(name grammar.Token) (any, grammar.LoxError) {
+	lookup := fmt.Sprintf("%s", name.Lexeme)
+	if value, ok := module.Exports[lookup]; ok {
+		return value, nil
+	}
+	return nil, RuntimeError{Token: name, Message: fmt.Sprintf("Module '%v' has no export '%v'.", module.Name, name.Lexeme)}
+}
+
+func (module *LoxModule) ToString() string {
+	return fmt.Sprintf("<module %v>", module.Name)
+}
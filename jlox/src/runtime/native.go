@@ -1,6 +1,8 @@
 package runtime
 
 import (
+	"fmt"
+
 	"github.com/DrEmbryo/jlox/src/grammar"
 )
 
@@ -9,10 +11,13 @@ type LoxCallable interface {
 	GetAirity() int
 }
 
-type NativeCallFunc func(...any) any
+type NativeCallFunc func(interpreter *Interpreter, arguments []any) (any, error)
 
 type NativeCall struct {
+	Name           string
 	Airity         int
+	OptionalAirity int
+	Variadic       bool
 	NativeCallFunc NativeCallFunc
 }
 
@@ -20,10 +25,182 @@ func (native *NativeCall) GetAirity() int {
 	return native.Airity
 }
 
+func (native *NativeCall) acceptsAirity(count int) bool {
+	if count < native.Airity {
+		return false
+	}
+	return native.Variadic || count <= native.Airity+native.OptionalAirity
+}
+
+func (native *NativeCall) airityMessage(count int) string {
+	switch {
+	case native.Variadic:
+		return fmt.Sprintf("Expect at least %v arguments but got %v.", native.Airity, count)
+	case native.OptionalAirity > 0:
+		return fmt.Sprintf("Expect %v to %v arguments but got %v.", native.Airity, native.Airity+native.OptionalAirity, count)
+	default:
+		return fmt.Sprintf("Expect %v arguments but got %v.", native.Airity, count)
+	}
+}
+
 func (native *NativeCall) Call(interpreter Interpreter, arguments []any) (any, grammar.LoxError) {
-	return native.NativeCallFunc(arguments), nil
+	if interpreter.Hook != nil {
+		interpreter.Hook.EnterFunction(&interpreter, native)
+		defer interpreter.Hook.ExitFunction(&interpreter, native)
+	}
+
+	value, err := native.NativeCallFunc(&interpreter, arguments)
+	if err == nil {
+		return value, nil
+	}
+	if loxErr, ok := err.(grammar.LoxError); ok {
+		return nil, loxErr
+	}
+	if native.Name != "" {
+		return nil, NativeError{Message: fmt.Sprintf("%s: %v", native.Name, err)}
+	}
+	return nil, NativeError{Message: err.Error()}
 }
 
 func (native *NativeCall) ToString() string {
+	if native.Name != "" {
+		return fmt.Sprintf("<native fn %s>", native.Name)
+	}
 	return "<native func>"
 }
+
+func argument(arguments []any, index int) (any, error) {
+	if index >= len(arguments) {
+		return nil, fmt.Errorf("missing argument %v", index+1)
+	}
+	return arguments[index], nil
+}
+
+func argumentTypeError(arguments []any, index int, expected string) error {
+	return fmt.Errorf("argument %v must be a %s but got %s", index+1, expected, TypeName(arguments[index]))
+}
+
+func NumberArg(arguments []any, index int) (float64, error) {
+	value, err := argument(arguments, index)
+	if err != nil {
+		return 0, err
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return 0, argumentTypeError(arguments, index, "number")
+	}
+	return number, nil
+}
+
+func OptionalNumberArg(arguments []any, index int, fallback float64) (float64, error) {
+	if index >= len(arguments) {
+		return fallback, nil
+	}
+	return NumberArg(arguments, index)
+}
+
+func StringArg(arguments []any, index int) (string, error) {
+	value, err := argument(arguments, index)
+	if err != nil {
+		return "", err
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", argumentTypeError(arguments, index, "string")
+	}
+	return str, nil
+}
+
+func OptionalStringArg(arguments []any, index int, fallback string) (string, error) {
+	if index >= len(arguments) {
+		return fallback, nil
+	}
+	return StringArg(arguments, index)
+}
+
+func BoolArg(arguments []any, index int) (bool, error) {
+	value, err := argument(arguments, index)
+	if err != nil {
+		return false, err
+	}
+	boolean, ok := value.(bool)
+	if !ok {
+		return false, argumentTypeError(arguments, index, "bool")
+	}
+	return boolean, nil
+}
+
+func ListArg(arguments []any, index int) (LoxList, error) {
+	value, err := argument(arguments, index)
+	if err != nil {
+		return LoxList{}, err
+	}
+	list, ok := value.(LoxList)
+	if !ok {
+		return LoxList{}, argumentTypeError(arguments, index, "list")
+	}
+	return list, nil
+}
+
+func CallableArg(arguments []any, index int) (any, error) {
+	value, err := argument(arguments, index)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := toCallable(value); !ok {
+		return nil, argumentTypeError(arguments, index, "function")
+	}
+	return value, nil
+}
+
+func toCallable(callee any) (LoxCallable, bool) {
+	switch calleeType := callee.(type) {
+	case LoxFunction:
+		return &calleeType, true
+	case NativeCall:
+		return &calleeType, true
+	case LoxClass:
+		return &calleeType, true
+	}
+	return nil, false
+}
+
+func checkAirity(function LoxCallable, paren grammar.Token, count int) grammar.LoxError {
+	if native, ok := function.(*NativeCall); ok {
+		if !native.acceptsAirity(count) {
+			return RuntimeError{Token: paren, Message: native.airityMessage(count)}
+		}
+		return nil
+	}
+	if count != function.GetAirity() {
+		return RuntimeError{Token: paren, Message: fmt.Sprintf("Expect %v arguments but got %v.", function.GetAirity(), count)}
+	}
+	return nil
+}
+
+func TypeName(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case LoxFunction, NativeCall:
+		return "function"
+	case LoxClass:
+		return "class"
+	case LoxClassInstance:
+		return "instance"
+	case LoxList:
+		return "list"
+	case LoxModule:
+		return "module"
+	case LoxObject:
+		return "object"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,95 @@
+package runtime_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/parser"
+	"github.com/DrEmbryo/jlox/src/resolver"
+	"github.com/DrEmbryo/jlox/src/runtime"
+	"github.com/DrEmbryo/jlox/src/utils"
+)
+
+func interpret(t *testing.T, source string, natives map[string]runtime.NativeCall) []grammar.LoxError {
+	tokens, lexErrs := lexer.Lexer{Source: []rune(source)}.Tokenize()
+	if len(lexErrs) > 0 {
+		t.Fatalf("lexer errors: %v", lexErrs)
+	}
+	stmts, err := parser.Parser{Tokens: tokens}.Parse()
+	if err != nil {
+		t.Fatalf("parser error: %v", err)
+	}
+	interpreter := runtime.Interpreter{Env: runtime.Environment{Values: make(map[string]any)}, LocalEnv: make(map[any]int)}
+	for name, native := range natives {
+		native.Name = name
+		interpreter.Env.Define(name, native)
+	}
+	resolver := resolver.Resolver{Interpreter: interpreter, Scopes: utils.Stack[map[string]bool]{}, Error: make([]grammar.LoxError, 0)}
+	if errs := resolver.Resolve(stmts); len(errs) > 0 {
+		t.Fatalf("resolver errors: %v", errs)
+	}
+	return interpreter.Interpret(stmts)
+}
+
+func TestNativeCall(t *testing.T) {
+	var recorded []any
+	record := runtime.NativeCall{Airity: 0, Variadic: true, NativeCallFunc: func(interpreter *runtime.Interpreter, arguments []any) (any, error) {
+		recorded = append(recorded, arguments...)
+		return nil, nil
+	}}
+	optional := runtime.NativeCall{Airity: 1, OptionalAirity: 1, NativeCallFunc: func(interpreter *runtime.Interpreter, arguments []any) (any, error) {
+		return runtime.OptionalNumberArg(arguments, 1, 10)
+	}}
+	fail := runtime.NativeCall{Airity: 0, NativeCallFunc: func(interpreter *runtime.Interpreter, arguments []any) (any, error) {
+		return nil, errors.New("failed")
+	}}
+	apply := runtime.NativeCall{Airity: 2, NativeCallFunc: func(interpreter *runtime.Interpreter, arguments []any) (any, error) {
+		callback, err := runtime.CallableArg(arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		return interpreter.Call(callback, arguments[1:])
+	}}
+	natives := map[string]runtime.NativeCall{"record": record, "optional": optional, "fail": fail, "apply": apply}
+
+	var tests = []struct {
+		name   string
+		source string
+		expect []any
+		errors []string
+	}{
+		{"variadic arguments", "record(1, \"a\", true);", []any{1.0, "a", true}, nil},
+		{"no variadic arguments", "record();", nil, nil},
+		{"optional argument default", "record(optional(1));", []any{10.0}, nil},
+		{"optional argument provided", "record(optional(1, 2));", []any{2.0}, nil},
+		{"too many arguments", "optional(1, 2, 3);", nil, []string{"Expect 1 to 2 arguments but got 3."}},
+		{"native error", "fail();", nil, []string{"fail: failed"}},
+		{"argument validation", "apply(1, 2);", nil, []string{"apply: argument 1 must be a function but got number"}},
+		{"callback into closure", "func double(x) { record(x * 2); } apply(double, 4);", []any{8.0}, nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorded = nil
+			errs := interpret(t, tc.source, natives)
+			if len(errs) != len(tc.errors) {
+				t.Fatalf("got errors %v, want %v", errs, tc.errors)
+			}
+			for i, err := range errs {
+				if err.(runtime.RuntimeError).Message != tc.errors[i] {
+					t.Errorf("got %v, want %v", err.(runtime.RuntimeError).Message, tc.errors[i])
+				}
+			}
+			if len(recorded) != len(tc.expect) {
+				t.Fatalf("got %v, want %v", recorded, tc.expect)
+			}
+			for i := range recorded {
+				if recorded[i] != tc.expect[i] {
+					t.Errorf("got %v, want %v", recorded, tc.expect)
+				}
+			}
+		})
+	}
+}
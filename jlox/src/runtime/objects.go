@@ -0,0 +1,11 @@
+package runtime
+
+import (
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+type LoxObject interface {
+	GetProperty(name grammar.Token) (any, grammar.LoxError)
+	SetProperty(name grammar.Token, value any) grammar.LoxError
+	ToString() string
+}
@@ -0,0 +1,40 @@
+package stdlib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+var Conversion = Library{
+	Name: "conversion",
+	Functions: map[string]runtime.NativeCall{
+		"num": {Airity: 1, NativeCallFunc: num},
+		"str": {Airity: 1, NativeCallFunc: str},
+	},
+}
+
+func num(interpreter *runtime.Interpreter, args []any) (any, error) {
+	switch value := args[0].(type) {
+	case float64:
+		return value, nil
+	case bool:
+		if value {
+			return float64(1), nil
+		}
+		return float64(0), nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("can't convert '%s' to a number", value)
+		}
+		return number, nil
+	}
+	return nil, fmt.Errorf("can't convert %s to a number", runtime.TypeName(args[0]))
+}
+
+func str(interpreter *runtime.Interpreter, args []any) (any, error) {
+	return allocateString(interpreter, runtime.Stringify(args[0]))
+}
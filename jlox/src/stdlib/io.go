@@ -0,0 +1,51 @@
+package stdlib
+
+import (
+	"io"
+
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+var IO = Library{
+	Name: "io",
+	Functions: map[string]runtime.NativeCall{
+		"readLine":  {Airity: 0, NativeCallFunc: readLine},
+		"readFile":  {Airity: 1, NativeCallFunc: readFile},
+		"writeFile": {Airity: 2, NativeCallFunc: writeFile},
+	},
+}
+
+func readLine(interpreter *runtime.Interpreter, args []any) (any, error) {
+	line, err := interpreter.ReadLine()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return allocateString(interpreter, line)
+}
+
+func readFile(interpreter *runtime.Interpreter, args []any) (any, error) {
+	path, err := runtime.StringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	content, err := interpreter.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return allocateString(interpreter, content)
+}
+
+func writeFile(interpreter *runtime.Interpreter, args []any) (any, error) {
+	path, err := runtime.StringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	content, err := runtime.StringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return nil, interpreter.WriteFile(path, content)
+}
@@ -0,0 +1,84 @@
+package stdlib
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+var Math = Library{
+	Name:      "math",
+	Functions: mathFunctions(),
+	Instance:  mathFunctions,
+}
+
+func mathFunctions() map[string]runtime.NativeCall {
+	generator := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return map[string]runtime.NativeCall{
+		"sqrt":   {Airity: 1, NativeCallFunc: numericFunc(math.Sqrt)},
+		"floor":  {Airity: 1, NativeCallFunc: numericFunc(math.Floor)},
+		"pow":    {Airity: 2, NativeCallFunc: pow},
+		"min":    {Airity: 1, Variadic: true, NativeCallFunc: numericReduce(math.Min)},
+		"max":    {Airity: 1, Variadic: true, NativeCallFunc: numericReduce(math.Max)},
+		"random": {Airity: 0, NativeCallFunc: random(generator)},
+		"seed":   {Airity: 1, NativeCallFunc: seed(generator)},
+	}
+}
+
+func numericFunc(function func(float64) float64) runtime.NativeCallFunc {
+	return func(interpreter *runtime.Interpreter, args []any) (any, error) {
+		value, err := runtime.NumberArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return function(value), nil
+	}
+}
+
+func numericReduce(function func(float64, float64) float64) runtime.NativeCallFunc {
+	return func(interpreter *runtime.Interpreter, args []any) (any, error) {
+		result, err := runtime.NumberArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		for i := 1; i < len(args); i++ {
+			value, err := runtime.NumberArg(args, i)
+			if err != nil {
+				return nil, err
+			}
+			result = function(result, value)
+		}
+		return result, nil
+	}
+}
+
+func pow(interpreter *runtime.Interpreter, args []any) (any, error) {
+	base, err := runtime.NumberArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := runtime.NumberArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return math.Pow(base, exponent), nil
+}
+
+func random(generator *rand.Rand) runtime.NativeCallFunc {
+	return func(interpreter *runtime.Interpreter, args []any) (any, error) {
+		return generator.Float64(), nil
+	}
+}
+
+func seed(generator *rand.Rand) runtime.NativeCallFunc {
+	return func(interpreter *runtime.Interpreter, args []any) (any, error) {
+		value, err := runtime.NumberArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		generator.Seed(int64(value))
+		return nil, nil
+	}
+}
@@ -0,0 +1,41 @@
+package stdlib
+
+import (
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+type Library struct {
+	Name      string
+	Functions map[string]runtime.NativeCall
+	// Instance, when set, builds the library's functions afresh on every
+	// Register so that state such as a random generator is not shared
+	// between interpreters. Functions still describes them to tooling.
+	Instance func() map[string]runtime.NativeCall
+}
+
+var All = []Library{Math, Strings, Types, Conversion, IO}
+
+func Register(env *runtime.Environment, libraries ...Library) {
+	for _, library := range libraries {
+		functions := library.Functions
+		if library.Instance != nil {
+			functions = library.Instance()
+		}
+		for name, function := range functions {
+			function.Name = name
+			env.Define(name, function)
+		}
+	}
+}
+
+func Lookup(names ...string) []Library {
+	libraries := make([]Library, 0)
+	for _, library := range All {
+		for _, name := range names {
+			if library.Name == name {
+				libraries = append(libraries, library)
+			}
+		}
+	}
+	return libraries
+}
@@ -0,0 +1,138 @@
+package stdlib
+
+import (
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+var Strings = Library{
+	Name: "strings",
+	Functions: map[string]runtime.NativeCall{
+		"len":     {Airity: 1, NativeCallFunc: length},
+		"substr":  {Airity: 2, OptionalAirity: 1, NativeCallFunc: substr},
+		"split":   {Airity: 2, NativeCallFunc: split},
+		"join":    {Airity: 1, OptionalAirity: 1, NativeCallFunc: join},
+		"upper":   {Airity: 1, NativeCallFunc: stringFunc(strings.ToUpper)},
+		"lower":   {Airity: 1, NativeCallFunc: stringFunc(strings.ToLower)},
+		"indexOf": {Airity: 2, NativeCallFunc: indexOf},
+		"replace": {Airity: 3, NativeCallFunc: replace},
+	},
+}
+
+func stringFunc(function func(string) string) runtime.NativeCallFunc {
+	return func(interpreter *runtime.Interpreter, args []any) (any, error) {
+		value, err := runtime.StringArg(args, 0)
+		if err != nil {
+			return nil, err
+		}
+		return allocateString(interpreter, function(value))
+	}
+}
+
+func allocateString(interpreter *runtime.Interpreter, value string) (any, error) {
+	if err := interpreter.AllocateString(value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func length(interpreter *runtime.Interpreter, args []any) (any, error) {
+	if list, ok := args[0].(runtime.LoxList); ok {
+		return float64(len(list.Elements)), nil
+	}
+	value, err := runtime.StringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return float64(len([]rune(value))), nil
+}
+
+func substr(interpreter *runtime.Interpreter, args []any) (any, error) {
+	value, err := runtime.StringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	runes := []rune(value)
+	start, err := runtime.NumberArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	end, err := runtime.OptionalNumberArg(args, 2, float64(len(runes)))
+	if err != nil {
+		return nil, err
+	}
+	from := max(0, min(int(start), len(runes)))
+	to := max(from, min(int(end), len(runes)))
+	return allocateString(interpreter, string(runes[from:to]))
+}
+
+func split(interpreter *runtime.Interpreter, args []any) (any, error) {
+	value, err := runtime.StringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	separator, err := runtime.StringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	parts := strings.Split(value, separator)
+	size := runtime.LIST_HEADER_SIZE + len(parts)*runtime.LIST_ELEMENT_SIZE + len(value)
+	if err := interpreter.Allocate(size); err != nil {
+		return nil, err
+	}
+
+	elements := make([]any, 0, len(parts))
+	for _, part := range parts {
+		elements = append(elements, part)
+	}
+	return runtime.LoxList{Elements: elements}, nil
+}
+
+func join(interpreter *runtime.Interpreter, args []any) (any, error) {
+	list, err := runtime.ListArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	separator, err := runtime.OptionalStringArg(args, 1, "")
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, 0)
+	for _, element := range list.Elements {
+		parts = append(parts, runtime.Stringify(element))
+	}
+	return allocateString(interpreter, strings.Join(parts, separator))
+}
+
+func indexOf(interpreter *runtime.Interpreter, args []any) (any, error) {
+	value, err := runtime.StringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	search, err := runtime.StringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	index := strings.Index(value, search)
+	if index < 0 {
+		return float64(-1), nil
+	}
+	return float64(len([]rune(value[:index]))), nil
+}
+
+func replace(interpreter *runtime.Interpreter, args []any) (any, error) {
+	value, err := runtime.StringArg(args, 0)
+	if err != nil {
+		return nil, err
+	}
+	old, err := runtime.StringArg(args, 1)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := runtime.StringArg(args, 2)
+	if err != nil {
+		return nil, err
+	}
+	return allocateString(interpreter, strings.ReplaceAll(value, old, replacement))
+}
@@ -0,0 +1,25 @@
+package stdlib
+
+import (
+	"github.com/DrEmbryo/jlox/src/runtime"
+)
+
+var Types = Library{
+	Name: "types",
+	Functions: map[string]runtime.NativeCall{
+		"type":     {Airity: 1, NativeCallFunc: typeOf},
+		"isNumber": {Airity: 1, NativeCallFunc: isType("number")},
+		"isString": {Airity: 1, NativeCallFunc: isType("string")},
+		"isBool":   {Airity: 1, NativeCallFunc: isType("bool")},
+	},
+}
+
+func typeOf(interpreter *runtime.Interpreter, args []any) (any, error) {
+	return runtime.TypeName(args[0]), nil
+}
+
+func isType(name string) runtime.NativeCallFunc {
+	return func(interpreter *runtime.Interpreter, args []any) (any, error) {
+		return runtime.TypeName(args[0]) == name, nil
+	}
+}
@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const CONTENT_LENGTH_HEADER = "Content-Length"
+
+// ReadMessage reads one message framed with a Content-Length header, as used
+// by the language server and debug adapter protocols.
+func ReadMessage(reader *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		header = strings.TrimRight(header, "\r\n")
+		if header == "" {
+			break
+		}
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed header %q", header)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), CONTENT_LENGTH_HEADER) {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid %s %q", CONTENT_LENGTH_HEADER, value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, fmt.Errorf("missing %s header", CONTENT_LENGTH_HEADER)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+func WriteMessage(writer io.Writer, body []byte) error {
+	if _, err := fmt.Fprintf(writer, "%s: %d\r\n\r\n", CONTENT_LENGTH_HEADER, len(body)); err != nil {
+		return err
+	}
+	_, err := writer.Write(body)
+	return err
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"text/tabwriter"
@@ -9,119 +10,208 @@ import (
 	"github.com/DrEmbryo/jlox/src/grammar"
 )
 
+// AstPrinter dumps a syntax tree as indented text. Output defaults to
+// os.Stdout.
 type AstPrinter struct {
+	Output io.Writer
+	offset int
 }
 
 type TokenPrinter struct {
+	Output io.Writer
+}
+
+func output(writer io.Writer) io.Writer {
+	if writer == nil {
+		return os.Stdout
+	}
+	return writer
 }
 
 func (printer *AstPrinter) Print(stmts []grammar.Statement) {
-	fmt.Println("Ast generated from tokens:")
+	writer := output(printer.Output)
+	fmt.Fprintln(writer, "Ast generated from tokens:")
 	for _, stmt := range stmts {
-		offset := 0
-		fmt.Println(printer.printNode(offset, stmt))
+		fmt.Fprintln(writer, printer.printStmt(0, stmt))
 	}
-	fmt.Println("")
-}
-
-func (printer *AstPrinter) printNode(offset int, stmt grammar.Statement) string {
-	nodeType := fmt.Sprintf("%T", stmt)
-	switch stmtType := stmt.(type) {
-	case grammar.Token:
-		return makeTemplateStr(offset, nodeType, fmt.Sprintf("type [%v] lexeme [%v] literal [%v]", stmtType.TokenType, stmtType.Lexeme, stmtType.Literal))
-	case grammar.VariableDeclarationStatement:
-		initExpr := printer.printNode(offset+1, stmtType.Initializer)
-		token := printer.printNode(offset+1, stmtType.Name)
-		return makeTemplateStr(offset, nodeType, token, initExpr)
-	case grammar.PrintStatement:
-		value := printer.printNode(offset+1, stmtType.Value)
-		return makeTemplateStr(offset, nodeType, value)
-	case grammar.BlockScopeStatement:
-		stmts := printer.printNode(offset+1, stmtType.Statements)
-		return makeTemplateStr(offset, nodeType, stmts)
-	case grammar.WhileLoopStatement:
-		expr := printer.printNode(offset+1, stmtType.Condition)
-		body := printer.printNode(offset+1, stmtType.Body)
-		return makeTemplateStr(offset, nodeType, expr, body)
-	case grammar.ReturnStatement:
-		expr := printer.printNode(offset+1, stmtType.Expression)
-		keyword := printer.printNode(offset+1, stmtType.Keyword)
-		return makeTemplateStr(offset, nodeType, expr, keyword)
-	case grammar.ConditionalStatement:
-		condition := printer.printNode(offset+1, stmtType.Condition)
-		thenBranch := printer.printNode(offset+1, stmtType.ThenBranch)
-		elseBranch := printer.printNode(offset+1, stmtType.ElseBranch)
-		return makeTemplateStr(offset, nodeType, condition, thenBranch, elseBranch)
-	case grammar.ClassDeclarationStatement:
-		className := printer.printNode(offset+1, stmtType.Name)
-		var builder strings.Builder
-		for _, method := range stmtType.Methods {
-			builder.WriteString(printer.printNode(offset+1, method))
-		}
-		methods := builder.String()
-		return makeTemplateStr(offset, nodeType, className, methods)
-	case []grammar.Statement:
-		var builder strings.Builder
-		for _, statement := range stmtType {
-			builder.WriteString(printer.printNode(offset+1, statement))
-		}
-		return builder.String()
-	case grammar.ExpressionStatement:
-		expr := printer.printNode(offset+1, stmtType.Expression)
-		return makeTemplateStr(offset, nodeType, expr)
-	case grammar.UnaryExpression:
-		token := printer.printNode(offset+1, stmtType.Operator)
-		rightExpr := printer.printNode(offset+1, stmtType.Right)
-		return makeTemplateStr(offset, nodeType, token, rightExpr)
-	case grammar.BinaryExpression:
-		leftExpr := printer.printNode(offset+1, stmtType.Left)
-		operator := printer.printNode(offset+1, stmtType.Operator)
-		rightExpr := printer.printNode(offset+1, stmtType.Right)
-		return makeTemplateStr(offset, nodeType, leftExpr, operator, rightExpr)
-	case grammar.LiteralExpression:
-		literal := fmt.Sprintf("literal [%v]", stmtType.Literal)
-		return makeTemplateStr(offset, nodeType, literal)
-	case grammar.VariableDeclaration:
-		token := printer.printNode(offset+1, stmtType.Name)
-		return makeTemplateStr(offset, nodeType, token)
-	case grammar.FunctionDeclarationStatement:
-		token := printer.printNode(offset+1, stmtType.Name)
-		body := printer.printNode(offset+1, stmtType.Body)
-		return makeTemplateStr(offset, nodeType, token, body)
-	case grammar.LogicExpression:
-		leftExpr := printer.printNode(offset+1, stmtType.Left)
-		operator := printer.printNode(offset+1, stmtType.Operator)
-		rightExpr := printer.printNode(offset+1, stmtType.Right)
-		return makeTemplateStr(offset, nodeType, leftExpr, operator, rightExpr)
-	case grammar.GroupingExpression:
-		expr := printer.printNode(offset+1, stmtType.Expression)
-		return makeTemplateStr(offset, nodeType, expr)
-	case grammar.AssignmentExpression:
-		token := printer.printNode(offset+1, stmtType.Name)
-		expr := printer.printNode(offset+1, stmtType.Value)
-		return makeTemplateStr(offset, nodeType, token, expr)
-	case grammar.CallExpression:
-		callee := printer.printNode(offset+1, stmtType.Callee)
-		expr := printer.printNode(offset+1, stmtType.Paren)
-		args := printer.printNode(offset+1, stmtType.Arguments)
-		return makeTemplateStr(offset, nodeType, callee, expr, args)
-	case grammar.PropertyAccessExpression:
-		object := printer.printNode(offset+1, stmtType.Object)
-		return makeTemplateStr(offset, nodeType, object)
-	case grammar.PropertyAssignmentExpression:
-		object := printer.printNode(offset+1, stmtType.Object)
-		value := printer.printNode(offset+1, stmtType.Value)
-		return makeTemplateStr(offset, nodeType, object, value)
-	case grammar.SelfReferenceExpression:
-		keyword := printer.printNode(offset+1, stmtType.Keyword)
-		return makeTemplateStr(offset, nodeType, keyword)
-	case grammar.BaseClassCallExpression:
-		keyword := printer.printNode(offset+1, stmtType.Keyword)
-		method := printer.printNode(offset+1, stmtType.Method)
-		return makeTemplateStr(offset+1, nodeType, keyword, method)
-	default:
-		return nodeType
+	fmt.Fprintln(writer, "")
+}
+
+// printStmt and printExpr print a node at the given offset; the visit
+// methods read it back from printer.offset.
+func (printer *AstPrinter) printStmt(offset int, stmt grammar.Statement) string {
+	if stmt == nil {
+		return fmt.Sprintf("%T", stmt)
+	}
+	parent := printer.offset
+	printer.offset = offset
+	str, _ := stmt.Accept(printer)
+	printer.offset = parent
+	return str.(string)
+}
+
+func (printer *AstPrinter) printExpr(offset int, expr grammar.Expression) string {
+	if expr == nil {
+		return fmt.Sprintf("%T", expr)
 	}
+	parent := printer.offset
+	printer.offset = offset
+	str, _ := expr.Accept(printer)
+	printer.offset = parent
+	return str.(string)
+}
+
+func (printer *AstPrinter) printToken(offset int, token grammar.Token) string {
+	return makeTemplateStr(offset, fmt.Sprintf("%T", token), fmt.Sprintf("type [%v] lexeme [%v] literal [%v]", token.TokenType, token.Lexeme, token.Literal))
+}
+
+func (printer *AstPrinter) template(node any, args ...string) (any, grammar.LoxError) {
+	return makeTemplateStr(printer.offset, append([]string{fmt.Sprintf("%T", node)}, args...)...), nil
+}
+
+func (printer *AstPrinter) VisitVariableDeclarationStatement(stmt grammar.VariableDeclarationStatement) (any, grammar.LoxError) {
+	token := printer.printToken(printer.offset+1, stmt.Name)
+	initExpr := printer.printExpr(printer.offset+1, stmt.Initializer)
+	return printer.template(stmt, token, initExpr)
+}
+
+func (printer *AstPrinter) VisitPrintStatement(stmt grammar.PrintStatement) (any, grammar.LoxError) {
+	return printer.template(stmt, printer.printExpr(printer.offset+1, stmt.Value))
+}
+
+func (printer *AstPrinter) VisitBlockScopeStatement(stmt grammar.BlockScopeStatement) (any, grammar.LoxError) {
+	var builder strings.Builder
+	for _, statement := range stmt.Statements {
+		builder.WriteString(printer.printStmt(printer.offset+1, statement))
+	}
+	return printer.template(stmt, builder.String())
+}
+
+func (printer *AstPrinter) VisitWhileLoopStatement(stmt grammar.WhileLoopStatement) (any, grammar.LoxError) {
+	expr := printer.printExpr(printer.offset+1, stmt.Condition)
+	body := printer.printStmt(printer.offset+1, stmt.Body)
+	return printer.template(stmt, expr, body)
+}
+
+func (printer *AstPrinter) VisitReturnStatement(stmt grammar.ReturnStatement) (any, grammar.LoxError) {
+	expr := printer.printExpr(printer.offset+1, stmt.Expression)
+	keyword := printer.printToken(printer.offset+1, stmt.Keyword)
+	return printer.template(stmt, expr, keyword)
+}
+
+func (printer *AstPrinter) VisitConditionalStatement(stmt grammar.ConditionalStatement) (any, grammar.LoxError) {
+	condition := printer.printExpr(printer.offset+1, stmt.Condition)
+	thenBranch := printer.printStmt(printer.offset+1, stmt.ThenBranch)
+	elseBranch := printer.printStmt(printer.offset+1, stmt.ElseBranch)
+	return printer.template(stmt, condition, thenBranch, elseBranch)
+}
+
+func (printer *AstPrinter) VisitClassDeclarationStatement(stmt grammar.ClassDeclarationStatement) (any, grammar.LoxError) {
+	className := printer.printToken(printer.offset+1, stmt.Name)
+	super := printer.printExpr(printer.offset+1, stmt.Super)
+	var builder strings.Builder
+	for _, field := range stmt.Fields {
+		builder.WriteString(printer.printStmt(printer.offset+1, field))
+	}
+	for _, method := range stmt.Methods {
+		builder.WriteString(printer.printStmt(printer.offset+1, method))
+	}
+	return printer.template(stmt, className, super, builder.String())
+}
+
+func (printer *AstPrinter) VisitImportStatement(stmt grammar.ImportStatement) (any, grammar.LoxError) {
+	path := printer.printToken(printer.offset+1, stmt.Path)
+	alias := printer.printToken(printer.offset+1, stmt.Alias)
+	return printer.template(stmt, path, alias)
+}
+
+func (printer *AstPrinter) VisitExportStatement(stmt grammar.ExportStatement) (any, grammar.LoxError) {
+	return printer.template(stmt, printer.printStmt(printer.offset+1, stmt.Declaration))
+}
+
+func (printer *AstPrinter) VisitExpressionStatement(stmt grammar.ExpressionStatement) (any, grammar.LoxError) {
+	return printer.template(stmt, printer.printExpr(printer.offset+1, stmt.Expression))
+}
+
+func (printer *AstPrinter) VisitFunctionDeclarationStatement(stmt grammar.FunctionDeclarationStatement) (any, grammar.LoxError) {
+	token := printer.printToken(printer.offset+1, stmt.Name)
+	var builder strings.Builder
+	for _, param := range stmt.Params {
+		builder.WriteString(printer.printToken(printer.offset+1, param))
+	}
+	body := printer.printStmt(printer.offset+1, stmt.Body)
+	return printer.template(stmt, token, builder.String(), body)
+}
+
+func (printer *AstPrinter) VisitUnaryExpression(expr grammar.UnaryExpression) (any, grammar.LoxError) {
+	token := printer.printToken(printer.offset+1, expr.Operator)
+	rightExpr := printer.printExpr(printer.offset+1, expr.Right)
+	return printer.template(expr, token, rightExpr)
+}
+
+func (printer *AstPrinter) VisitBinaryExpression(expr grammar.BinaryExpression) (any, grammar.LoxError) {
+	leftExpr := printer.printExpr(printer.offset+1, expr.Left)
+	operator := printer.printToken(printer.offset+1, expr.Operator)
+	rightExpr := printer.printExpr(printer.offset+1, expr.Right)
+	return printer.template(expr, leftExpr, operator, rightExpr)
+}
+
+func (printer *AstPrinter) VisitLiteralExpression(expr grammar.LiteralExpression) (any, grammar.LoxError) {
+	return printer.template(expr, fmt.Sprintf("literal [%v]", expr.Literal))
+}
+
+func (printer *AstPrinter) VisitVariableDeclaration(expr grammar.VariableDeclaration) (any, grammar.LoxError) {
+	return printer.template(expr, printer.printToken(printer.offset+1, expr.Name))
+}
+
+func (printer *AstPrinter) VisitLogicExpression(expr grammar.LogicExpression) (any, grammar.LoxError) {
+	leftExpr := printer.printExpr(printer.offset+1, expr.Left)
+	operator := printer.printToken(printer.offset+1, expr.Operator)
+	rightExpr := printer.printExpr(printer.offset+1, expr.Right)
+	return printer.template(expr, leftExpr, operator, rightExpr)
+}
+
+func (printer *AstPrinter) VisitGroupingExpression(expr grammar.GroupingExpression) (any, grammar.LoxError) {
+	return printer.template(expr, printer.printExpr(printer.offset+1, expr.Expression))
+}
+
+func (printer *AstPrinter) VisitAssignmentExpression(expr grammar.AssignmentExpression) (any, grammar.LoxError) {
+	token := printer.printToken(printer.offset+1, expr.Name)
+	value := printer.printExpr(printer.offset+1, expr.Value)
+	return printer.template(expr, token, value)
+}
+
+func (printer *AstPrinter) VisitCallExpression(expr grammar.CallExpression) (any, grammar.LoxError) {
+	callee := printer.printExpr(printer.offset+1, expr.Callee)
+	paren := printer.printToken(printer.offset+1, expr.Paren)
+	var builder strings.Builder
+	for _, argument := range expr.Arguments {
+		builder.WriteString(printer.printExpr(printer.offset+1, argument))
+	}
+	return printer.template(expr, callee, paren, builder.String())
+}
+
+func (printer *AstPrinter) VisitPropertyAccessExpression(expr grammar.PropertyAccessExpression) (any, grammar.LoxError) {
+	object := printer.printExpr(printer.offset+1, expr.Object)
+	name := printer.printToken(printer.offset+1, expr.Name)
+	return printer.template(expr, object, name)
+}
+
+func (printer *AstPrinter) VisitPropertyAssignmentExpression(expr grammar.PropertyAssignmentExpression) (any, grammar.LoxError) {
+	object := printer.printExpr(printer.offset+1, expr.Object)
+	name := printer.printToken(printer.offset+1, expr.Name)
+	value := printer.printExpr(printer.offset+1, expr.Value)
+	return printer.template(expr, object, name, value)
+}
+
+func (printer *AstPrinter) VisitSelfReferenceExpression(expr grammar.SelfReferenceExpression) (any, grammar.LoxError) {
+	return printer.template(expr, printer.printToken(printer.offset+1, expr.Keyword))
+}
+
+func (printer *AstPrinter) VisitBaseClassCallExpression(expr grammar.BaseClassCallExpression) (any, grammar.LoxError) {
+	keyword := printer.printToken(printer.offset+1, expr.Keyword)
+	method := printer.printToken(printer.offset+1, expr.Method)
+	return printer.template(expr, keyword, method)
 }
 
 func makeTemplateStr(offset int, args ...string) string {
@@ -144,13 +234,13 @@ func offsetTemplateStr(str string, offset int) string {
 }
 
 func (printer *TokenPrinter) Print(tokens []grammar.Token) {
-	fmt.Println("Tokens generated from source:")
-	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', 0)
+	fmt.Fprintln(output(printer.Output), "Tokens generated from source:")
+	writer := tabwriter.NewWriter(output(printer.Output), 0, 0, 1, ' ', 0)
 	for index, token := range tokens {
 		fmt.Fprintln(writer, printer.printToken(index, token))
 	}
 	writer.Flush()
-	fmt.Println()
+	fmt.Fprintln(output(printer.Output))
 }
 
 func (printer *TokenPrinter) printToken(index int, token grammar.Token) string {
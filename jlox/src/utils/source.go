@@ -0,0 +1,288 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/DrEmbryo/jlox/src/grammar"
+)
+
+const SOURCE_INDENT = "  "
+
+// SourcePrinter turns a syntax tree back into Lox source. Parsing its output
+// gives the tree it was given, up to token positions: groupings are kept as
+// written and the while loops the parser builds for for statements are
+// printed as for statements again. Every node type has a visit method, so a
+// new one does not compile until the printer handles it. Output defaults to
+// os.Stdout.
+type SourcePrinter struct {
+	Output io.Writer
+	indent int
+}
+
+func (printer *SourcePrinter) Print(stmts []grammar.Statement) {
+	fmt.Fprint(output(printer.Output), printer.Source(stmts))
+}
+
+func (printer *SourcePrinter) Source(stmts []grammar.Statement) string {
+	var builder strings.Builder
+	for _, stmt := range stmts {
+		printer.statement(&builder, 0, stmt)
+	}
+	return builder.String()
+}
+
+func (printer *SourcePrinter) statement(builder *strings.Builder, indent int, stmt grammar.Statement) {
+	if stmt == nil {
+		return
+	}
+	builder.WriteString(strings.Repeat(SOURCE_INDENT, indent))
+	builder.WriteString(printer.inline(indent, stmt))
+	builder.WriteString("\n")
+}
+
+// inline prints a statement starting at the current position, leaving the
+// caller to indent its first line and end its last one. The visit methods
+// read the indent back from printer.indent.
+func (printer *SourcePrinter) inline(indent int, stmt grammar.Statement) string {
+	parent := printer.indent
+	printer.indent = indent
+	source, _ := stmt.Accept(printer)
+	printer.indent = parent
+	return source.(string)
+}
+
+func (printer *SourcePrinter) VisitExpressionStatement(stmt grammar.ExpressionStatement) (any, grammar.LoxError) {
+	return printer.expression(stmt.Expression) + ";", nil
+}
+
+func (printer *SourcePrinter) VisitPrintStatement(stmt grammar.PrintStatement) (any, grammar.LoxError) {
+	return "print " + printer.expression(stmt.Value) + ";", nil
+}
+
+func (printer *SourcePrinter) VisitVariableDeclarationStatement(stmt grammar.VariableDeclarationStatement) (any, grammar.LoxError) {
+	source := "var " + lexeme(stmt.Name) + annotation(stmt.Type)
+	if stmt.Initializer != nil {
+		source += " = " + printer.expression(stmt.Initializer)
+	}
+	return source + ";", nil
+}
+
+func (printer *SourcePrinter) VisitFunctionDeclarationStatement(stmt grammar.FunctionDeclarationStatement) (any, grammar.LoxError) {
+	return "func " + printer.function(printer.indent, stmt), nil
+}
+
+func (printer *SourcePrinter) VisitClassDeclarationStatement(stmt grammar.ClassDeclarationStatement) (any, grammar.LoxError) {
+	source := "class " + lexeme(stmt.Name)
+	if stmt.Super != nil {
+		source += " < " + printer.expression(stmt.Super)
+	}
+	if len(stmt.Fields) == 0 && len(stmt.Methods) == 0 {
+		return source + " {}", nil
+	}
+	var builder strings.Builder
+	builder.WriteString(source + " {\n")
+	padding := strings.Repeat(SOURCE_INDENT, printer.indent+1)
+	for _, field := range stmt.Fields {
+		builder.WriteString(padding + lexeme(field.Name) + annotation(field.Type) + ";\n")
+	}
+	for _, method := range stmt.Methods {
+		builder.WriteString(padding + printer.function(printer.indent+1, method) + "\n")
+	}
+	builder.WriteString(strings.Repeat(SOURCE_INDENT, printer.indent) + "}")
+	return builder.String(), nil
+}
+
+func (printer *SourcePrinter) VisitReturnStatement(stmt grammar.ReturnStatement) (any, grammar.LoxError) {
+	if stmt.Expression == nil {
+		return "return;", nil
+	}
+	return "return " + printer.expression(stmt.Expression) + ";", nil
+}
+
+func (printer *SourcePrinter) VisitBlockScopeStatement(stmt grammar.BlockScopeStatement) (any, grammar.LoxError) {
+	if loop, ok := forInitializer(stmt); ok {
+		return printer.forLoop(printer.indent, stmt.Statements[2], loop), nil
+	}
+	return printer.block(printer.indent, stmt), nil
+}
+
+func (printer *SourcePrinter) VisitConditionalStatement(stmt grammar.ConditionalStatement) (any, grammar.LoxError) {
+	indent := printer.indent
+	source := "if (" + printer.expression(stmt.Condition) + ")" + printer.branch(indent, stmt.ThenBranch)
+	if stmt.ElseBranch == nil {
+		return source, nil
+	}
+	if _, ok := stmt.ThenBranch.(grammar.BlockScopeStatement); ok {
+		source += " else"
+	} else {
+		source += "\n" + strings.Repeat(SOURCE_INDENT, indent) + "else"
+	}
+	if _, ok := stmt.ElseBranch.(grammar.ConditionalStatement); ok {
+		return source + " " + printer.inline(indent, stmt.ElseBranch), nil
+	}
+	return source + printer.branch(indent, stmt.ElseBranch), nil
+}
+
+func (printer *SourcePrinter) VisitWhileLoopStatement(stmt grammar.WhileLoopStatement) (any, grammar.LoxError) {
+	if stmt.Keyword.TokenType == grammar.FOR {
+		return printer.forLoop(printer.indent, nil, stmt), nil
+	}
+	return "while (" + printer.expression(stmt.Condition) + ")" + printer.branch(printer.indent, stmt.Body), nil
+}
+
+func (printer *SourcePrinter) VisitImportStatement(stmt grammar.ImportStatement) (any, grammar.LoxError) {
+	return fmt.Sprintf("import \"%v\" as %s;", stmt.Path.Lexeme, lexeme(stmt.Alias)), nil
+}
+
+func (printer *SourcePrinter) VisitExportStatement(stmt grammar.ExportStatement) (any, grammar.LoxError) {
+	return "export " + printer.inline(printer.indent, stmt.Declaration), nil
+}
+
+func (printer *SourcePrinter) function(indent int, function grammar.FunctionDeclarationStatement) string {
+	params := make([]string, 0, len(function.Params))
+	for index, param := range function.Params {
+		var paramType *grammar.TypeAnnotation
+		if index < len(function.ParamTypes) {
+			paramType = function.ParamTypes[index]
+		}
+		params = append(params, lexeme(param)+annotation(paramType))
+	}
+	signature := lexeme(function.Name) + "(" + strings.Join(params, ", ") + ")" + annotation(function.ReturnType)
+	return signature + " " + printer.block(indent, function.Body)
+}
+
+func (printer *SourcePrinter) block(indent int, block grammar.BlockScopeStatement) string {
+	var builder strings.Builder
+	for _, stmt := range block.Statements {
+		printer.statement(&builder, indent+1, stmt)
+	}
+	if builder.Len() == 0 {
+		return "{}"
+	}
+	return "{\n" + builder.String() + strings.Repeat(SOURCE_INDENT, indent) + "}"
+}
+
+// branch prints the body of an if or a loop: a block on the same line, any
+// other statement after a space.
+func (printer *SourcePrinter) branch(indent int, stmt grammar.Statement) string {
+	if stmt == nil {
+		return ";"
+	}
+	return " " + printer.inline(indent, stmt)
+}
+
+// forLoop undoes Parser.forStatement. The parser wraps the loop and its
+// initializer in a braceless block, wraps the body and increment in another
+// one, and stands in true for a missing condition.
+func (printer *SourcePrinter) forLoop(indent int, initializer grammar.Statement, loop grammar.WhileLoopStatement) string {
+	clauses := ";"
+	if initializer != nil {
+		clauses = printer.inline(indent, initializer)
+	}
+	if literal, ok := loop.Condition.(grammar.LiteralExpression); !ok || literal.Literal != true {
+		clauses += " " + printer.expression(loop.Condition)
+	}
+	clauses += ";"
+	body := loop.Body
+	if block, ok := body.(grammar.BlockScopeStatement); ok && block.Brace == (grammar.Token{}) && len(block.Statements) == 4 && block.Statements[0] == nil && block.Statements[1] == nil {
+		if increment, ok := block.Statements[3].(grammar.ExpressionStatement); ok {
+			clauses += " " + printer.expression(increment.Expression)
+			body = block.Statements[2]
+		}
+	}
+	return "for (" + clauses + ")" + printer.branch(indent, body)
+}
+
+// forInitializer recognizes the block the parser puts around a for loop
+// with an initializer.
+func forInitializer(block grammar.BlockScopeStatement) (grammar.WhileLoopStatement, bool) {
+	if block.Brace != (grammar.Token{}) || len(block.Statements) != 4 || block.Statements[0] != nil || block.Statements[1] != nil {
+		return grammar.WhileLoopStatement{}, false
+	}
+	loop, ok := block.Statements[3].(grammar.WhileLoopStatement)
+	return loop, ok && loop.Keyword.TokenType == grammar.FOR
+}
+
+func (printer *SourcePrinter) expression(expr grammar.Expression) string {
+	source, _ := expr.Accept(printer)
+	return source.(string)
+}
+
+func (printer *SourcePrinter) VisitLiteralExpression(expr grammar.LiteralExpression) (any, grammar.LoxError) {
+	switch literal := expr.Literal.(type) {
+	case nil:
+		return "null", nil
+	case float64:
+		return strconv.FormatFloat(literal, 'f', -1, 64), nil
+	case string:
+		return "\"" + literal + "\"", nil
+	default:
+		return fmt.Sprint(literal), nil
+	}
+}
+
+func (printer *SourcePrinter) VisitGroupingExpression(expr grammar.GroupingExpression) (any, grammar.LoxError) {
+	return "(" + printer.expression(expr.Expression) + ")", nil
+}
+
+func (printer *SourcePrinter) VisitUnaryExpression(expr grammar.UnaryExpression) (any, grammar.LoxError) {
+	right := printer.expression(expr.Right)
+	if strings.HasPrefix(right, lexeme(expr.Operator)) {
+		right = " " + right
+	}
+	return lexeme(expr.Operator) + right, nil
+}
+
+func (printer *SourcePrinter) VisitBinaryExpression(expr grammar.BinaryExpression) (any, grammar.LoxError) {
+	return printer.expression(expr.Left) + " " + lexeme(expr.Operator) + " " + printer.expression(expr.Right), nil
+}
+
+func (printer *SourcePrinter) VisitLogicExpression(expr grammar.LogicExpression) (any, grammar.LoxError) {
+	return printer.expression(expr.Left) + " " + lexeme(expr.Operator) + " " + printer.expression(expr.Right), nil
+}
+
+func (printer *SourcePrinter) VisitVariableDeclaration(expr grammar.VariableDeclaration) (any, grammar.LoxError) {
+	return lexeme(expr.Name), nil
+}
+
+func (printer *SourcePrinter) VisitAssignmentExpression(expr grammar.AssignmentExpression) (any, grammar.LoxError) {
+	return lexeme(expr.Name) + " = " + printer.expression(expr.Value), nil
+}
+
+func (printer *SourcePrinter) VisitCallExpression(expr grammar.CallExpression) (any, grammar.LoxError) {
+	arguments := make([]string, 0, len(expr.Arguments))
+	for _, argument := range expr.Arguments {
+		arguments = append(arguments, printer.expression(argument))
+	}
+	return printer.expression(expr.Callee) + "(" + strings.Join(arguments, ", ") + ")", nil
+}
+
+func (printer *SourcePrinter) VisitPropertyAccessExpression(expr grammar.PropertyAccessExpression) (any, grammar.LoxError) {
+	return printer.expression(expr.Object) + "." + lexeme(expr.Name), nil
+}
+
+func (printer *SourcePrinter) VisitPropertyAssignmentExpression(expr grammar.PropertyAssignmentExpression) (any, grammar.LoxError) {
+	return printer.expression(expr.Object) + "." + lexeme(expr.Name) + " = " + printer.expression(expr.Value), nil
+}
+
+func (printer *SourcePrinter) VisitSelfReferenceExpression(expr grammar.SelfReferenceExpression) (any, grammar.LoxError) {
+	return "this", nil
+}
+
+func (printer *SourcePrinter) VisitBaseClassCallExpression(expr grammar.BaseClassCallExpression) (any, grammar.LoxError) {
+	return "super." + lexeme(expr.Method), nil
+}
+
+func annotation(annotation *grammar.TypeAnnotation) string {
+	if annotation == nil {
+		return ""
+	}
+	return ": " + lexeme(annotation.Name)
+}
+
+func lexeme(token grammar.Token) string {
+	return fmt.Sprint(token.Lexeme)
+}
@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/DrEmbryo/jlox/src/ast"
+	"github.com/DrEmbryo/jlox/src/grammar"
+	"github.com/DrEmbryo/jlox/src/lexer"
+	"github.com/DrEmbryo/jlox/src/parser"
+)
+
+func parse(t *testing.T, source string) []grammar.Statement {
+	t.Helper()
+	lexer := &lexer.Lexer{Source: []rune(source)}
+	tokens, errs := lexer.Tokenize()
+	if len(errs) > 0 {
+		t.Fatalf("lexer errors %v in\n%s", errs, source)
+	}
+	parser := parser.Parser{Tokens: tokens}
+	stmts, err := parser.Parse()
+	if err != nil {
+		t.Fatalf("parser error %v in\n%s", err, source)
+	}
+	return stmts
+}
+
+var positions = regexp.MustCompile(`\s*"(line|column)": \d+,?`)
+
+// tree dumps a program without token positions, which printing moves.
+func tree(t *testing.T, stmts []grammar.Statement) string {
+	t.Helper()
+	data, err := ast.JSON(stmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return positions.ReplaceAllString(string(data), "")
+}
+
+func TestSourcePrinter(t *testing.T) {
+	var tests = []struct {
+		name   string
+		source string
+		expect string
+	}{
+		{"expressions", "print -(1+2.5)*3 == - -a and !b or null;", "print -(1 + 2.5) * 3 == - -a and !b or null;\n"},
+		{"variables", "var a:number=1;var b;b=\"two\";", "var a: number = 1;\nvar b;\nb = \"two\";\n"},
+		{"calls and properties", "a.b(1,2)(3).c=this.d;", "a.b(1, 2)(3).c = this.d;\n"},
+		{"functions", "func f(a:number,b):string{return;}", "func f(a: number, b): string {\n  return;\n}\n"},
+		{"classes", "class A{}class B<A{x:number;get(){return super.get();}}", "class A {}\nclass B < A {\n  x: number;\n  get() {\n    return super.get();\n  }\n}\n"},
+		{"conditionals", "if(a){}else if(b)print 1;else{print 2;}", "if (a) {} else if (b) print 1;\nelse {\n  print 2;\n}\n"},
+		{"while", "while(a<3)a=a+1;", "while (a < 3) a = a + 1;\n"},
+		{"for", "for(var i=0;i<3;i=i+1)print i;", "for (var i = 0; i < 3; i = i + 1) print i;\n"},
+		{"for without clauses", "for(;;){}", "for (;;) {}\n"},
+		{"for with expression initializer", "for(i=0;;){print i;}", "for (i = 0;;) {\n  print i;\n}\n"},
+		{"for without initializer", "{for(;i<3;i=i+1){}}", "{\n  for (; i < 3; i = i + 1) {}\n}\n"},
+		{"modules", "import \"lib.lox\" as lib;export func f(){}", "import \"lib.lox\" as lib;\nexport func f() {}\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			printer := SourcePrinter{}
+			stmts := parse(t, tc.source)
+			source := printer.Source(stmts)
+			if source != tc.expect {
+				t.Errorf("got\n%s\nwant\n%s", source, tc.expect)
+			}
+			if got, want := tree(t, parse(t, source)), tree(t, stmts); got != want {
+				t.Errorf("reparsing changed the tree:\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestSourcePrinterRoundTripsTestdata(t *testing.T) {
+	paths, err := filepath.Glob("../conformance/testdata/*/*.lox")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no scripts found: %v", err)
+	}
+	for _, path := range paths {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lexer := &lexer.Lexer{Source: []rune(string(source))}
+		tokens, errs := lexer.Tokenize()
+		if len(errs) > 0 {
+			continue
+		}
+		parser := parser.Parser{Tokens: tokens}
+		stmts, parseErr := parser.Parse()
+		if parseErr != nil {
+			continue
+		}
+		printer := SourcePrinter{}
+		printed := printer.Source(stmts)
+		if got, want := tree(t, parse(t, printed)), tree(t, stmts); got != want {
+			t.Errorf("%s: reparsing changed the tree, printed\n%s", path, printed)
+		}
+	}
+}